@@ -42,6 +42,9 @@ type Config struct {
 	OAICandidate llm.Config        `yaml:"oai_candidate"`
 	StyleTTS     ai.StyleTTSConfig `yaml:"tts"`
 	IndexTTS   ai.IndexTTSConfig `yaml:"index_tts"`
+	// TTSEngines adds engines cards can pick next to the built-in "index"
+	// and "style".
+	TTSEngines ai.TTSRegistryConfig `yaml:"tts_engines"`
 	Whisper    whisperx.Config   `yaml:"whisper"`
 
 	Twitch twitch.Config `yaml:"twitch"`
@@ -58,6 +61,9 @@ type Config struct {
 type IngestConfig struct {
 	Host string `yaml:"host"`
 	Port int    `yaml:"port"`
+
+	// EventSub opens an EventSub WebSocket session per channel next to IRC.
+	EventSub bool `yaml:"eventsub"`
 }
 
 type InfluxConfig struct {
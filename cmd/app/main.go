@@ -26,6 +26,7 @@ import (
 	"app/pkg/ffmpeg"
 	"app/pkg/llm"
 	"app/pkg/llmfilter"
+	"app/pkg/memdistill"
 	"app/pkg/oai"
 	"app/pkg/s3client"
 	"app/pkg/twitch"
@@ -72,13 +73,24 @@ func main() {
 
 	// Character replies use cydonia (chat format). To fall back to lexi, swap to:
 	//   var characterLlm processor.CharacterLLM = llm.CompletionClient{Client: llmModel}
-	var characterLlm processor.CharacterLLM = llm.ChatClient{Client: llm.New(httpClient, &cfg.LLM2)}
+	characterLlmClient := llm.New(httpClient, &cfg.LLM2)
+	var characterLlm processor.CharacterLLM = llm.ChatClient{Client: characterLlmClient}
 	oaiClient := oai.New(cfg.OAI.AccessToken, cfg.OAI.URL, cfg.OAI.Model, cfg.OAI.MaxTokens)
 	textFilter := llmfilter.New(oaiClient)
 	ffmpegClient := ffmpeg.New(&cfg.Ffmpeg)
 	chatTTSEngine := ai.NewStyleTTSClient(httpClient, &cfg.StyleTTS)
 	indexClient := ai.NewIndexTTSClient(httpClient, &cfg.IndexTTS)
 	ttsEngine := ai.NewIndexTTSEngine(indexClient, ffmpegClient)
+	ttsEngines := ai.NewTTSRegistry()
+	if err := ttsEngines.Register(ai.IndexTTSEngineName, ttsEngine, ai.TTSParams{}); err != nil {
+		log.Fatal("failed to register tts engine: ", err)
+	}
+	if err := ttsEngines.Register(ai.StyleTTSEngineName, chatTTSEngine, ai.TTSParams{}); err != nil {
+		log.Fatal("failed to register tts engine: ", err)
+	}
+	if err := ttsEngines.Configure(httpClient, ffmpegClient, &cfg.TTSEngines); err != nil {
+		log.Fatal("failed to configure tts engines: ", err)
+	}
 	whisper := whisperx.New(httpClient, &cfg.Whisper)
 
 	s3, err := s3client.New(ctx, &cfg.S3)
@@ -92,13 +104,16 @@ func main() {
 	if err := s3.EnsureBucket(ctx, s3client.CharDataBucket); err != nil {
 		log.Fatal("failed to ensure s3 char data bucket: ", err)
 	}
+	if err := s3.EnsureBucket(ctx, s3client.TracksBucket); err != nil {
+		log.Fatal("failed to ensure s3 tracks bucket: ", err)
+	}
 
 	// attach s3 to db so it can transparently store media
 	db.AttachS3Client(s3)
 
 	connManager := conns.NewConnectionManager(ctx, logger.WithGroup("conns"), nil)
 
-	procService := processor.NewService(logger.WithGroup("service"), db, s3, ffmpegClient, ttsEngine, chatTTSEngine, whisper, llmModel, imageLlm, textFilter, connManager)
+	procService := processor.NewService(logger.WithGroup("service"), db, s3, ffmpegClient, ttsEngine, chatTTSEngine, ttsEngines, whisper, llmModel, imageLlm, textFilter, connManager)
 
 	aiHandler := processor.NewAIHandler(logger.WithGroup("ai_handler"), characterLlm, imageLlm, cfg.NativeImages, db, s3, procService)
 	ttsHandler := processor.NewTTSHandler(logger.WithGroup("tts_handler"), db, procService)
@@ -107,15 +122,16 @@ func main() {
 	agenticDetector := agentic.NewDetector(oaiClient)
 	agenticPlanner := agentic.NewPlanner(oaiClient)
 	agenticHandler := processor.NewAgenticHandler(logger.WithGroup("agentic_handler"), db, agenticDetector, agenticPlanner, characterLlm, procService)
+	memorySuggester := processor.NewMemorySuggester(logger.WithGroup("memory_suggester"), db, memdistill.New(oaiClient), characterLlmClient)
 	chatTTSHandler := processor.NewChatTTSHandler(logger.WithGroup("chat_tts_handler"), db, procService)
 
-	proc := processor.NewProcessor(logger.WithGroup("processor"), db, connManager, aiHandler, ttsHandler, universalHandler, agenticHandler, chatTTSHandler)
+	twitchClient := twitch.New(httpClient, &cfg.Twitch)
 
-	conns.SetProcessor(connManager, proc)
+	proc := processor.NewProcessor(logger.WithGroup("processor"), db, connManager, aiHandler, ttsHandler, universalHandler, agenticHandler, chatTTSHandler, twitchClient)
 
-	twitchClient := twitch.New(httpClient, &cfg.Twitch)
+	conns.SetProcessor(connManager, proc)
 
-	api := api.NewAPI(&cfg.Api, cfg.Ingest.Host, cfg.Ingest.Port, logger.WithGroup("api"), connManager, twitchClient, db, s3, ttsHandler, aiHandler, universalHandler, agenticHandler, procService)
+	api := api.NewAPI(&cfg.Api, cfg.Ingest.Host, cfg.Ingest.Port, logger.WithGroup("api"), connManager, twitchClient, db, s3, ttsHandler, aiHandler, universalHandler, agenticHandler, procService, procService, characterLlmClient, procService)
 
 	router := api.NewRouter()
 
@@ -176,6 +192,26 @@ func main() {
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(time.Hour)
+
+	loop:
+		for {
+			select {
+			case <-ticker.C:
+				if err := memorySuggester.SuggestMemories(ctx); err != nil {
+					logger.Error("failed to suggest memories", "err", err)
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				break loop
+			}
+		}
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -45,7 +45,7 @@ func main() {
 	}
 	defer database.Close()
 
-	svc := ingest.NewService(logger.WithGroup("ingest"), database, &cfg.Twitch)
+	svc := ingest.NewService(logger.WithGroup("ingest"), database, &cfg.Twitch, cfg.Ingest.EventSub)
 
 	reg := prometheus.NewRegistry()
 	ingest.RegisterMetrics(reg)
@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const DefaultArchiveRetentionDays = 90
+
+// ArchiveRetention is how long finished messages stay searchable.
+func (c *Config) ArchiveRetention() time.Duration {
+	days := c.ArchiveRetentionDays
+	if days <= 0 {
+		days = DefaultArchiveRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// archiveFinishedCTE copies the rows of a preceding "finished" CTE, which
+// returns updated msg_queue rows, into msg_archive once they are processed
+// or deleted. Running in the status update itself keeps the archive from
+// missing a row however the message ended. Character and reward type are
+// resolved now; the reward may be gone by the time anyone looks.
+var archiveFinishedCTE = fmt.Sprintf(`
+	archived as (
+		insert into msg_archive (id, user_id, status, twitch_login, twitch_user_id, reward_type, card_id, char_name, request, response, msg, data, created_at)
+		select
+			f.id,
+			f.user_id,
+			f.status,
+			coalesce(f.msg->>'twitch_login', ''),
+			coalesce((f.msg->>'twitch_user_id')::integer, 0),
+			case when f.msg->'event' is not null then %[3]d else rb.reward_type end,
+			coalesce(cc.id, ecc.id),
+			coalesce(cc.name, ecc.name, ''),
+			coalesce(f.msg->>'message', ''),
+			coalesce(f.data->>'ai_response', ''),
+			f.msg,
+			f.data,
+			uuid_v7_to_timestamptz(f.id)
+		from finished f
+		left join reward_buttons rb on rb.user_id = f.user_id and rb.twitch_reward_id = f.msg->>'reward_id'
+		left join char_cards cc on cc.id = rb.card_id
+		left join event_rules er on er.user_id = f.user_id and er.event_type = f.msg->'event'->>'type'
+		left join char_cards ecc on ecc.id = er.card_id
+		where f.status in (%[1]d, %[2]d)
+		on conflict (id) do update
+		set status = excluded.status, response = excluded.response, data = excluded.data
+	)
+`, MsgStatusProcessed, MsgStatusDeleted, TwitchRewardEvent)
+
+// ArchiveRetention is how long this DB keeps archived messages.
+func (db *DB) ArchiveRetention() time.Duration {
+	return db.archiveRetention
+}
+
+// PruneArchive drops archived messages, their tracks and unflagged filter
+// runs past the retention.
+func (db *DB) PruneArchive(ctx context.Context) (int, error) {
+	tag, err := db.Exec(ctx, `
+		delete from msg_archive
+		where archived_at < now() - $1::interval
+	`, db.archiveRetention)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune message archive: %w", err)
+	}
+
+	if err := db.pruneTracks(ctx); err != nil {
+		return 0, err
+	}
+	if err := db.pruneFilterRuns(ctx); err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+type ArchivedMsg struct {
+	ID uuid.UUID
+
+	Status MsgStatus
+
+	TwitchLogin  string
+	TwitchUserID int
+
+	RewardType *TwitchRewardType
+	CardID     *uuid.UUID
+	CharName   string
+
+	Request  string
+	Response string
+
+	Data []byte
+
+	// HasTracks is set when the message's audio was kept for export.
+	HasTracks bool
+
+	CreatedAt time.Time
+}
+
+const archivedMsgColumns = `
+	id,
+	status,
+	twitch_login,
+	twitch_user_id,
+	reward_type,
+	card_id,
+	char_name,
+	request,
+	response,
+	data,
+	exists (select 1 from msg_tracks t where t.msg_id = msg_archive.id),
+	created_at
+`
+
+func scanArchivedMsg(row rowScanner) (*ArchivedMsg, error) {
+	var msg ArchivedMsg
+	err := row.Scan(
+		&msg.ID,
+		&msg.Status,
+		&msg.TwitchLogin,
+		&msg.TwitchUserID,
+		&msg.RewardType,
+		&msg.CardID,
+		&msg.CharName,
+		&msg.Request,
+		&msg.Response,
+		&msg.Data,
+		&msg.HasTracks,
+		&msg.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+func (db *DB) GetArchivedMsg(ctx context.Context, userID uuid.UUID, msgID uuid.UUID) (*ArchivedMsg, error) {
+	msg, err := scanArchivedMsg(db.QueryRow(ctx, `
+		select `+archivedMsgColumns+`
+		from msg_archive
+		where user_id = $1 and id = $2
+	`, userID, msgID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived message: %w", parseErr(err))
+	}
+
+	return msg, nil
+}
+
+// ArchiveFilter narrows a history search. Zero fields match everything;
+// Viewer, CharName and Text match substrings, case-insensitively.
+type ArchiveFilter struct {
+	Viewer   string
+	CharName string
+	Text     string
+
+	// RewardType of -1 is chat TTS, the messages without a reward.
+	RewardType *TwitchRewardType
+
+	From time.Time
+	To   time.Time
+}
+
+// ChatTTSRewardType stands for "no reward" in ArchiveFilter.RewardType.
+const ChatTTSRewardType TwitchRewardType = -1
+
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// SearchArchive pages through the channel's archived messages, newest first.
+// Fetching one row past limit tells the caller whether there is a next page.
+func (db *DB) SearchArchive(ctx context.Context, userID uuid.UUID, filter *ArchiveFilter, limit, offset int) ([]*ArchivedMsg, error) {
+	where := []string{"user_id = $1"}
+	args := []any{userID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// ilike with a leading wildcard still uses the trigram indexes
+	if filter.Viewer != "" {
+		where = append(where, "twitch_login ilike "+arg(likePattern(filter.Viewer)))
+	}
+	if filter.CharName != "" {
+		where = append(where, "char_name ilike "+arg(likePattern(filter.CharName)))
+	}
+	if filter.Text != "" {
+		where = append(where, "(request || ' ' || response) ilike "+arg(likePattern(filter.Text)))
+	}
+	if filter.RewardType != nil {
+		if *filter.RewardType == ChatTTSRewardType {
+			where = append(where, "reward_type is null")
+		} else {
+			where = append(where, "reward_type = "+arg(*filter.RewardType))
+		}
+	}
+	if !filter.From.IsZero() {
+		where = append(where, "created_at >= "+arg(filter.From))
+	}
+	if !filter.To.IsZero() {
+		where = append(where, "created_at < "+arg(filter.To))
+	}
+
+	rows, err := db.Query(ctx, `
+		select `+archivedMsgColumns+`
+		from msg_archive
+		where `+strings.Join(where, " and ")+`
+		order by created_at desc, id desc
+		limit `+arg(limit)+` offset `+arg(offset), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search message archive: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []*ArchivedMsg
+	for rows.Next() {
+		msg, err := scanArchivedMsg(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan archived message: %w", err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan archived messages: %w", err)
+	}
+
+	return msgs, nil
+}
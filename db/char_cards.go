@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type Card struct {
@@ -33,6 +34,14 @@ type Card struct {
 	CreatedAt time.Time
 
 	Data *CardData
+
+	// Memories is not a column: the processor fills it per request with the
+	// already-filtered lines the chat prompt renders as "Things you remember".
+	Memories []string
+
+	// ScriptGuide is not a column either: for cards in script mode the
+	// processor fills it with the syntax the reply may be performed in.
+	ScriptGuide string
 }
 
 // CharacterBasicInfo represents minimal character information for detection
@@ -41,6 +50,7 @@ type CharacterBasicInfo struct {
 	Name        string
 	ShortName   string
 	Description string
+	Aliases     []string
 }
 
 type MessageExample struct {
@@ -61,6 +71,48 @@ type CardData struct {
 
 	ImageID string `json:"image_id,omitempty"`
 	VoiceID string `json:"voice_id,omitempty"`
+
+	// TTS picks the engine the character speaks with; nil is the default
+	// engine with its own settings.
+	TTS *CardTTS `json:"tts,omitempty"`
+
+	// Script lets the character perform its reply as a skit in universal TTS
+	// syntax; nil keeps a monologue in its own voice.
+	Script *CardScript `json:"script,omitempty"`
+
+	// Aliases are other names chat knows the character by, normalized to
+	// lowercase words. Agent BAJ detection matches them like the name.
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// CardScript is the script mode of a character. Its own voice narrates.
+type CardScript struct {
+	// Cast are the public short names the model is offered; any public
+	// voice still plays if the model names it.
+	Cast []string `json:"cast,omitempty"`
+}
+
+// CardTTS tunes the voice of a character. Zero values keep the engine's
+// defaults, and each engine ignores what it doesn't support.
+type CardTTS struct {
+	Engine string `json:"engine,omitempty"`
+
+	EmoWeight                float64 `json:"emo_weight,omitempty"`
+	MaxTextTokensPerSentence int     `json:"max_text_tokens_per_sentence,omitempty"`
+	// EmoVector is the character's emotion when a message names none, one
+	// weight per emotion in IndexTTS order.
+	EmoVector []float64 `json:"emo_vector,omitempty"`
+
+	Alpha *float64 `json:"alpha,omitempty"`
+	Beta  *float64 `json:"beta,omitempty"`
+
+	Voice string `json:"voice,omitempty"`
+
+	// Speed is a tempo factor, Pitch a shift in semitones and Gain a
+	// loudness offset in dB, applied to the speech of any engine.
+	Speed float64 `json:"speed,omitempty"`
+	Pitch float64 `json:"pitch,omitempty"`
+	Gain  float64 `json:"gain,omitempty"`
 }
 
 type PublicShortName struct {
@@ -537,7 +589,8 @@ func (db *DB) GetAllCharacterBasicInfo(ctx context.Context) ([]CharacterBasicInf
 			id,
 			name,
 			coalesce(short_char_name, ''),
-			coalesce(description, '')
+			coalesce(description, ''),
+			coalesce(data->'aliases', '[]'::jsonb)
         from char_cards
         where public = true
         order by name asc
@@ -545,12 +598,76 @@ func (db *DB) GetAllCharacterBasicInfo(ctx context.Context) ([]CharacterBasicInf
 	if err != nil {
 		return nil, fmt.Errorf("get all character basic info: %w", err)
 	}
+
+	return collectCharacterBasicInfo(rows)
+}
+
+// GetCharacterCandidates shortlists public characters for detection: up to
+// maxMatches whose name is like one of terms or whose short name or alias
+// equals one, closest first, then the fill most redeemed others. Terms are
+// lowercase, as agentic.CandidateTerms makes them.
+func (db *DB) GetCharacterCandidates(ctx context.Context, terms []string, maxMatches, fill int) ([]CharacterBasicInfo, error) {
+	rows, err := db.Query(ctx, `
+        with terms as (
+			select distinct unnest($1::text[]) as term
+		),
+		matches as (
+			select c.id, similarity(c.name, t.term) as score
+			from char_cards c
+			join terms t on c.name % t.term
+			where c.public = true
+			union all
+			select c.id, 1::real
+			from char_cards c
+			join terms t on lower(c.short_char_name) = t.term
+			where c.public = true
+			union all
+			select c.id, 1::real
+			from char_cards c
+			join terms t on c.data->'aliases' ? t.term
+			where c.public = true
+		),
+		ranked as (
+			select id, max(score) as score from matches
+			group by id
+			order by score desc
+			limit $2
+		),
+		popular as (
+			select id, 0::real as score from char_cards
+			where public = true and id not in (select id from ranked)
+			order by redeems desc
+			limit $3
+		),
+		shortlist as (
+			select * from ranked
+			union all
+			select * from popular
+		)
+        select
+			c.id,
+			c.name,
+			coalesce(c.short_char_name, ''),
+			coalesce(c.description, ''),
+			coalesce(c.data->'aliases', '[]'::jsonb)
+        from shortlist s
+		join char_cards c on c.id = s.id
+        order by s.score desc, c.redeems desc, c.name asc
+    `, terms, maxMatches, fill)
+	if err != nil {
+		return nil, fmt.Errorf("get character candidates: %w", err)
+	}
+
+	return collectCharacterBasicInfo(rows)
+}
+
+func collectCharacterBasicInfo(rows pgx.Rows) ([]CharacterBasicInfo, error) {
 	defer rows.Close()
 
 	var out []CharacterBasicInfo
 	for rows.Next() {
 		var char CharacterBasicInfo
-		if err := rows.Scan(&char.ID, &char.Name, &char.ShortName, &char.Description); err != nil {
+		if err := rows.Scan(&char.ID, &char.Name, &char.ShortName, &char.Description, &char.Aliases); err != nil {
 			return nil, fmt.Errorf("scan character basic info: %w", err)
 		}
 		out = append(out, char)
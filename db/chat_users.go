@@ -62,3 +62,62 @@ func (db *DB) IncrementChatUserRewardCount(ctx context.Context, twitchUserID int
 	}
 	return nil
 }
+
+// OptOutChatUserMemory records the viewer's ^^optout and, in the same
+// statement, purges the memories tagged with them and their interaction log.
+// It cannot reach free-text memories that merely mention the viewer.
+func (db *DB) OptOutChatUserMemory(ctx context.Context, twitchUserID int, twitchLogin string) (int, int, error) {
+	var memories, interactions int
+	err := db.QueryRow(ctx, `
+		WITH opted_out AS (
+			INSERT INTO chat_users (twitch_user_id, twitch_login, memory_opt_out)
+			VALUES ($1, $2, true)
+			ON CONFLICT (twitch_user_id) DO UPDATE
+			SET memory_opt_out = true, twitch_login = $2, updated_at = now()
+		),
+		memories AS (
+			DELETE FROM char_memories
+			WHERE subject_twitch_user_id = $1
+			RETURNING 1
+		),
+		interactions AS (
+			DELETE FROM char_interactions
+			WHERE twitch_user_id = $1
+			RETURNING 1
+		)
+		SELECT
+			(SELECT count(*) FROM memories),
+			(SELECT count(*) FROM interactions)
+	`, twitchUserID, twitchLogin).Scan(&memories, &interactions)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to opt out chat user memory: %w", err)
+	}
+	return memories, interactions, nil
+}
+
+func (db *DB) OptInChatUserMemory(ctx context.Context, twitchUserID int, twitchLogin string) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO chat_users (twitch_user_id, twitch_login, memory_opt_out)
+		VALUES ($1, $2, false)
+		ON CONFLICT (twitch_user_id) DO UPDATE
+		SET memory_opt_out = false, twitch_login = $2, updated_at = now()
+	`, twitchUserID, twitchLogin)
+	if err != nil {
+		return fmt.Errorf("failed to opt in chat user memory: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) IsChatUserMemoryOptOut(ctx context.Context, twitchUserID int) (bool, error) {
+	var optOut bool
+	err := db.QueryRow(ctx, `
+		SELECT memory_opt_out FROM chat_users WHERE twitch_user_id = $1
+	`, twitchUserID).Scan(&optOut)
+	if err != nil {
+		if ErrCode(parseErr(err)) == ErrCodeNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get chat user memory opt out: %w", err)
+	}
+	return optOut, nil
+}
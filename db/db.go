@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -11,10 +12,16 @@ import (
 type DB struct {
 	*pgxpool.Pool
 	s3 S3Provider
+
+	archiveRetention time.Duration
 }
 
 type Config struct {
 	ConnStr string `yaml:"conn_str"`
+
+	// ArchiveRetentionDays is how long finished messages stay in the
+	// history; 0 means DefaultArchiveRetentionDays.
+	ArchiveRetentionDays int `yaml:"archive_retention_days"`
 }
 
 func New(ctx context.Context, cfg *Config) (*DB, error) {
@@ -27,7 +34,7 @@ func New(ctx context.Context, cfg *Config) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping db: %w", err)
 	}
 
-	db := &DB{Pool: pool}
+	db := &DB{Pool: pool, archiveRetention: cfg.ArchiveRetention()}
 
 	return db, nil
 }
@@ -37,6 +44,7 @@ type S3Provider interface {
 	EnsureBucket(ctx context.Context, bucket string) error
 	PutObject(ctx context.Context, bucket string, objectName string, reader io.Reader, size int64, contentType string) error
 	GetObject(ctx context.Context, bucket string, objectName string) (io.ReadCloser, error)
+	RemoveObject(ctx context.Context, bucket string, objectName string) error
 }
 
 // AttachS3Client injects an S3 client into DB for media storage
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageEventTypes lists the events a rule can react to, in page order.
+var MessageEventTypes = []string{MessageEventFollow, MessageEventSub, MessageEventGift, MessageEventRaid}
+
+// EventRule makes a character react to a channel event. MinThreshold is
+// compared against raid viewers and gifted sub count; follows and subs
+// ignore it.
+type EventRule struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+
+	EventType string
+
+	CardID   uuid.UUID
+	CardName string
+
+	Prompt       string
+	MinThreshold int
+	Enabled      bool
+
+	UpdatedAt time.Time
+}
+
+// Matches reports whether the rule reacts to ev.
+func (r *EventRule) Matches(ev *MessageEvent) bool {
+	if !r.Enabled || ev == nil || ev.Type != r.EventType {
+		return false
+	}
+
+	switch ev.Type {
+	case MessageEventRaid:
+		return ev.Viewers >= r.MinThreshold
+	case MessageEventGift:
+		return ev.Total >= r.MinThreshold
+	default:
+		return true
+	}
+}
+
+// RenderPrompt fills {user}, {viewers}, {total} and {tier} into the rule's
+// prompt template.
+func (r *EventRule) RenderPrompt(msg *TwitchMessage) string {
+	ev := msg.Event
+	if ev == nil {
+		ev = &MessageEvent{}
+	}
+
+	return strings.NewReplacer(
+		"{user}", msg.TwitchLogin,
+		"{viewers}", fmt.Sprint(ev.Viewers),
+		"{total}", fmt.Sprint(ev.Total),
+		"{tier}", TierName(ev.Tier),
+	).Replace(r.Prompt)
+}
+
+// TierName turns a sub tier ("1000", "Prime") into "Tier 1" or "Prime".
+func TierName(tier string) string {
+	switch tier {
+	case "1000", "2000", "3000":
+		return "Tier " + tier[:1]
+	case "":
+		return "Tier 1"
+	default:
+		return tier
+	}
+}
+
+const eventRuleColumns = `
+	er.id,
+	er.user_id,
+	er.event_type,
+	er.card_id,
+	cc.name,
+	er.prompt,
+	er.min_threshold,
+	er.enabled,
+	er.updated_at
+`
+
+func scanEventRule(row rowScanner) (*EventRule, error) {
+	var r EventRule
+	err := row.Scan(
+		&r.ID,
+		&r.UserID,
+		&r.EventType,
+		&r.CardID,
+		&r.CardName,
+		&r.Prompt,
+		&r.MinThreshold,
+		&r.Enabled,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (db *DB) GetEventRule(ctx context.Context, userID uuid.UUID, eventType string) (*EventRule, error) {
+	rule, err := scanEventRule(db.QueryRow(ctx, `
+		select `+eventRuleColumns+`
+		from event_rules er
+		join char_cards cc on cc.id = er.card_id
+		where er.user_id = $1 and er.event_type = $2
+	`, userID, eventType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event rule: %w", parseErr(err))
+	}
+
+	return rule, nil
+}
+
+func (db *DB) GetEventRules(ctx context.Context, userID uuid.UUID) ([]*EventRule, error) {
+	rows, err := db.Query(ctx, `
+		select `+eventRuleColumns+`
+		from event_rules er
+		join char_cards cc on cc.id = er.card_id
+		where er.user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*EventRule
+	for rows.Next() {
+		rule, err := scanEventRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get event rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (db *DB) UpsertEventRule(ctx context.Context, rule *EventRule) error {
+	_, err := db.Exec(ctx, `
+		insert into event_rules (
+			user_id,
+			event_type,
+			card_id,
+			prompt,
+			min_threshold,
+			enabled
+		)
+		values ($1, $2, $3, $4, $5, $6)
+		on conflict (user_id, event_type) do update
+		set
+			card_id = excluded.card_id,
+			prompt = excluded.prompt,
+			min_threshold = excluded.min_threshold,
+			enabled = excluded.enabled,
+			updated_at = now()
+	`, rule.UserID, rule.EventType, rule.CardID, rule.Prompt, rule.MinThreshold, rule.Enabled)
+	if err != nil {
+		return fmt.Errorf("failed to upsert event rule: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteEventRule(ctx context.Context, userID uuid.UUID, eventType string) error {
+	_, err := db.Exec(ctx, `
+		delete from event_rules
+		where user_id = $1 and event_type = $2
+	`, userID, eventType)
+	if err != nil {
+		return fmt.Errorf("failed to delete event rule: %w", err)
+	}
+
+	return nil
+}
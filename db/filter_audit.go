@@ -0,0 +1,242 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FilterPass names the filter pass that censored a span.
+type FilterPass string
+
+const (
+	FilterPassRegex    FilterPass = "regex"
+	FilterPassBuiltin  FilterPass = "builtin"
+	FilterPassStreamer FilterPass = "streamer"
+)
+
+// FilterRunKind is what a filter run judged.
+type FilterRunKind string
+
+const (
+	FilterRunRequest FilterRunKind = "request"
+	FilterRunReply   FilterRunKind = "reply"
+	// FilterRunInterjection is a viewer's ^^say line in a running dialogue,
+	// recorded under the dialogue's message apart from its request.
+	FilterRunInterjection FilterRunKind = "interjection"
+)
+
+// FilterRunSpan is a censored range of a run's target, in runes.
+type FilterRunSpan struct {
+	Start int        `json:"start"`
+	End   int        `json:"end"`
+	Pass  FilterPass `json:"pass"`
+}
+
+type FilterRun struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	MsgID  uuid.UUID
+	Kind   FilterRunKind
+
+	Target  string
+	Context string
+	Custom  string
+
+	// LLMSkipped is set when only the regex pass ran.
+	LLMSkipped bool
+
+	Spans []FilterRunSpan
+
+	CreatedAt time.Time
+}
+
+// FilterFlagKind is how a mod says a run got it wrong.
+type FilterFlagKind string
+
+const (
+	FilterFlagFalsePositive FilterFlagKind = "false_positive"
+	FilterFlagMissed        FilterFlagKind = "missed"
+)
+
+func (k FilterFlagKind) Valid() bool {
+	return k == FilterFlagFalsePositive || k == FilterFlagMissed
+}
+
+type FilterFlag struct {
+	ID    uuid.UUID
+	RunID uuid.UUID
+	Kind  FilterFlagKind
+
+	// Text is the substring of the run's target the flag is about.
+	Text      string
+	Note      string
+	FlaggedBy string
+
+	CreatedAt time.Time
+}
+
+func (db *DB) InsertFilterRun(ctx context.Context, run *FilterRun) error {
+	spans := run.Spans
+	if spans == nil {
+		spans = []FilterRunSpan{}
+	}
+
+	_, err := db.Exec(ctx, `
+		insert into filter_runs (user_id, msg_id, kind, target, context, custom, llm_skipped, spans)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, run.UserID, run.MsgID, run.Kind, run.Target, run.Context, run.Custom, run.LLMSkipped, spans)
+	if err != nil {
+		return fmt.Errorf("failed to insert filter run: %w", err)
+	}
+
+	return nil
+}
+
+const filterRunColumns = `
+	id,
+	user_id,
+	msg_id,
+	kind,
+	target,
+	context,
+	custom,
+	llm_skipped,
+	spans,
+	created_at
+`
+
+func scanFilterRun(row rowScanner) (*FilterRun, error) {
+	var run FilterRun
+	err := row.Scan(
+		&run.ID,
+		&run.UserID,
+		&run.MsgID,
+		&run.Kind,
+		&run.Target,
+		&run.Context,
+		&run.Custom,
+		&run.LLMSkipped,
+		&run.Spans,
+		&run.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// GetLatestFilterRun returns the message's most recent run of the kind; a
+// replay filters again, and the newest run is the one that played.
+func (db *DB) GetLatestFilterRun(ctx context.Context, userID, msgID uuid.UUID, kind FilterRunKind) (*FilterRun, error) {
+	run, err := scanFilterRun(db.QueryRow(ctx, `
+		select `+filterRunColumns+`
+		from filter_runs
+		where user_id = $1 and msg_id = $2 and kind = $3
+		order by created_at desc
+		limit 1
+	`, userID, msgID, kind))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter run: %w", parseErr(err))
+	}
+
+	return run, nil
+}
+
+func (db *DB) AddFilterFlag(ctx context.Context, flag *FilterFlag) error {
+	_, err := db.Exec(ctx, `
+		insert into filter_flags (run_id, kind, text, note, flagged_by)
+		values ($1, $2, $3, $4, $5)
+	`, flag.RunID, flag.Kind, flag.Text, flag.Note, flag.FlaggedBy)
+	if err != nil {
+		return fmt.Errorf("failed to add filter flag: %w", err)
+	}
+
+	return nil
+}
+
+// FlaggedFilterRun is a run with every flag mods put on it.
+type FlaggedFilterRun struct {
+	Run   *FilterRun
+	Flags []*FilterFlag
+}
+
+// GetFlaggedFilterRuns lists every flagged run across channels, oldest
+// first so an exported corpus keeps its order as it grows.
+func (db *DB) GetFlaggedFilterRuns(ctx context.Context) ([]*FlaggedFilterRun, error) {
+	rows, err := db.Query(ctx, `
+		select
+			`+filterRunColumns+`,
+			f.id,
+			f.kind,
+			f.text,
+			f.note,
+			f.flagged_by,
+			f.created_at
+		from filter_runs
+		join filter_flags f on f.run_id = filter_runs.id
+		order by filter_runs.created_at, filter_runs.id, f.created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flagged filter runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*FlaggedFilterRun
+	for rows.Next() {
+		var run FilterRun
+		var flag FilterFlag
+		err := rows.Scan(
+			&run.ID,
+			&run.UserID,
+			&run.MsgID,
+			&run.Kind,
+			&run.Target,
+			&run.Context,
+			&run.Custom,
+			&run.LLMSkipped,
+			&run.Spans,
+			&run.CreatedAt,
+			&flag.ID,
+			&flag.Kind,
+			&flag.Text,
+			&flag.Note,
+			&flag.FlaggedBy,
+			&flag.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan flagged filter run: %w", err)
+		}
+		flag.RunID = run.ID
+
+		if n := len(runs); n > 0 && runs[n-1].Run.ID == run.ID {
+			runs[n-1].Flags = append(runs[n-1].Flags, &flag)
+			continue
+		}
+		runs = append(runs, &FlaggedFilterRun{Run: &run, Flags: []*FilterFlag{&flag}})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan flagged filter runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// pruneFilterRuns drops unflagged runs past the archive retention; flagged
+// ones are the regression corpus and stay.
+func (db *DB) pruneFilterRuns(ctx context.Context) error {
+	_, err := db.Exec(ctx, `
+		delete from filter_runs r
+		where r.created_at < now() - $1::interval
+		and not exists (select 1 from filter_flags f where f.run_id = r.id)
+	`, db.archiveRetention)
+	if err != nil {
+		return fmt.Errorf("failed to prune filter runs: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CharInteraction is a finished, unskipped AI exchange as it was heard on
+// stream. Request and Reply hold the censored text only. Rows are never
+// edited apart from the distilled_at bookkeeping of the suggestion job.
+type CharInteraction struct {
+	ID uuid.UUID
+
+	UserID   uuid.UUID
+	CardID   uuid.UUID
+	CardName string // read-only, joined from char_cards
+
+	TwitchUserID   int
+	RequesterLogin string
+
+	Request string
+	Reply   string
+
+	CreatedAt time.Time
+}
+
+func (db *DB) InsertCharInteraction(ctx context.Context, interaction *CharInteraction) error {
+	var twitchUserID *int
+	if interaction.TwitchUserID != 0 {
+		twitchUserID = &interaction.TwitchUserID
+	}
+
+	_, err := db.Exec(ctx, `
+		insert into char_interactions (
+			user_id,
+			card_id,
+			twitch_user_id,
+			requester_login,
+			request,
+			reply
+		) values ($1, $2, $3, $4, $5, $6)
+	`,
+		interaction.UserID,
+		interaction.CardID,
+		twitchUserID,
+		interaction.RequesterLogin,
+		interaction.Request,
+		interaction.Reply,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert char interaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetUndistilledInteractions returns up to limit interactions the suggestion
+// job has not looked at yet, oldest first.
+func (db *DB) GetUndistilledInteractions(ctx context.Context, limit int) ([]*CharInteraction, error) {
+	rows, err := db.Query(ctx, `
+		select
+			ci.id,
+			ci.user_id,
+			ci.card_id,
+			cc.name,
+			coalesce(ci.twitch_user_id, 0),
+			ci.requester_login,
+			ci.request,
+			ci.reply,
+			ci.created_at
+		from char_interactions ci
+		join char_cards cc on cc.id = ci.card_id
+		where ci.distilled_at is null
+		order by ci.created_at asc
+		limit $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get undistilled interactions: %w", err)
+	}
+	defer rows.Close()
+
+	var interactions []*CharInteraction
+	for rows.Next() {
+		var i CharInteraction
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CardID,
+			&i.CardName,
+			&i.TwitchUserID,
+			&i.RequesterLogin,
+			&i.Request,
+			&i.Reply,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan char interaction: %w", err)
+		}
+		interactions = append(interactions, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get undistilled interactions: %w", err)
+	}
+
+	return interactions, nil
+}
+
+func (db *DB) MarkInteractionDistilled(ctx context.Context, id uuid.UUID) error {
+	_, err := db.Exec(ctx, `
+		update char_interactions
+		set distilled_at = now()
+		where id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark interaction distilled: %w", err)
+	}
+
+	return nil
+}
+
+// InsertSuggestedMemory stores mem as a suggestion distilled from the
+// interaction and marks the interaction distilled in the same statement, so
+// an interaction yields at most one suggestion even if the job runs twice.
+func (db *DB) InsertSuggestedMemory(ctx context.Context, interactionID uuid.UUID, mem *CharMemory) error {
+	_, err := db.Exec(ctx, `
+		with distilled as (
+			update char_interactions
+			set distilled_at = now()
+			where id = $1 and distilled_at is null
+			returning id
+		)
+		insert into char_memories (
+			user_id,
+			card_id,
+			subject_twitch_user_id,
+			text,
+			token_count,
+			suggested
+		)
+		select $2, $3, $4, $5, $6, true
+		from distilled
+		where not exists (
+			select 1 from chat_users
+			where twitch_user_id = $4 and memory_opt_out
+		)
+	`,
+		interactionID,
+		mem.UserID,
+		mem.CardID,
+		mem.SubjectTwitchUserID,
+		mem.Text,
+		mem.TokenCount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert suggested memory: %w", err)
+	}
+
+	return nil
+}
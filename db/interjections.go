@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// AgenticInterjectionCooldown is how long a viewer waits between two
+	// interjections on one channel.
+	AgenticInterjectionCooldown = 30 * time.Second
+
+	// agenticInterjectionMaxAge outlasts any dialogue; older rows are pruned.
+	agenticInterjectionMaxAge = time.Hour
+)
+
+// AgenticInterjection is a line a viewer sent into a running Agent BAJ
+// dialogue.
+type AgenticInterjection struct {
+	TwitchUserID int
+	TwitchLogin  string
+	Message      string
+
+	CreatedAt time.Time
+}
+
+// PushAgenticInterjection queues a viewer's line for the channel's running
+// dialogue. It reports false, storing nothing, while the viewer's previous
+// line is within the cooldown.
+func (db *DB) PushAgenticInterjection(ctx context.Context, userID uuid.UUID, twitchUserID int, twitchLogin, message string) (bool, error) {
+	var pushed bool
+	err := pgx.BeginFunc(ctx, db.Pool, func(tx pgx.Tx) error {
+		// two lines sent at once would both pass the cooldown check; the
+		// lock lets the second see the first, and ends with the transaction
+		if _, err := tx.Exec(ctx, `
+			select pg_advisory_xact_lock(hashtextextended('agentic_interjection:' || $1::text || ':' || $2::text, 0))
+		`, userID, twitchUserID); err != nil {
+			return err
+		}
+
+		return tx.QueryRow(ctx, `
+			with pruned as (
+				delete from agentic_interjections
+				where user_id = $1 and created_at < now() - $6::interval
+			),
+			recent as (
+				select count(*) as n from agentic_interjections
+				where user_id = $1 and twitch_user_id = $2
+				and created_at > now() - $5::interval
+			),
+			pushed as (
+				insert into agentic_interjections (user_id, twitch_user_id, twitch_login, message)
+				select $1, $2, $3, $4 from recent where recent.n = 0
+				returning 1
+			)
+			select exists (select 1 from pushed)
+		`, userID, twitchUserID, twitchLogin, message, AgenticInterjectionCooldown, agenticInterjectionMaxAge).Scan(&pushed)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to push agentic interjection: %w", err)
+	}
+
+	return pushed, nil
+}
+
+// TakeAgenticInterjections removes and returns up to limit of the channel's
+// interjections sent within the last since, oldest first. Lines from viewers
+// banned or timed out from the AI are dropped.
+func (db *DB) TakeAgenticInterjections(ctx context.Context, userID uuid.UUID, since time.Duration, limit int) ([]*AgenticInterjection, error) {
+	rows, err := db.Query(ctx, `
+		with taken as (
+			delete from agentic_interjections
+			where id in (
+				select id from agentic_interjections
+				where user_id = $1 and created_at > now() - $2::interval
+				order by created_at
+				limit $3
+			)
+			returning twitch_user_id, twitch_login, message, created_at
+		)
+		select t.twitch_user_id, t.twitch_login, t.message, t.created_at
+		from taken t
+		where not exists (
+			select 1 from viewer_bans vb
+			where vb.user_id = $1 and vb.twitch_user_id = t.twitch_user_id
+			and (vb.until is null or vb.until > now())
+		)
+		order by t.created_at
+	`, userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take agentic interjections: %w", err)
+	}
+	defer rows.Close()
+
+	var interjections []*AgenticInterjection
+	for rows.Next() {
+		var i AgenticInterjection
+		if err := rows.Scan(&i.TwitchUserID, &i.TwitchLogin, &i.Message, &i.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan agentic interjection: %w", err)
+		}
+		interjections = append(interjections, &i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan agentic interjections: %w", err)
+	}
+
+	return interjections, nil
+}
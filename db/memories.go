@@ -0,0 +1,369 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxMemoryTextLen     = 200  // per memory, in characters
+	MaxInjectedMemories  = 20   // lines in the "Things you remember" block
+	MemoryRotationSlots  = 4    // of MaxInjectedMemories, filled at random from the remainder
+	MaxInjectedMemTokens = 1000 // hard token budget of the whole block
+)
+
+// ErrMemoryOptOut rejects a memory tagged with a viewer who opted out via
+// ^^optout.
+var ErrMemoryOptOut = errors.New("viewer opted out of memories")
+
+// CharMemory is a distilled fact a character remembers. UserID nil is the
+// global scope (travels with the card), CardID nil covers all of the
+// streamer's characters; at least one of them is always set.
+type CharMemory struct {
+	ID uuid.UUID
+
+	UserID *uuid.UUID
+	CardID *uuid.UUID
+
+	SubjectTwitchUserID *int
+
+	Text       string
+	TokenCount int
+
+	Pinned    bool
+	ExpiresAt *time.Time
+	Suggested bool
+
+	CreatedBy *uuid.UUID
+	CreatedAt time.Time
+}
+
+const charMemoryColumns = `
+	id,
+	user_id,
+	card_id,
+	subject_twitch_user_id,
+	text,
+	token_count,
+	pinned,
+	expires_at,
+	suggested,
+	created_by,
+	created_at
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCharMemory(row rowScanner) (*CharMemory, error) {
+	var m CharMemory
+	err := row.Scan(
+		&m.ID,
+		&m.UserID,
+		&m.CardID,
+		&m.SubjectTwitchUserID,
+		&m.Text,
+		&m.TokenCount,
+		&m.Pinned,
+		&m.ExpiresAt,
+		&m.Suggested,
+		&m.CreatedBy,
+		&m.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (db *DB) InsertCharMemory(ctx context.Context, mem *CharMemory) (uuid.UUID, error) {
+	if mem.UserID == nil && mem.CardID == nil {
+		return uuid.Nil, fmt.Errorf("failed to insert char memory: user_id and card_id are both empty")
+	}
+
+	var id uuid.UUID
+	err := db.QueryRow(ctx, `
+		insert into char_memories (
+			user_id,
+			card_id,
+			subject_twitch_user_id,
+			text,
+			token_count,
+			pinned,
+			expires_at,
+			suggested,
+			created_by
+		)
+		select $1, $2, $3, $4, $5, $6, $7, $8, $9
+		where not exists (
+			select 1 from chat_users
+			where twitch_user_id = $3 and memory_opt_out
+		)
+		returning id
+	`,
+		mem.UserID,
+		mem.CardID,
+		mem.SubjectTwitchUserID,
+		mem.Text,
+		mem.TokenCount,
+		mem.Pinned,
+		mem.ExpiresAt,
+		mem.Suggested,
+		mem.CreatedBy,
+	).Scan(&id)
+	if err != nil {
+		if ErrCode(parseErr(err)) == ErrCodeNoRows {
+			return uuid.Nil, ErrMemoryOptOut
+		}
+		return uuid.Nil, fmt.Errorf("failed to insert char memory: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateCharMemory rewrites the editable fields; scope and authorship are
+// fixed at insert time.
+func (db *DB) UpdateCharMemory(ctx context.Context, mem *CharMemory) error {
+	_, err := db.Exec(ctx, `
+		update char_memories
+		set
+			text = $2,
+			token_count = $3,
+			pinned = $4,
+			expires_at = $5,
+			suggested = $6
+		where id = $1
+	`, mem.ID, mem.Text, mem.TokenCount, mem.Pinned, mem.ExpiresAt, mem.Suggested)
+	if err != nil {
+		return fmt.Errorf("failed to update char memory: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeleteCharMemory(ctx context.Context, id uuid.UUID) error {
+	_, err := db.Exec(ctx, `delete from char_memories where id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete char memory: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) GetCharMemory(ctx context.Context, id uuid.UUID) (*CharMemory, error) {
+	mem, err := scanCharMemory(db.QueryRow(ctx, `
+		select `+charMemoryColumns+`
+		from char_memories
+		where id = $1
+	`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get char memory: %w", parseErr(err))
+	}
+
+	return mem, nil
+}
+
+func (db *DB) queryCharMemories(ctx context.Context, query string, args ...any) ([]*CharMemory, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mems []*CharMemory
+	for rows.Next() {
+		mem, err := scanCharMemory(rows)
+		if err != nil {
+			return nil, err
+		}
+		mems = append(mems, mem)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mems, nil
+}
+
+// GetStreamerMemories lists everything in the streamer's scopes (per-card and
+// streamer-wide), expired rows included, oldest unpinned first so the list
+// shows what to prune.
+func (db *DB) GetStreamerMemories(ctx context.Context, userID uuid.UUID) ([]*CharMemory, error) {
+	mems, err := db.queryCharMemories(ctx, `
+		select `+charMemoryColumns+`
+		from char_memories
+		where user_id = $1
+		order by pinned asc, created_at asc
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get streamer memories: %w", err)
+	}
+
+	return mems, nil
+}
+
+// GetGlobalMemories lists the global scope of a card.
+func (db *DB) GetGlobalMemories(ctx context.Context, cardID uuid.UUID) ([]*CharMemory, error) {
+	mems, err := db.queryCharMemories(ctx, `
+		select `+charMemoryColumns+`
+		from char_memories
+		where user_id is null and card_id = $1
+		order by pinned asc, created_at asc
+	`, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get global memories: %w", err)
+	}
+
+	return mems, nil
+}
+
+// GetActiveMemories picks what a card remembers on a streamer's channel:
+// approved, unexpired rows from the per-card, streamer-wide and (unless
+// disabled) global scopes. Pinned and newest rows come first, the last
+// MemoryRotationSlots are a random pick from the rest, and the result is cut
+// at MaxInjectedMemTokens.
+func (db *DB) GetActiveMemories(ctx context.Context, userID, cardID uuid.UUID, includeGlobal bool) ([]*CharMemory, error) {
+	mems, err := db.queryCharMemories(ctx, `
+		with active as (
+			select
+				`+charMemoryColumns+`,
+				row_number() over (order by pinned desc, created_at desc) as rn
+			from char_memories
+			where
+				not suggested
+			and
+				(expires_at is null or expires_at > now())
+			and (
+				(user_id = $1 and (card_id = $2 or card_id is null))
+			or
+				(user_id is null and card_id = $2 and $3)
+			)
+		)
+		(
+			select `+charMemoryColumns+`
+			from active
+			where rn <= $4
+			order by rn
+		)
+		union all
+		(
+			select `+charMemoryColumns+`
+			from active
+			where rn > $4
+			order by random()
+			limit $5
+		)
+	`, userID, cardID, includeGlobal, MaxInjectedMemories-MemoryRotationSlots, MemoryRotationSlots)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active memories: %w", err)
+	}
+
+	tokens := 0
+	for i, mem := range mems {
+		if tokens+mem.TokenCount > MaxInjectedMemTokens {
+			return mems[:i], nil
+		}
+		tokens += mem.TokenCount
+	}
+
+	return mems, nil
+}
+
+// DeleteSessionMemories wipes the streamer's TTL'd memories, leaving permanent
+// lore alone. TTLs only approximate "this stream", so this is the manual
+// override when streams run long or back-to-back.
+func (db *DB) DeleteSessionMemories(ctx context.Context, userID uuid.UUID) (int, error) {
+	tag, err := db.Exec(ctx, `
+		delete from char_memories
+		where user_id = $1 and expires_at is not null
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete session memories: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// MemoryCard is the id and name of a card memories can be attached to.
+type MemoryCard struct {
+	ID   uuid.UUID
+	Name string
+}
+
+// GetMemoryCards lists the cards a streamer's memories can be about: the ones
+// behind the channel's AI rewards plus any a memory already points at.
+func (db *DB) GetMemoryCards(ctx context.Context, userID uuid.UUID) ([]MemoryCard, error) {
+	rows, err := db.Query(ctx, `
+		select
+			cc.id,
+			cc.name
+		from char_cards cc
+		where
+			cc.id in (
+				select card_id from reward_buttons
+				where user_id = $1 and reward_type = $2 and card_id is not null
+			)
+		or
+			cc.id in (
+				select card_id from char_memories
+				where user_id = $1 and card_id is not null
+			)
+		order by cc.name asc
+	`, userID, TwitchRewardAI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory cards: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []MemoryCard
+	for rows.Next() {
+		var card MemoryCard
+		if err := rows.Scan(&card.ID, &card.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan memory card: %w", err)
+		}
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get memory cards: %w", err)
+	}
+
+	return cards, nil
+}
+
+// ApproveCharMemory turns a suggestion into a live memory with the given
+// expiry.
+func (db *DB) ApproveCharMemory(ctx context.Context, id uuid.UUID, expiresAt *time.Time) error {
+	_, err := db.Exec(ctx, `
+		update char_memories
+		set
+			suggested = false,
+			expires_at = $2
+		where id = $1 and suggested
+	`, id, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to approve char memory: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) CountSuggestedMemories(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := db.QueryRow(ctx, `
+		select count(*)
+		from char_memories
+		where user_id = $1 and suggested
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count suggested memories: %w", err)
+	}
+
+	return count, nil
+}
@@ -9,6 +9,7 @@ import (
 	"app/pkg/textfilter"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
@@ -41,6 +42,26 @@ type TwitchMessage struct {
 	TwitchUserID int    `json:"twitch_user_id,omitempty"`
 	Message      string `json:"message"`
 	RewardID     string `json:"reward_id"`
+
+	// Event is set on follows, subs, gifts and raids; Message then only
+	// describes it for the control panel.
+	Event *MessageEvent `json:"event,omitempty"`
+}
+
+const (
+	MessageEventFollow = "follow"
+	MessageEventSub    = "sub"
+	MessageEventGift   = "gift"
+	MessageEventRaid   = "raid"
+)
+
+type MessageEvent struct {
+	Type string `json:"type"`
+
+	Tier        string `json:"tier,omitempty"` // 1000, 2000 or 3000
+	Total       int    `json:"total,omitempty"`
+	IsAnonymous bool   `json:"is_anonymous,omitempty"`
+	Viewers     int    `json:"viewers,omitempty"`
 }
 
 type Message struct {
@@ -54,6 +75,9 @@ type Message struct {
 
 	Updated int
 
+	// Position orders waiting messages, lowest plays first.
+	Position int64
+
 	Data []byte
 }
 
@@ -107,6 +131,36 @@ func (db *DB) PushIngestMsg(ctx context.Context, userID uuid.UUID, msg TwitchMes
 	return id, nil
 }
 
+// PushRedemptionMsg queues a channel point redemption keyed on its
+// redemption id. false means the redemption is already queued, by EventSub
+// or the IRC fallback, and nothing was inserted.
+func (db *DB) PushRedemptionMsg(ctx context.Context, userID uuid.UUID, msg TwitchMessage, data *MessageData) (uuid.UUID, bool, error) {
+	var id uuid.UUID
+
+	err := db.QueryRow(ctx, `
+		INSERT INTO
+			msg_queue (
+				user_id,
+				msg,
+				status,
+				data,
+				unique_id,
+				updated
+			)
+		VALUES ($1, $2, $3, $4, $5, nextval('updated_seq'))
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`, userID, msg, MsgStatusWait, data, "redemption:"+data.RedemptionID).Scan(&id)
+	if err != nil {
+		if ErrCode(parseErr(err)) == ErrCodeNoRows {
+			return uuid.Nil, false, nil
+		}
+		return uuid.Nil, false, fmt.Errorf("failed to push redemption message: %w", err)
+	}
+
+	return id, true, nil
+}
+
 func (db *DB) GetNextMsg(ctx context.Context, userID uuid.UUID) (*Message, error) {
 	msg := Message{}
 
@@ -123,7 +177,7 @@ func (db *DB) GetNextMsg(ctx context.Context, userID uuid.UUID) (*Message, error
 		and
 			status = $2
         order by
-            updated asc,
+            position asc,
             id asc
 		limit 1
 	`, userID, MsgStatusWait).Scan(&msg.ID, &msg.UserID, &msg.TwitchMessage, &msg.Data)
@@ -143,13 +197,14 @@ func (db *DB) GetMessageByID(ctx context.Context, msgID uuid.UUID) (*Message, er
 			user_id,
 			status,
 			updated,
+			position,
 			msg,
 			data
 		from
 			msg_queue
 		where
 			id = $1
-	`, msgID).Scan(&msg.ID, &msg.UserID, &msg.Status, &msg.Updated, &msg.TwitchMessage, &msg.Data)
+	`, msgID).Scan(&msg.ID, &msg.UserID, &msg.Status, &msg.Updated, &msg.Position, &msg.TwitchMessage, &msg.Data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get message by id: %w", parseErr(err))
 	}
@@ -157,14 +212,22 @@ func (db *DB) GetMessageByID(ctx context.Context, msgID uuid.UUID) (*Message, er
 	return &msg, nil
 }
 
+// CleanQueue drops finished messages from msg_queue once they are old enough
+// to be off every control panel, and the archive past its retention. Rows
+// that somehow missed the archive are archived on the way out.
 func (db *DB) CleanQueue(ctx context.Context) error {
 	_, err := db.Exec(ctx, `
-		delete from
-			msg_queue
-		where
-			(status = $1 or status = $2)
-		and
-			updated < currval('updated_seq') - 200
+		with finished as (
+			delete from
+				msg_queue
+			where
+				(status = $1 or status = $2)
+			and
+				updated < currval('updated_seq') - 200
+			returning id, user_id, status, msg, data
+		),
+		`+archiveFinishedCTE+`
+		select 1
 	`, MsgStatusDeleted, MsgStatusProcessed)
 
 	if err != nil {
@@ -176,18 +239,27 @@ func (db *DB) CleanQueue(ctx context.Context) error {
 		return fmt.Errorf("failed to clean queue: %w", err)
 	}
 
+	if _, err := db.PruneArchive(ctx); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func (db *DB) UpdateMessageStatus(ctx context.Context, msgID uuid.UUID, status MsgStatus) error {
 	_, err := db.Exec(ctx, `
-		update
-			msg_queue
-		set
-			status = $1,
-			updated = nextval('updated_seq')
-		where
-			id = $2
+		with finished as (
+			update
+				msg_queue
+			set
+				status = $1,
+				updated = nextval('updated_seq')
+			where
+				id = $2
+			returning id, user_id, status, msg, data
+		),
+		`+archiveFinishedCTE+`
+		select 1
 	`, status, msgID)
 	if err != nil {
 		return fmt.Errorf("failed to update message status: %w", err)
@@ -196,6 +268,142 @@ func (db *DB) UpdateMessageStatus(ctx context.Context, msgID uuid.UUID, status M
 	return nil
 }
 
+type QueueMove int
+
+const (
+	QueueMoveUp QueueMove = iota
+	QueueMoveDown
+	QueueMoveNext // ahead of every waiting message
+	QueueMoveLast // behind every waiting message
+)
+
+var ErrMsgNotWaiting = errors.New("message is not waiting in queue")
+
+// MoveMsg reorders a waiting message, or returns ErrMsgNotWaiting. Up and
+// down swap positions with the neighbour, so moving the first message up is
+// a no-op. Every touched row gets a fresh updated so all open control panels
+// pick the new order up.
+func (db *DB) MoveMsg(ctx context.Context, userID, msgID uuid.UUID, move QueueMove) error {
+	var query string
+	switch move {
+	case QueueMoveUp, QueueMoveDown:
+		cmp, dir := "<", "desc"
+		if move == QueueMoveDown {
+			cmp, dir = ">", "asc"
+		}
+		query = `
+			with cur as (
+				select id, position from msg_queue
+				where id = $2 and user_id = $1 and status = $3
+			), neighbour as (
+				select mq.id, mq.position from msg_queue mq, cur
+				where
+					mq.user_id = $1
+				and
+					mq.status = $3
+				and
+					(mq.position, mq.id) ` + cmp + ` (cur.position, cur.id)
+				order by mq.position ` + dir + `, mq.id ` + dir + `
+				limit 1
+			)
+			update msg_queue mq
+			set
+				position = case when mq.id = cur.id then neighbour.position else cur.position end,
+				updated = nextval('updated_seq')
+			from cur, neighbour
+			where mq.id = cur.id or mq.id = neighbour.id
+		`
+	case QueueMoveNext:
+		query = `
+			update msg_queue
+			set
+				position = (
+					select min(position) - 1 from msg_queue
+					where user_id = $1 and status = $3
+				),
+				updated = nextval('updated_seq')
+			where id = $2 and user_id = $1 and status = $3
+		`
+	case QueueMoveLast:
+		query = `
+			update msg_queue
+			set
+				position = nextval('updated_seq'),
+				updated = nextval('updated_seq')
+			where id = $2 and user_id = $1 and status = $3
+		`
+	default:
+		return fmt.Errorf("failed to move message: unknown move %d", move)
+	}
+
+	tag, err := db.Exec(ctx, query, userID, msgID, MsgStatusWait)
+	if err != nil {
+		return fmt.Errorf("failed to move message: %w", err)
+	}
+
+	if tag.RowsAffected() != 0 {
+		return nil
+	}
+
+	// up and down touch nothing at either end of the queue too
+	if move == QueueMoveUp || move == QueueMoveDown {
+		var waiting bool
+		err := db.QueryRow(ctx, `
+			select exists (select 1 from msg_queue where id = $2 and user_id = $1 and status = $3)
+		`, userID, msgID, MsgStatusWait).Scan(&waiting)
+		if err != nil {
+			return fmt.Errorf("failed to move message: %w", err)
+		}
+		if waiting {
+			return nil
+		}
+	}
+
+	return ErrMsgNotWaiting
+}
+
+var ErrMsgNotProcessed = errors.New("message is not processed")
+
+// ErrMsgNoReply is returned for replays of AI messages whose reply was never
+// stored; replaying them would mean asking the model again.
+var ErrMsgNoReply = errors.New("message has no stored reply")
+
+// ReplayMsg queues a copy of a processed message, data included, to play
+// next. The copy drops the redemption so it is never settled twice.
+func (db *DB) ReplayMsg(ctx context.Context, userID, msgID uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+
+	err := db.QueryRow(ctx, `
+		insert into msg_queue (
+			user_id,
+			msg,
+			status,
+			data,
+			position
+		)
+		select
+			user_id,
+			msg,
+			$3,
+			(coalesce(data, '{}'::jsonb) - 'redemption_id' - 'redemption_status') || jsonb_build_object('replay_of', id::text),
+			coalesce(
+				(select min(position) - 1 from msg_queue where user_id = $1 and status = $3),
+				nextval('updated_seq')
+			)
+		from msg_queue
+		where id = $2 and user_id = $1 and status = $4
+		returning id
+	`, userID, msgID, MsgStatusWait, MsgStatusProcessed).Scan(&id)
+	if err != nil {
+		if ErrCode(parseErr(err)) == ErrCodeNoRows {
+			return uuid.Nil, ErrMsgNotProcessed
+		}
+		return uuid.Nil, fmt.Errorf("failed to replay message: %w", err)
+	}
+
+	return id, nil
+}
+
 type MessageData struct {
 	AIResponse string `json:"ai_response,omitzero"`
 
@@ -204,6 +412,52 @@ type MessageData struct {
 
 	ShowImages *bool    `json:"show_images,omitempty"`
 	ImageIDs   []string `json:"image_ids,omitempty"`
+
+	// ReplayOf is the processed message this one plays again; the stored
+	// AIResponse and spans are reused instead of asking the model anew.
+	ReplayOf string `json:"replay_of,omitempty"`
+
+	// RedemptionID is the channel point redemption behind the message, if
+	// found; RedemptionStatus is set once it was fulfilled or canceled.
+	RedemptionID     string `json:"redemption_id,omitempty"`
+	RedemptionStatus string `json:"redemption_status,omitempty"`
+
+	// SkipReason is why the processor skipped the message on its own, e.g.
+	// a banned viewer; shown in the control panel.
+	SkipReason string `json:"skip_reason,omitempty"`
+}
+
+// GetClaimedRedemptionIDs lists the redemptions already behind one of the
+// user's queued messages and not settled yet, so a lookup by redeemer and
+// text does not hand the same redemption to a second message.
+func (db *DB) GetClaimedRedemptionIDs(ctx context.Context, userID uuid.UUID) (map[string]bool, error) {
+	rows, err := db.Query(ctx, `
+		select
+			data->>'redemption_id'
+		from
+			msg_queue
+		where
+			user_id = $1
+		and
+			data ? 'redemption_id'
+		and
+			not data ? 'redemption_status'
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get claimed redemptions: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect claimed redemptions: %w", err)
+	}
+
+	claimed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		claimed[id] = true
+	}
+
+	return claimed, nil
 }
 
 func (db *DB) UpdateMessageData(ctx context.Context, msgID uuid.UUID, data *MessageData) error {
@@ -238,22 +492,27 @@ func ParseMessageData(data []byte) (*MessageData, error) {
 }
 
 func (db *DB) UpdateCurrentMessages(ctx context.Context, userID uuid.UUID) (cntUpdated int, err error) {
-	tag, err := db.Exec(ctx, `
-		update
-			msg_queue
-		set
-			status = $1,
-			updated = nextval('updated_seq')
-		where
-			user_id = $2
-		and
-			status = $3
-	`, MsgStatusProcessed, userID, MsgStatusCurrent)
+	err = db.QueryRow(ctx, `
+		with finished as (
+			update
+				msg_queue
+			set
+				status = $1,
+				updated = nextval('updated_seq')
+			where
+				user_id = $2
+			and
+				status = $3
+			returning id, user_id, status, msg, data
+		),
+		`+archiveFinishedCTE+`
+		select count(*) from finished
+	`, MsgStatusProcessed, userID, MsgStatusCurrent).Scan(&cntUpdated)
 	if err != nil {
 		return 0, fmt.Errorf("failed to update current message: %w", err)
 	}
 
-	return int(tag.RowsAffected()), nil
+	return cntUpdated, nil
 }
 
 func (db *DB) HasWaitingKnownRewardMessage(ctx context.Context, userID uuid.UUID) (bool, error) {
@@ -275,21 +534,28 @@ func (db *DB) HasWaitingKnownRewardMessage(ctx context.Context, userID uuid.UUID
 }
 
 func (db *DB) SkipWaitingNoRewardMessages(ctx context.Context, userID uuid.UUID) (int, error) {
-	tag, err := db.Exec(ctx, `
-		UPDATE msg_queue
-		SET
-			status = $1,
-			updated = nextval('updated_seq')
-		WHERE
-			user_id = $2
-		AND status = $3
-		AND (msg->>'reward_id' IS NULL OR msg->>'reward_id' = '')
-	`, MsgStatusDeleted, userID, MsgStatusWait)
+	var skipped int
+	err := db.QueryRow(ctx, `
+		WITH finished AS (
+			UPDATE msg_queue
+			SET
+				status = $1,
+				updated = nextval('updated_seq')
+			WHERE
+				user_id = $2
+			AND status = $3
+			AND (msg->>'reward_id' IS NULL OR msg->>'reward_id' = '')
+			AND msg->'event' IS NULL
+			RETURNING id, user_id, status, msg, data
+		),
+		`+archiveFinishedCTE+`
+		SELECT count(*) FROM finished
+	`, MsgStatusDeleted, userID, MsgStatusWait).Scan(&skipped)
 	if err != nil {
 		return 0, fmt.Errorf("failed to skip waiting no-reward messages: %w", err)
 	}
 
-	return int(tag.RowsAffected()), nil
+	return skipped, nil
 }
 
 func (db *DB) GetMessageUpdates(ctx context.Context, userID uuid.UUID, updated int) ([]*Message, error) {
@@ -299,6 +565,7 @@ func (db *DB) GetMessageUpdates(ctx context.Context, userID uuid.UUID, updated i
 			user_id,
 			status,
 			updated,
+			position,
 			msg,
 			data
 		from
@@ -315,7 +582,7 @@ func (db *DB) GetMessageUpdates(ctx context.Context, userID uuid.UUID, updated i
 	messages := make([]*Message, 0, 20)
 	for rows.Next() {
 		var msg Message
-		err := rows.Scan(&msg.ID, &msg.UserID, &msg.Status, &msg.Updated, &msg.TwitchMessage, &msg.Data)
+		err := rows.Scan(&msg.ID, &msg.UserID, &msg.Status, &msg.Updated, &msg.Position, &msg.TwitchMessage, &msg.Data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
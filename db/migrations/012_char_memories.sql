@@ -0,0 +1,24 @@
+create table if not exists char_memories (
+    id uuid default uuid_generate_v7() primary key,
+
+    user_id uuid references users(id) on delete cascade,      -- null = global, travels with the public card
+    card_id uuid references char_cards(id) on delete cascade, -- null = all of the streamer's characters
+
+    subject_twitch_user_id bigint, -- null = not about a specific viewer
+
+    text text not null,
+    token_count int not null,
+
+    pinned boolean not null default false,
+    expires_at timestamp,                     -- null = permanent lore
+    suggested boolean not null default false, -- awaiting mod approval, never injected
+
+    created_by uuid references users(id) on delete set null,
+    created_at timestamp not null default now(),
+
+    check (user_id is not null or card_id is not null)
+);
+
+create index if not exists char_memories_user_id_idx on char_memories (user_id);
+create index if not exists char_memories_card_id_idx on char_memories (card_id);
+create index if not exists char_memories_subject_twitch_user_id_idx on char_memories (subject_twitch_user_id);
@@ -0,0 +1,18 @@
+create table if not exists char_interactions (
+    id uuid default uuid_generate_v7() primary key,
+
+    user_id uuid not null references users(id) on delete cascade,
+    card_id uuid not null references char_cards(id) on delete cascade,
+
+    twitch_user_id bigint,
+    requester_login text not null,
+
+    request text not null, -- filtered, as spoken on stream
+    reply text not null,   -- filtered, as spoken on stream
+
+    distilled_at timestamp, -- null = not yet seen by the memory suggestion job
+
+    created_at timestamp not null default now()
+);
+
+create index if not exists char_interactions_undistilled_idx on char_interactions (created_at) where distilled_at is null;
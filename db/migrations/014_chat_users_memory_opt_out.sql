@@ -0,0 +1 @@
+alter table chat_users add column if not exists memory_opt_out boolean not null default false;
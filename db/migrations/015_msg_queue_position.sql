@@ -0,0 +1,11 @@
+-- play order of waiting messages, lower plays first. Drawn from updated_seq so
+-- the backfill from updated keeps the order the queue had before.
+alter table msg_queue add column if not exists position bigint;
+
+update msg_queue set position = updated where position is null;
+
+alter table msg_queue alter column position set default nextval('updated_seq');
+alter table msg_queue alter column position set not null;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS msg_queue_user_status_position_idx
+ON msg_queue (user_id, status, position, id);
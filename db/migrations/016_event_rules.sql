@@ -0,0 +1,18 @@
+-- per-channel reactions to follows, subs, gifts and raids: which character
+-- answers, what it is told, and the smallest event worth a reaction
+create table if not exists event_rules (
+    id uuid default uuid_generate_v7() primary key,
+
+    user_id uuid not null references users(id) on delete cascade,
+    event_type text not null, -- follow, sub, gift, raid
+
+    card_id uuid not null references char_cards(id) on delete cascade,
+    prompt text not null,
+    min_threshold integer not null default 0, -- raid viewers, gifted subs
+
+    enabled boolean not null default true,
+
+    updated_at timestamp not null default now(),
+
+    unique (user_id, event_type)
+);
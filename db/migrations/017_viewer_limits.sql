@@ -0,0 +1,29 @@
+-- viewers a channel keeps away from the AI, for good (until null) or for a
+-- while. Keyed by twitch user id like chat_users, so renames don't dodge it.
+create table if not exists viewer_bans (
+    id uuid default uuid_generate_v7() primary key,
+
+    user_id uuid not null references users(id) on delete cascade,
+    twitch_user_id integer not null,
+    twitch_login text not null,
+
+    until timestamp,
+    banned_by text not null,
+
+    created_at timestamp not null default now(),
+
+    unique (user_id, twitch_user_id)
+);
+
+-- redeems that passed the per-viewer limits, counted against the limit's
+-- window; rows older than the longest window are pruned on insert
+create table if not exists viewer_redeems (
+    user_id uuid not null references users(id) on delete cascade,
+    twitch_user_id integer not null,
+    reward_type integer not null,
+
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS viewer_redeems_user_viewer_type_created_idx
+ON viewer_redeems (user_id, twitch_user_id, reward_type, created_at);
@@ -0,0 +1,36 @@
+-- finished (processed or skipped) messages, kept after CleanQueue purges
+-- msg_queue so mods can look back at what was asked and said. The id is the
+-- msg_queue id; rows expire after the configured retention.
+create table if not exists msg_archive (
+    id uuid primary key,
+
+    user_id uuid not null references users(id) on delete cascade,
+    status integer not null,
+
+    twitch_login text not null default '',
+    twitch_user_id integer not null default 0,
+
+    reward_type integer, -- null for chat tts
+    char_name text not null default '',
+
+    request text not null default '',
+    response text not null default '',
+
+    msg jsonb not null default '{}'::jsonb,
+    data jsonb not null default '{}'::jsonb,
+
+    created_at timestamp not null default now(), -- when it was queued
+    archived_at timestamp not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS msg_archive_user_created_idx
+ON msg_archive (user_id, created_at desc);
+
+CREATE INDEX IF NOT EXISTS msg_archive_archived_idx
+ON msg_archive (archived_at);
+
+CREATE INDEX IF NOT EXISTS msg_archive_login_trgm_idx
+ON msg_archive USING gin (twitch_login gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS msg_archive_text_trgm_idx
+ON msg_archive USING gin ((request || ' ' || response) gin_trgm_ops);
@@ -0,0 +1,24 @@
+-- the card a message played with, so a highlight export can show its image
+ALTER TABLE msg_archive ADD COLUMN IF NOT EXISTS card_id uuid;
+
+-- one row per played track (request or reply): the final mp3 lives in s3
+-- under <msg_id>/<id>.mp3, the karaoke word timings here. Rows expire with
+-- the archive retention.
+create table if not exists msg_tracks (
+    id uuid primary key,
+    msg_id uuid not null,
+
+    user_id uuid not null references users(id) on delete cascade,
+
+    text text not null default '',
+    words jsonb not null default '[]'::jsonb,
+    duration_ms bigint not null default 0,
+
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS msg_tracks_msg_idx
+ON msg_tracks (msg_id, created_at);
+
+CREATE INDEX IF NOT EXISTS msg_tracks_created_idx
+ON msg_tracks (created_at);
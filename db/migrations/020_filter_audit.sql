@@ -0,0 +1,44 @@
+-- every content filter run on a request or reply: what was judged, in what
+-- context, and which pass (regex, built-in llm policy, streamer rules)
+-- censored each span. Runs expire with the archive retention unless a mod
+-- flagged them.
+create table if not exists filter_runs (
+    id uuid default uuid_generate_v7() primary key,
+
+    user_id uuid not null references users(id) on delete cascade,
+    msg_id uuid not null,
+    kind text not null, -- request, reply
+
+    target text not null,
+    context text not null default '',
+    custom text not null default '', -- the streamer's filter rules at the time
+    llm_skipped boolean not null default false,
+
+    spans jsonb not null default '[]'::jsonb,
+
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS filter_runs_msg_idx
+ON filter_runs (msg_id, kind, created_at desc);
+
+CREATE INDEX IF NOT EXISTS filter_runs_created_idx
+ON filter_runs (created_at);
+
+-- mods marking a run's decision as wrong: text was censored but is fine, or
+-- text should have been censored. Flagged runs export as a regression corpus.
+create table if not exists filter_flags (
+    id uuid default uuid_generate_v7() primary key,
+
+    run_id uuid not null references filter_runs(id) on delete cascade,
+    kind text not null, -- false_positive, missed
+
+    text text not null,
+    note text not null default '',
+    flagged_by text not null,
+
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS filter_flags_run_idx
+ON filter_flags (run_id);
@@ -0,0 +1,16 @@
+-- lines chat sends into a running Agent BAJ dialogue with ^^say. The
+-- processor takes them between turns; rows past the longest dialogue are
+-- pruned on insert.
+create table if not exists agentic_interjections (
+    id uuid default uuid_generate_v7() primary key,
+
+    user_id uuid not null references users(id) on delete cascade,
+    twitch_user_id integer not null,
+    twitch_login text not null,
+    message text not null,
+
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS agentic_interjections_user_created_idx
+ON agentic_interjections (user_id, created_at);
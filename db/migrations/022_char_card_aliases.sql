@@ -0,0 +1,7 @@
+-- Agent BAJ detection shortlists characters before asking the model: names
+-- through char_cards_name_trgm_idx, short names and aliases exactly
+CREATE INDEX IF NOT EXISTS char_cards_short_char_name_lower_idx
+ON char_cards (lower(short_char_name));
+
+CREATE INDEX IF NOT EXISTS char_cards_aliases_idx
+ON char_cards USING gin ((data->'aliases'));
@@ -0,0 +1,14 @@
+-- a channel point redemption is queued once, whether EventSub or the IRC
+-- fallback gets there first. Older duplicates keep the id on the first row.
+update msg_queue m
+set data = m.data - 'redemption_id'
+where m.data ? 'redemption_id'
+and exists (
+    select 1 from msg_queue o
+    where o.data->>'redemption_id' = m.data->>'redemption_id'
+    and o.id < m.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS msg_queue_redemption_id_idx
+ON msg_queue ((data->>'redemption_id'))
+WHERE data ? 'redemption_id';
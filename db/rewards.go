@@ -14,6 +14,9 @@ const (
 	TwitchRewardAI
 	TwitchRewardUniversalTTS
 	TwitchRewardAgentic
+	// TwitchRewardEvent is no reward at all: follows, subs, gifts and raids
+	// answered through an event rule.
+	TwitchRewardEvent
 )
 
 func (t TwitchRewardType) String() string {
@@ -26,18 +29,41 @@ func (t TwitchRewardType) String() string {
 		return "BAJ TTS"
 	case TwitchRewardAgentic:
 		return "Agent BAJ"
+	case TwitchRewardEvent:
+		return "Event"
 	default:
 		return "unknown"
 	}
 }
 
-type TwitchRewardData struct{}
+const DefaultRewardCost = 10
+
+// TwitchRewardData mirrors the reward's Twitch settings so they survive the
+// reward being re-created. Zero limits are disabled.
+type TwitchRewardData struct {
+	Cost                  int `json:"cost,omitempty"`
+	GlobalCooldownSeconds int `json:"global_cooldown_seconds,omitempty"`
+	MaxPerStream          int `json:"max_per_stream,omitempty"`
+	MaxPerUserPerStream   int `json:"max_per_user_per_stream,omitempty"`
+}
+
+// WithDefaults fills in what was never configured; nil is all defaults.
+func (d *TwitchRewardData) WithDefaults() *TwitchRewardData {
+	out := TwitchRewardData{}
+	if d != nil {
+		out = *d
+	}
+	if out.Cost <= 0 {
+		out.Cost = DefaultRewardCost
+	}
+	return &out
+}
 
 type TwitchReward struct {
 	ID uuid.UUID `json:"id"`
 
-	UserID uuid.UUID `json:"user_id"`
-	CardID uuid.UUID `json:"card_id"`
+	UserID uuid.UUID  `json:"user_id"`
+	CardID *uuid.UUID `json:"card_id"`
 
 	TwitchRewardID string `json:"twitch_reward_id"`
 
@@ -46,7 +72,9 @@ type TwitchReward struct {
 	Data *TwitchRewardData `json:"data"`
 }
 
-func (db *DB) UpsertTwitchReward(ctx context.Context, userID uuid.UUID, CardID *uuid.UUID, twitchRewardID string, rewardType TwitchRewardType) error {
+// UpsertTwitchReward maps a Twitch reward to a card and type. A nil data
+// keeps the stored settings, e.g. when linking an existing reward.
+func (db *DB) UpsertTwitchReward(ctx context.Context, userID uuid.UUID, CardID *uuid.UUID, twitchRewardID string, rewardType TwitchRewardType, data *TwitchRewardData) error {
 	var query string
 	if CardID == nil {
 		query = `
@@ -54,11 +82,15 @@ func (db *DB) UpsertTwitchReward(ctx context.Context, userID uuid.UUID, CardID *
 				user_id,
 				card_id,
 				twitch_reward_id,
-				reward_type
+				reward_type,
+				data
 			)
-			VALUES ($1, $2, $3, $4)
+			VALUES ($1, $2, $3, $4, coalesce($5::jsonb, '{}'::jsonb))
 			ON CONFLICT (user_id, reward_type) WHERE card_id IS NULL DO UPDATE
-			SET twitch_reward_id = excluded.twitch_reward_id
+			SET
+				twitch_reward_id = excluded.twitch_reward_id,
+				data = coalesce($5::jsonb, reward_buttons.data),
+				updated_at = now()
 		`
 	} else {
 		query = `
@@ -66,15 +98,19 @@ func (db *DB) UpsertTwitchReward(ctx context.Context, userID uuid.UUID, CardID *
 				user_id,
 				card_id,
 				twitch_reward_id,
-				reward_type
+				reward_type,
+				data
 			)
-			VALUES ($1, $2, $3, $4)
+			VALUES ($1, $2, $3, $4, coalesce($5::jsonb, '{}'::jsonb))
 			ON CONFLICT (user_id, card_id, reward_type) WHERE card_id IS NOT NULL DO UPDATE
-			SET twitch_reward_id = excluded.twitch_reward_id
+			SET
+				twitch_reward_id = excluded.twitch_reward_id,
+				data = coalesce($5::jsonb, reward_buttons.data),
+				updated_at = now()
 		`
 	}
 
-	_, err := db.Exec(ctx, query, userID, CardID, twitchRewardID, rewardType)
+	_, err := db.Exec(ctx, query, userID, CardID, twitchRewardID, rewardType, data)
 	if err != nil {
 		return fmt.Errorf("upsertTwitchReward: %w", err)
 	}
@@ -92,16 +128,66 @@ func (db *DB) GetRewardByTwitchReward(ctx context.Context, twitchRewardID string
 		WHERE twitch_reward_id = $1
 	`, twitchRewardID).Scan(&cardID, &rewardType)
 	if err != nil {
-		return nil, 0, fmt.Errorf("getRewardByTwitchReward: %w", err)
+		return nil, 0, fmt.Errorf("getRewardByTwitchReward: %w", parseErr(err))
 	}
 
 	return cardID, rewardType, nil
 }
 
-func (db *DB) UpsertUniversalTTSReward(ctx context.Context, userID uuid.UUID, twitchRewardID string) error {
-	return db.UpsertTwitchReward(ctx, userID, nil, twitchRewardID, TwitchRewardUniversalTTS)
+func (db *DB) UpsertUniversalTTSReward(ctx context.Context, userID uuid.UUID, twitchRewardID string, data *TwitchRewardData) error {
+	return db.UpsertTwitchReward(ctx, userID, nil, twitchRewardID, TwitchRewardUniversalTTS, data)
+}
+
+func (db *DB) UpsertAgenticReward(ctx context.Context, userID uuid.UUID, twitchRewardID string, data *TwitchRewardData) error {
+	return db.UpsertTwitchReward(ctx, userID, nil, twitchRewardID, TwitchRewardAgentic, data)
+}
+
+// GetTwitchReward returns the user's reward of a type, for a card or (nil)
+// the card-less one.
+func (db *DB) GetTwitchReward(ctx context.Context, userID uuid.UUID, cardID *uuid.UUID, rewardType TwitchRewardType) (*TwitchReward, error) {
+	var reward TwitchReward
+
+	err := db.QueryRow(ctx, `
+		SELECT
+			id,
+			user_id,
+			card_id,
+			twitch_reward_id,
+			reward_type,
+			data
+		FROM reward_buttons
+		WHERE
+			user_id = $1
+		AND
+			card_id IS NOT DISTINCT FROM $2
+		AND
+			reward_type = $3
+	`, userID, cardID, rewardType).Scan(
+		&reward.ID,
+		&reward.UserID,
+		&reward.CardID,
+		&reward.TwitchRewardID,
+		&reward.RewardType,
+		&reward.Data,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("getTwitchReward: %w", parseErr(err))
+	}
+
+	return &reward, nil
 }
 
-func (db *DB) UpsertAgenticReward(ctx context.Context, userID uuid.UUID, twitchRewardID string) error {
-	return db.UpsertTwitchReward(ctx, userID, nil, twitchRewardID, TwitchRewardAgentic)
+func (db *DB) UpdateTwitchRewardData(ctx context.Context, id uuid.UUID, data *TwitchRewardData) error {
+	_, err := db.Exec(ctx, `
+		UPDATE reward_buttons
+		SET
+			data = $2,
+			updated_at = now()
+		WHERE id = $1
+	`, id, data)
+	if err != nil {
+		return fmt.Errorf("updateTwitchRewardData: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,174 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"app/pkg/s3client"
+
+	"github.com/google/uuid"
+)
+
+// TrackWord is one karaoke word, in ms from the start of its track.
+type TrackWord struct {
+	W string `json:"w"`
+	S int64  `json:"s"`
+	E int64  `json:"e"`
+}
+
+// MsgTrack is a played track kept for highlight export. Its audio is in s3.
+type MsgTrack struct {
+	ID     uuid.UUID
+	MsgID  uuid.UUID
+	UserID uuid.UUID
+
+	Text     string
+	Words    []TrackWord
+	Duration time.Duration
+
+	CreatedAt time.Time
+}
+
+func trackKey(msgID, trackID uuid.UUID) string {
+	return msgID.String() + "/" + trackID.String() + ".mp3"
+}
+
+// SaveMsgTrack stores the track's final mp3 and its word timings.
+func (db *DB) SaveMsgTrack(ctx context.Context, track *MsgTrack, mp3 []byte) error {
+	if db.s3 == nil {
+		return errors.New("no s3 client attached")
+	}
+
+	if err := db.s3.PutObject(ctx, s3client.TracksBucket, trackKey(track.MsgID, track.ID), bytes.NewReader(mp3), int64(len(mp3)), "audio/mpeg"); err != nil {
+		return fmt.Errorf("failed to upload track audio: %w", err)
+	}
+
+	words := track.Words
+	if words == nil {
+		words = []TrackWord{}
+	}
+
+	_, err := db.Exec(ctx, `
+		insert into msg_tracks (id, msg_id, user_id, text, words, duration_ms)
+		values ($1, $2, $3, $4, $5, $6)
+		on conflict (id) do nothing
+	`, track.ID, track.MsgID, track.UserID, track.Text, words, track.Duration.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to save track: %w", err)
+	}
+
+	return nil
+}
+
+// GetMsgTracks returns the message's tracks in the order they played.
+func (db *DB) GetMsgTracks(ctx context.Context, msgID uuid.UUID) ([]*MsgTrack, error) {
+	rows, err := db.Query(ctx, `
+		select
+			id,
+			msg_id,
+			user_id,
+			text,
+			words,
+			duration_ms,
+			created_at
+		from msg_tracks
+		where msg_id = $1
+		order by created_at, id
+	`, msgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks: %w", err)
+	}
+	defer rows.Close()
+
+	var tracks []*MsgTrack
+	for rows.Next() {
+		var track MsgTrack
+		var durationMs int64
+		err := rows.Scan(
+			&track.ID,
+			&track.MsgID,
+			&track.UserID,
+			&track.Text,
+			&track.Words,
+			&durationMs,
+			&track.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan track: %w", err)
+		}
+		track.Duration = time.Duration(durationMs) * time.Millisecond
+		tracks = append(tracks, &track)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan tracks: %w", err)
+	}
+
+	return tracks, nil
+}
+
+func (db *DB) GetMsgTrackAudio(ctx context.Context, track *MsgTrack) ([]byte, error) {
+	if db.s3 == nil {
+		return nil, errors.New("no s3 client attached")
+	}
+
+	rc, err := db.s3.GetObject(ctx, s3client.TracksBucket, trackKey(track.MsgID, track.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track audio: %w", err)
+	}
+	defer rc.Close()
+
+	audio, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read track audio: %w", err)
+	}
+
+	return audio, nil
+}
+
+// pruneTracks drops tracks past the archive retention along with their
+// audio. A failed s3 delete only leaves an orphaned object behind.
+func (db *DB) pruneTracks(ctx context.Context) error {
+	rows, err := db.Query(ctx, `
+		delete from msg_tracks
+		where created_at < now() - $1::interval
+		returning id, msg_id
+	`, db.archiveRetention)
+	if err != nil {
+		return fmt.Errorf("failed to prune tracks: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var id, msgID uuid.UUID
+		if err := rows.Scan(&id, &msgID); err != nil {
+			return fmt.Errorf("failed to scan pruned track: %w", err)
+		}
+		keys = append(keys, trackKey(msgID, id))
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to prune tracks: %w", err)
+	}
+
+	if db.s3 == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, key := range keys {
+		if err := db.s3.RemoveObject(ctx, s3client.TracksBucket, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove %d of %d pruned track objects: %w", len(errs), len(keys), errors.Join(errs...))
+	}
+
+	return nil
+}
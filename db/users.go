@@ -14,6 +14,10 @@ const (
 	DefaultTtsLimitSeconds = 80
 	DefaultMaxSfxCount     = 10
 	DefaultSfxTotalLimit   = 20 // seconds; total SFX duration per universal TTS message (0 = unlimited)
+
+	DefaultAgenticMaxTurns   = 20
+	MaxAgenticMaxTurns       = 50
+	DefaultAgenticAudioLimit = 300 // seconds; air time of one Agent BAJ dialogue (0 = unlimited)
 )
 
 type User struct {
@@ -174,12 +178,16 @@ func (db *DB) GetUserBySession(ctx context.Context, session string) (*User, erro
 
 type UserSettings struct {
 	Filters        string        `json:"filters"`
+	FilterWords    string        `json:"filter_words,omitempty"` // Comma-separated literal words, matched through leetspeak and lookalike letters
 	RequestTimeout time.Duration `json:"requestTimeout"`
 	TtsLimit       *int          `json:"tts_limit,omitempty"`       // Maximum TTS audio length in seconds (nil = not set, 0 = use default 80s)
 	MaxSfxCount    *int          `json:"max_sfx_count,omitempty"`   // Maximum number of SFX that can be used in a single TTS message (nil = not set, 0 = unlimited)
 	SfxTotalLimit  *int          `json:"sfx_total_limit,omitempty"` // Maximum cumulative SFX duration in seconds per universal TTS message (nil = not set, 0 = unlimited; default 20s)
 	Token          string        `json:"token,omitempty"`
 
+	AgenticMaxTurns   *int `json:"agentic_max_turns,omitempty"`   // Maximum lines in one Agent BAJ dialogue (nil = not set; default 20, at most 50)
+	AgenticAudioLimit *int `json:"agentic_audio_limit,omitempty"` // Agent BAJ dialogue air time in seconds before it wraps up (nil = not set, 0 = unlimited; default 300s)
+
 	IngestAllMessages bool `json:"ingest_all_messages,omitempty"` // When true, ingest all chat messages, not just reward redemptions
 
 	DisableAudioNormalization bool `json:"disable_audio_normalization,omitempty"` // When true, skip loudnorm and alimiter on TTS audio
@@ -189,6 +197,12 @@ type UserSettings struct {
 	DisableRegexFilter bool `json:"disable_regex_filter,omitempty"` // When true, skip the regex/word-list content filter
 
 	CustomFilterPrompt string `json:"custom_filter_prompt,omitempty"` // Streamer-written instructions appended to the LLM filter system prompt
+
+	DisableGlobalMemories bool `json:"disable_global_memories,omitempty"` // When true, characters only remember this channel's memories, not the card's global ones
+
+	QueuePaused bool `json:"queue_paused,omitempty"` // When true, the processor holds the queue; messages keep queueing
+
+	RedeemLimits map[TwitchRewardType]RedeemLimit `json:"redeem_limits,omitempty"` // Per-viewer redeem caps by reward type; missing = unlimited
 }
 
 func (db *DB) UpdateUserData(ctx context.Context, userID uuid.UUID, settings *UserSettings) error {
@@ -205,6 +219,22 @@ func (db *DB) UpdateUserData(ctx context.Context, userID uuid.UUID, settings *Us
 	return nil
 }
 
+// SetQueuePaused flips only the queue_paused key, so it can't clobber a
+// settings form saved at the same time.
+func (db *DB) SetQueuePaused(ctx context.Context, userID uuid.UUID, paused bool) error {
+	_, err := db.Exec(ctx, `
+		UPDATE users
+		SET
+			data = jsonb_set(data, '{queue_paused}', to_jsonb($1::boolean))
+		WHERE id = $2
+	`, paused, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set queue paused: %w", err)
+	}
+
+	return nil
+}
+
 // GenerateUserToken sets a new API token in user's settings and persists it.
 func (db *DB) GenerateUserToken(ctx context.Context, userID uuid.UUID) (string, error) {
 	settings, err := db.GetUserSettings(ctx, userID)
@@ -285,5 +315,15 @@ func (db *DB) GetUserSettings(ctx context.Context, userID uuid.UUID) (*UserSetti
 		settings.SfxTotalLimit = &defaultSfxTotal // Default to 20 seconds total SFX
 	}
 
+	if settings.AgenticMaxTurns == nil {
+		defaultAgenticMaxTurns := DefaultAgenticMaxTurns
+		settings.AgenticMaxTurns = &defaultAgenticMaxTurns
+	}
+
+	if settings.AgenticAudioLimit == nil {
+		defaultAgenticAudioLimit := DefaultAgenticAudioLimit
+		settings.AgenticAudioLimit = &defaultAgenticAudioLimit
+	}
+
 	return &settings, nil
 }
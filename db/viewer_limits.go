@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxRedeemLimitWindow bounds a redeem limit's window; viewer_redeems keeps
+// no older rows.
+const MaxRedeemLimitWindow = 7 * 24 * time.Hour
+
+// RedeemLimit caps how often one viewer may redeem a reward type. Zero in
+// either field means no limit.
+type RedeemLimit struct {
+	Count         int `json:"count,omitempty"`
+	WindowSeconds int `json:"window_seconds,omitempty"`
+}
+
+func (l RedeemLimit) Enabled() bool {
+	return l.Count > 0 && l.WindowSeconds > 0
+}
+
+func (l RedeemLimit) Window() time.Duration {
+	return time.Duration(l.WindowSeconds) * time.Second
+}
+
+type ViewerBan struct {
+	UserID       uuid.UUID
+	TwitchUserID int
+	TwitchLogin  string
+
+	// Until is nil for a ban, the end of a timeout otherwise.
+	Until    *time.Time
+	BannedBy string
+
+	CreatedAt time.Time
+}
+
+// Reason is the skip reason the control panel shows for the viewer's
+// messages.
+func (b *ViewerBan) Reason() string {
+	if b.Until == nil {
+		return fmt.Sprintf("%s is banned from AI by %s", b.TwitchLogin, b.BannedBy)
+	}
+	return fmt.Sprintf("%s is timed out from AI by %s until %s UTC", b.TwitchLogin, b.BannedBy, b.Until.UTC().Format("15:04"))
+}
+
+// BanViewer bans the viewer from the channel's AI, or times them out when
+// until is set. A second ban replaces the first.
+func (db *DB) BanViewer(ctx context.Context, userID uuid.UUID, twitchUserID int, twitchLogin string, until *time.Time, bannedBy string) error {
+	_, err := db.Exec(ctx, `
+		insert into viewer_bans (user_id, twitch_user_id, twitch_login, until, banned_by)
+		values ($1, $2, $3, $4, $5)
+		on conflict (user_id, twitch_user_id) do update
+		set twitch_login = $3, until = $4, banned_by = $5, created_at = now()
+	`, userID, twitchUserID, twitchLogin, until, bannedBy)
+	if err != nil {
+		return fmt.Errorf("failed to ban viewer: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) UnbanViewer(ctx context.Context, userID uuid.UUID, twitchUserID int) error {
+	_, err := db.Exec(ctx, `
+		delete from viewer_bans
+		where user_id = $1 and twitch_user_id = $2
+	`, userID, twitchUserID)
+	if err != nil {
+		return fmt.Errorf("failed to unban viewer: %w", err)
+	}
+
+	return nil
+}
+
+const viewerBanColumns = `
+	vb.user_id,
+	vb.twitch_user_id,
+	coalesce(cu.twitch_login, vb.twitch_login),
+	vb.until,
+	vb.banned_by,
+	vb.created_at
+`
+
+func scanViewerBan(row rowScanner) (*ViewerBan, error) {
+	var b ViewerBan
+	err := row.Scan(
+		&b.UserID,
+		&b.TwitchUserID,
+		&b.TwitchLogin,
+		&b.Until,
+		&b.BannedBy,
+		&b.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// GetViewerBan returns the viewer's ban or running timeout; an expired
+// timeout is no rows.
+func (db *DB) GetViewerBan(ctx context.Context, userID uuid.UUID, twitchUserID int) (*ViewerBan, error) {
+	ban, err := scanViewerBan(db.QueryRow(ctx, `
+		select `+viewerBanColumns+`
+		from viewer_bans vb
+		left join chat_users cu on cu.twitch_user_id = vb.twitch_user_id
+		where vb.user_id = $1 and vb.twitch_user_id = $2
+		and (vb.until is null or vb.until > now())
+	`, userID, twitchUserID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get viewer ban: %w", parseErr(err))
+	}
+
+	return ban, nil
+}
+
+// GetViewerBans lists the channel's bans and running timeouts, newest first.
+// Logins come from chat_users when known, so renamed viewers show up under
+// their current name.
+func (db *DB) GetViewerBans(ctx context.Context, userID uuid.UUID) ([]*ViewerBan, error) {
+	rows, err := db.Query(ctx, `
+		select `+viewerBanColumns+`
+		from viewer_bans vb
+		left join chat_users cu on cu.twitch_user_id = vb.twitch_user_id
+		where vb.user_id = $1
+		and (vb.until is null or vb.until > now())
+		order by vb.created_at desc
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get viewer bans: %w", err)
+	}
+	defer rows.Close()
+
+	var bans []*ViewerBan
+	for rows.Next() {
+		ban, err := scanViewerBan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan viewer ban: %w", err)
+		}
+		bans = append(bans, ban)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan viewer bans: %w", err)
+	}
+
+	return bans, nil
+}
+
+// TakeViewerRedeem counts a redeem against the viewer's limit for the reward
+// type. It reports false, recording nothing, when the window is already full.
+func (db *DB) TakeViewerRedeem(ctx context.Context, userID uuid.UUID, twitchUserID int, rewardType TwitchRewardType, limit RedeemLimit) (bool, error) {
+	var taken bool
+	err := db.QueryRow(ctx, `
+		with pruned as (
+			delete from viewer_redeems
+			where user_id = $1 and twitch_user_id = $2
+			and created_at < now() - $5::interval
+		),
+		used as (
+			select count(*) as n from viewer_redeems
+			where user_id = $1 and twitch_user_id = $2 and reward_type = $3
+			and created_at > now() - $4::interval
+		),
+		taken as (
+			insert into viewer_redeems (user_id, twitch_user_id, reward_type)
+			select $1, $2, $3 from used where used.n < $6
+			returning 1
+		)
+		select exists (select 1 from taken)
+	`, userID, twitchUserID, rewardType, limit.Window(), MaxRedeemLimitWindow, limit.Count).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("failed to take viewer redeem: %w", err)
+	}
+
+	return taken, nil
+}
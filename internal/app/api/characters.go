@@ -4,9 +4,11 @@ import (
 	"app/db"
 	"app/internal/app/conns"
 	"app/internal/app/processor"
+	"app/pkg/agentic"
 	"app/pkg/ai"
 	"app/pkg/ctxstore"
 	"app/pkg/ws"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -15,11 +17,14 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -137,6 +142,7 @@ type characterPage struct {
 	CharacterID     uuid.UUID
 	Card            *db.Card
 	MessageExamples *msgExamples
+	Voice           *voiceTuningView
 }
 
 func (api *API) character(r *http.Request) template.HTML {
@@ -189,10 +195,16 @@ func (api *API) character(r *http.Request) template.HTML {
 		}
 	}
 
+	var tts *db.CardTTS
+	if card != nil {
+		tts = card.Data.TTS
+	}
+
 	return getHtml("character.html", &characterPage{
 		CharacterID:     characterID,
 		Card:            card,
 		MessageExamples: msgExamples,
+		Voice:           api.voiceTuningView(tts),
 	})
 }
 
@@ -231,6 +243,7 @@ func formToCard(form url.Values) (*db.Card, error) {
 	card.Data.Name = form.Get("name")
 	card.Data.Description = form.Get("description")
 	card.Data.Personality = form.Get("personality")
+	card.Data.SystemPrompt = form.Get("system_prompt")
 
 	requests := make([]entry, 0, len(form))
 	responses := make([]entry, 0, len(form))
@@ -271,9 +284,97 @@ func formToCard(form url.Values) (*db.Card, error) {
 
 	card.Data.FirstMessage = form.Get("first_message")
 
+	tts, err := formToCardTTS(form)
+	if err != nil {
+		return nil, err
+	}
+	card.Data.TTS = tts
+
+	card.Data.Script = formToCardScript(form)
+
+	aliases, err := formToCardAliases(form)
+	if err != nil {
+		return nil, err
+	}
+	card.Data.Aliases = aliases
+
 	return card, nil
 }
 
+const (
+	// maxCardAliases and maxCardAliasRunes keep aliases to names, not lore.
+	maxCardAliases    = 10
+	maxCardAliasRunes = 50
+)
+
+// formToCardAliases reads the comma separated alias list, normalized the way
+// Agent BAJ detection matches prompts.
+func formToCardAliases(form url.Values) ([]string, error) {
+	var aliases []string
+	for _, alias := range strings.Split(form.Get("aliases"), ",") {
+		alias = agentic.NormalizeAlias(alias)
+		if alias == "" || slices.Contains(aliases, alias) {
+			continue
+		}
+		if utf8.RuneCountInString(alias) > maxCardAliasRunes {
+			return nil, fmt.Errorf("an alias can be at most %d characters long", maxCardAliasRunes)
+		}
+		aliases = append(aliases, alias)
+	}
+
+	if len(aliases) > maxCardAliases {
+		return nil, fmt.Errorf("a character can have at most %d aliases", maxCardAliases)
+	}
+
+	return aliases, nil
+}
+
+// maxScriptCast keeps the cast list in the prompt short.
+const maxScriptCast = 20
+
+// formToCardScript reads script mode; cast is a comma or space separated
+// list of short names.
+func formToCardScript(form url.Values) *db.CardScript {
+	if !form.Has("script_mode") {
+		return nil
+	}
+
+	script := &db.CardScript{}
+	for _, name := range strings.FieldsFunc(form.Get("script_cast"), func(r rune) bool { return r == ',' || r == ' ' }) {
+		name = strings.ToLower(strings.TrimSuffix(name, ":"))
+		if name != "" && !slices.Contains(script.Cast, name) {
+			script.Cast = append(script.Cast, name)
+		}
+	}
+
+	return script
+}
+
+func (api *API) checkCardScript(ctx context.Context, script *db.CardScript) error {
+	if script == nil || len(script.Cast) == 0 {
+		return nil
+	}
+	if len(script.Cast) > maxScriptCast {
+		return fmt.Errorf("a script cast can have at most %d voices", maxScriptCast)
+	}
+
+	voices, err := api.db.GetPublicShortNamedCards(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get public voices: %w", err)
+	}
+
+	for _, name := range script.Cast {
+		if name == processor.ScriptNarrator {
+			return fmt.Errorf("%s is the character's own voice and can't be in the cast", processor.ScriptNarrator)
+		}
+		if !slices.ContainsFunc(voices, func(v db.PublicShortName) bool { return strings.EqualFold(v.ShortCharName, name) }) {
+			return fmt.Errorf("unknown voice in the script cast: %s", name)
+		}
+	}
+
+	return nil
+}
+
 func (api *API) extractVoiceRef(r *http.Request) ([]byte, error) {
 	file, _, err := r.FormFile("voice_ref")
 	if err != nil {
@@ -304,20 +405,17 @@ func (api *API) extractImage(r *http.Request) ([]byte, error) {
 }
 
 func (api *API) updateCharacter(user *db.User, card *db.Card, w http.ResponseWriter, r *http.Request) {
-	var voiceRef []byte
-	if _, ok := r.MultipartForm.File["voice_ref"]; !ok {
-		oldCard, err := api.db.GetCharCardByID(r.Context(), user.ID, card.ID)
-		if err != nil {
-			_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
-				ErrorCode:    http.StatusInternalServerError,
-				ErrorMessage: err.Error(),
-			})
-			return
-		}
+	oldCard, err := api.db.GetCharCardByID(r.Context(), user.ID, card.ID)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
 
-		voiceRef = oldCard.Data.VoiceReference
-	} else {
-		var err error
+	voiceRef := oldCard.Data.VoiceReference
+	if _, ok := r.MultipartForm.File["voice_ref"]; ok {
 		voiceRef, err = api.extractVoiceRef(r)
 		if err != nil {
 			_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
@@ -329,20 +427,8 @@ func (api *API) updateCharacter(user *db.User, card *db.Card, w http.ResponseWri
 	}
 	card.Data.VoiceReference = voiceRef
 
-	var image []byte
-	if _, ok := r.MultipartForm.File["image"]; !ok {
-		oldCard, err := api.db.GetCharCardByID(r.Context(), user.ID, card.ID)
-		if err != nil {
-			_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
-				ErrorCode:    http.StatusInternalServerError,
-				ErrorMessage: err.Error(),
-			})
-			return
-		}
-
-		image = oldCard.Data.Image
-	} else {
-		var err error
+	image := oldCard.Data.Image
+	if _, ok := r.MultipartForm.File["image"]; ok {
 		image, err = api.extractImage(r)
 		if err != nil {
 			_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
@@ -363,10 +449,20 @@ func (api *API) updateCharacter(user *db.User, card *db.Card, w http.ResponseWri
 	}
 
 	api.imageCache.Invalidate(card.ID)
+	// the samples are spoken with the card's voice, same as saveVoiceTuning
+	if oldCard.ShortCharName.Valid && voiceChanged(oldCard.Data, card.Data) {
+		api.voiceSamples.Invalidate(oldCard.ShortCharName.String)
+	}
 
 	_, _ = w.Write([]byte("Success"))
 }
 
+// voiceChanged reports whether a card edit changes how the character sounds:
+// the reference clip, or the engine, voice and tuning.
+func voiceChanged(old, data *db.CardData) bool {
+	return !bytes.Equal(old.VoiceReference, data.VoiceReference) || !reflect.DeepEqual(old.TTS, data.TTS)
+}
+
 func (api *API) insertCharacter(user *db.User, card *db.Card, w http.ResponseWriter, r *http.Request) {
 	voiceRef, err := api.extractVoiceRef(r)
 	if err != nil {
@@ -450,6 +546,22 @@ func (api *API) upsertCharacter(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := api.checkCardTTS(card.Data.TTS); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if err := api.checkCardScript(r.Context(), card.Data.Script); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
 	characterID, err := uuid.Parse(characterIDStr)
 	if err != nil {
 		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
@@ -579,9 +691,18 @@ func (api *API) universalTTSReward(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	settings, err := parseRewardSettings(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
 	prompt := "Voices: " + r.Host + "/voices"
 
-	if err := api.createRewardAndUpsert(r.Context(), user, nil, "", db.TwitchRewardUniversalTTS, prompt); err != nil {
+	if err := api.createRewardAndUpsert(r.Context(), user, nil, "", db.TwitchRewardUniversalTTS, prompt, settings); err != nil {
 		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
 			ErrorCode:    http.StatusInternalServerError,
 			ErrorMessage: err.Error(),
@@ -603,7 +724,16 @@ func (api *API) agenticReward(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := api.createRewardAndUpsert(r.Context(), user, nil, "", db.TwitchRewardAgentic, ""); err != nil {
+	settings, err := parseRewardSettings(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if err := api.createRewardAndUpsert(r.Context(), user, nil, "", db.TwitchRewardAgentic, "", settings); err != nil {
 		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
 			ErrorCode:    http.StatusInternalServerError,
 			ErrorMessage: err.Error(),
@@ -621,9 +751,14 @@ type rewardChooseSpecialData struct {
 	ExistingPostURL  string
 	Error            string
 	ExistingRewardID string
+
+	Settings *db.TwitchRewardData
+	Existing *existingReward
 }
 
-func (api *API) universalTTSRewardChoose(r *http.Request) template.HTML {
+// specialRewardChoose renders the choose page of a card-less reward, with an
+// edit form when one is already mapped.
+func (api *API) specialRewardChoose(r *http.Request, data *rewardChooseSpecialData, rewardType db.TwitchRewardType, editPostURL string) template.HTML {
 	user := ctxstore.GetUser(r.Context())
 	if user == nil {
 		return getHtml("error.html", &htmlErr{
@@ -632,11 +767,26 @@ func (api *API) universalTTSRewardChoose(r *http.Request) template.HTML {
 		})
 	}
 
-	return getHtml("reward_choose_special.html", &rewardChooseSpecialData{
+	existing, err := api.existingRewards(r.Context(), user.ID, nil, editPostURL, rewardType)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "get rewards: " + err.Error(),
+		})
+	}
+	if len(existing) != 0 {
+		data.Existing = &existing[0]
+	}
+
+	return getHtml("reward_choose_special.html", data)
+}
+
+func (api *API) universalTTSRewardChoose(r *http.Request) template.HTML {
+	return api.specialRewardChoose(r, &rewardChooseSpecialData{
 		Subtitle:        "Universal TTS",
 		CreatePostURL:   "/universal-tts/reward",
 		ExistingPostURL: "/universal-tts/reward_existing",
-	})
+	}, db.TwitchRewardUniversalTTS, "/universal-tts/reward_edit")
 }
 
 func (api *API) universalTTSRewardExisting(w http.ResponseWriter, r *http.Request) {
@@ -671,7 +821,7 @@ func (api *API) universalTTSRewardExisting(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := api.db.UpsertUniversalTTSReward(r.Context(), user.ID, existingID); err != nil {
+	if err := api.db.UpsertUniversalTTSReward(r.Context(), user.ID, existingID, nil); err != nil {
 		_ = html.ExecuteTemplate(w, "reward_choose_special.html", &rewardChooseSpecialData{
 			Subtitle:         "Universal TTS",
 			CreatePostURL:    "/universal-tts/reward",
@@ -687,19 +837,11 @@ func (api *API) universalTTSRewardExisting(w http.ResponseWriter, r *http.Reques
 }
 
 func (api *API) agenticRewardChoose(r *http.Request) template.HTML {
-	user := ctxstore.GetUser(r.Context())
-	if user == nil {
-		return getHtml("error.html", &htmlErr{
-			ErrorCode:    http.StatusUnauthorized,
-			ErrorMessage: "not authorized",
-		})
-	}
-
-	return getHtml("reward_choose_special.html", &rewardChooseSpecialData{
+	return api.specialRewardChoose(r, &rewardChooseSpecialData{
 		Subtitle:        "Agent Baj",
 		CreatePostURL:   "/agentic/reward",
 		ExistingPostURL: "/agentic/reward_existing",
-	})
+	}, db.TwitchRewardAgentic, "/agentic/reward_edit")
 }
 
 func (api *API) agenticRewardExisting(w http.ResponseWriter, r *http.Request) {
@@ -734,7 +876,7 @@ func (api *API) agenticRewardExisting(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := api.db.UpsertAgenticReward(r.Context(), user.ID, existingID); err != nil {
+	if err := api.db.UpsertAgenticReward(r.Context(), user.ID, existingID, nil); err != nil {
 		_ = html.ExecuteTemplate(w, "reward_choose_special.html", &rewardChooseSpecialData{
 			Subtitle:         "Agent Baj",
 			CreatePostURL:    "/agentic/reward",
@@ -754,6 +896,10 @@ type tryPageData struct {
 	CharacterName string
 	AgenticMode   bool
 	UniversalMode bool
+
+	// Voice is the voice A/B panel, character mode only.
+	Voice   *voiceTuningView
+	CanEdit bool
 }
 
 // tryCharacter serves the try page for testing a character
@@ -796,6 +942,8 @@ func (api *API) tryCharacter(r *http.Request) template.HTML {
 		CharacterName: card.Name,
 		AgenticMode:   false,
 		UniversalMode: false,
+		Voice:         api.voiceTuningView(card.Data.TTS),
+		CanEdit:       card.OwnerUserID == user.ID,
 	})
 }
 
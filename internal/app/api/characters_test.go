@@ -0,0 +1,129 @@
+package api
+
+import (
+	"app/db"
+	"app/pkg/ai"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFormToCardTTS(t *testing.T) {
+	tts, err := formToCardTTS(url.Values{"tts_engine": {""}, "tts_alpha": {" "}})
+	if err != nil || tts != nil {
+		t.Fatalf("expected no tts settings for an empty section, got %+v, %v", tts, err)
+	}
+
+	tts, err = formToCardTTS(url.Values{
+		"tts_engine":     {"style"},
+		"tts_emo_weight": {"0.6"},
+		"tts_max_tokens": {"80"},
+		"tts_alpha":      {"0.3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tts.Engine != "style" || tts.EmoWeight != 0.6 || tts.MaxTextTokensPerSentence != 80 {
+		t.Errorf("unexpected tts settings: %+v", tts)
+	}
+	if tts.Alpha == nil || *tts.Alpha != 0.3 || tts.Beta != nil {
+		t.Errorf("expected only alpha to be set, got %v and %v", tts.Alpha, tts.Beta)
+	}
+
+	if _, err := formToCardTTS(url.Values{"tts_beta": {"1.5"}}); err == nil {
+		t.Error("expected an out of range beta to be rejected")
+	}
+}
+
+func TestFormToCardTTSVoiceTuning(t *testing.T) {
+	tts, err := formToCardTTS(url.Values{
+		"tts_speed":         {"1.25"},
+		"tts_pitch":         {"-3"},
+		"tts_gain":          {"4"},
+		"tts_emotion_angry": {"0.5"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tts.Speed != 1.25 || tts.Pitch != -3 || tts.Gain != 4 {
+		t.Errorf("unexpected voice tuning: %+v", tts)
+	}
+	if len(tts.EmoVector) != len(ai.EmotionNames) {
+		t.Fatalf("expected a full emotion vector, got %v", tts.EmoVector)
+	}
+	for i, name := range ai.EmotionNames {
+		want := 0.0
+		if name == "angry" {
+			want = 0.5
+		}
+		if tts.EmoVector[i] != want {
+			t.Errorf("emotion %s: expected %g, got %g", name, want, tts.EmoVector[i])
+		}
+	}
+
+	for _, form := range []url.Values{
+		{"tts_speed": {"3"}},
+		{"tts_pitch": {"13"}},
+		{"tts_gain": {"loud"}},
+		{"tts_emotion_happy": {"-0.1"}},
+	} {
+		if _, err := formToCardTTS(form); err == nil {
+			t.Errorf("expected %v to be rejected", form)
+		}
+	}
+}
+
+func TestVoiceChanged(t *testing.T) {
+	old := &db.CardData{Name: "Bob", VoiceReference: []byte("ref"), TTS: &db.CardTTS{Engine: "style", Speed: 1.2}}
+
+	same := *old
+	same.Name = "Robert"
+	same.TTS = &db.CardTTS{Engine: "style", Speed: 1.2}
+	if voiceChanged(old, &same) {
+		t.Error("a rename should keep the voice samples")
+	}
+
+	tuned := same
+	tuned.TTS = &db.CardTTS{Engine: "style", Speed: 1.5}
+	if !voiceChanged(old, &tuned) {
+		t.Error("a new speed should drop the voice samples")
+	}
+
+	newRef := same
+	newRef.VoiceReference = []byte("other")
+	if !voiceChanged(old, &newRef) {
+		t.Error("a new reference clip should drop the voice samples")
+	}
+}
+
+func TestFormToCardScript(t *testing.T) {
+	if script := formToCardScript(url.Values{"script_cast": {"forsen"}}); script != nil {
+		t.Fatalf("expected no script without script mode, got %+v", script)
+	}
+
+	script := formToCardScript(url.Values{"script_mode": {""}, "script_cast": {"Forsen, obiwan forsen: ,"}})
+	if script == nil {
+		t.Fatal("expected script mode to be on")
+	}
+	if len(script.Cast) != 2 || script.Cast[0] != "forsen" || script.Cast[1] != "obiwan" {
+		t.Errorf("unexpected cast: %v", script.Cast)
+	}
+}
+
+func TestFormToCardAliases(t *testing.T) {
+	aliases, err := formToCardAliases(url.Values{"aliases": {"Narcissism  Doctor, dr. les,, narcissism doctor"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(aliases) != 2 || aliases[0] != "narcissism doctor" || aliases[1] != "dr les" {
+		t.Errorf("unexpected aliases: %v", aliases)
+	}
+
+	if aliases, err := formToCardAliases(url.Values{}); err != nil || aliases != nil {
+		t.Errorf("expected no aliases, got %v, %v", aliases, err)
+	}
+
+	if _, err := formToCardAliases(url.Values{"aliases": {strings.Repeat("a", maxCardAliasRunes+1)}}); err == nil {
+		t.Error("expected an error for a long alias")
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"app/db"
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// The control API drives a channel's queue over plain HTTP, authenticated by
+// the channel's API token (the one the OBS overlay uses), so Stream Deck,
+// Touch Portal and chat bots work without a focused browser source.
+
+// apiToken reads the token from an "Authorization: Bearer" header, falling
+// back to a token field in a POST body for tools that cannot set headers.
+// Never the query string: URLs end up in logs and browser history.
+func apiToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+
+	if r.Method != http.MethodPost {
+		return ""
+	}
+
+	return r.PostFormValue("token")
+}
+
+func validAPIToken(token, expected string) bool {
+	return len(expected) != 0 && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// controlChannels looks up the channel a control API call drives.
+type controlChannels interface {
+	GetUserByTwitchLogin(ctx context.Context, twitchLogin string) (*db.User, error)
+	GetUserSettings(ctx context.Context, userID uuid.UUID) (*db.UserSettings, error)
+	HasPermission(ctx context.Context, twitchUserID int, permission db.Permission) (bool, db.PermissionStatus, error)
+}
+
+// controlAPI resolves {twitch_login}, checks the token and the streamer
+// permission, then runs action. The token is handed on because the processor
+// re-checks it for the *Current updates.
+func (api *API) controlAPI(action func(user *db.User, token string, r *http.Request) error) http.HandlerFunc {
+	return controlHandler(api.logger, api.db, action)
+}
+
+func controlHandler(logger *slog.Logger, channels controlChannels, action func(user *db.User, token string, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		twitchLogin := chi.URLParam(r, "twitch_login")
+
+		user, err := channels.GetUserByTwitchLogin(r.Context(), twitchLogin)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("user not found"))
+
+			return
+		}
+
+		settings, err := channels.GetUserSettings(r.Context(), user.ID)
+		if err != nil {
+			logger.Error("failed to get user settings", "err", err, "user", twitchLogin)
+
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("failed to get user settings"))
+
+			return
+		}
+
+		token := apiToken(r)
+		if token == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("missing token"))
+
+			return
+		}
+		if !validAPIToken(token, settings.Token) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("invalid token"))
+
+			return
+		}
+
+		if hasPerm, _, err := channels.HasPermission(r.Context(), user.TwitchUserID, db.PermissionStreamer); err != nil {
+			logger.Error("failed to check permission", "err", err, "user", twitchLogin)
+
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("failed to check permission"))
+
+			return
+		} else if !hasPerm {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("you don't have permission"))
+
+			return
+		}
+
+		if err := action(user, token, r); err != nil {
+			logger.Error("control api action failed", "err", err, "user", twitchLogin, "path", r.URL.Path)
+
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("failed: " + err.Error()))
+
+			return
+		}
+
+		_, _ = w.Write([]byte("success"))
+	}
+}
+
+func (api *API) apiSkipCurrent(user *db.User, token string, r *http.Request) error {
+	api.connManager.SkipCurrent(user.ID, token, r.FormValue("msg_id"))
+	return nil
+}
+
+func (api *API) apiShowImagesCurrent(user *db.User, token string, r *http.Request) error {
+	api.connManager.ShowImagesCurrent(user.ID, token, r.FormValue("msg_id"))
+	return nil
+}
+
+func (api *API) apiClean(user *db.User, _ string, _ *http.Request) error {
+	api.connManager.CleanOverlay(user.ID)
+	return nil
+}
+
+func (api *API) apiPause(user *db.User, _ string, r *http.Request) error {
+	return api.setQueuePaused(r.Context(), user.ID, true)
+}
+
+func (api *API) apiResume(user *db.User, _ string, r *http.Request) error {
+	return api.setQueuePaused(r.Context(), user.ID, false)
+}
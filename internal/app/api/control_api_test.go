@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"app/db"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestAPIToken(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest("POST", "/api/v1/streamer/skip-current?token=fromquery", nil)
+	if got := apiToken(req); got != "" {
+		t.Fatalf("got %q, the query string must not carry the token", got)
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/streamer/skip-current", strings.NewReader(url.Values{"token": {"frombody"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if got := apiToken(req); got != "frombody" {
+		t.Fatalf("got %q, want body token", got)
+	}
+
+	req.Header.Set("Authorization", "Bearer fromheader")
+	if got := apiToken(req); got != "fromheader" {
+		t.Fatalf("got %q, want header token", got)
+	}
+}
+
+func TestValidAPIToken(t *testing.T) {
+	t.Parallel()
+
+	if validAPIToken("", "") {
+		t.Fatal("empty token accepted for a user without one")
+	}
+	if validAPIToken("abc", "abd") {
+		t.Fatal("wrong token accepted")
+	}
+	if !validAPIToken("abc", "abc") {
+		t.Fatal("right token rejected")
+	}
+}
+
+// fakeControlChannels is one streamer, "streamer", with the token "secret".
+type fakeControlChannels struct {
+	user *db.User
+}
+
+func (f *fakeControlChannels) GetUserByTwitchLogin(_ context.Context, twitchLogin string) (*db.User, error) {
+	if twitchLogin != f.user.TwitchLogin {
+		return nil, errors.New("no rows")
+	}
+	return f.user, nil
+}
+
+func (f *fakeControlChannels) GetUserSettings(context.Context, uuid.UUID) (*db.UserSettings, error) {
+	return &db.UserSettings{Token: "secret"}, nil
+}
+
+func (f *fakeControlChannels) HasPermission(context.Context, int, db.Permission) (bool, db.PermissionStatus, error) {
+	return true, 0, nil
+}
+
+func TestControlHandler(t *testing.T) {
+	t.Parallel()
+
+	channels := &fakeControlChannels{user: &db.User{ID: uuid.New(), TwitchLogin: "streamer", TwitchUserID: 42}}
+
+	var ran []string
+	router := chi.NewRouter()
+	router.Post("/api/v1/{twitch_login}/skip-current", controlHandler(slog.New(slog.DiscardHandler), channels, func(user *db.User, token string, r *http.Request) error {
+		ran = append(ran, user.TwitchLogin+":"+token)
+		return nil
+	}))
+
+	for _, tc := range []struct {
+		name   string
+		login  string
+		query  string
+		header string
+		body   string
+		code   int
+	}{
+		{name: "unknown channel", login: "nobody", header: "Bearer secret", code: http.StatusNotFound},
+		{name: "missing token", login: "streamer", code: http.StatusUnauthorized},
+		{name: "token in the query", login: "streamer", query: "?token=secret", code: http.StatusUnauthorized},
+		{name: "wrong token", login: "streamer", header: "Bearer nope", code: http.StatusForbidden},
+		{name: "header token", login: "streamer", header: "Bearer secret", code: http.StatusOK},
+		{name: "body token", login: "streamer", body: "token=secret", code: http.StatusOK},
+	} {
+		req := httptest.NewRequest("POST", "/api/v1/"+tc.login+"/skip-current"+tc.query, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.code {
+			body, _ := io.ReadAll(w.Body)
+			t.Fatalf("%s: got %d (%s), want %d", tc.name, w.Code, body, tc.code)
+		}
+	}
+
+	if len(ran) != 2 || ran[0] != "streamer:secret" || ran[1] != "streamer:secret" {
+		t.Fatalf("action ran %v, want once per accepted call", ran)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	immediateticker "app/pkg/immediate_ticker"
 	"app/pkg/textfilter"
 	"app/pkg/ws"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/nicklaw5/helix/v2"
 )
@@ -115,6 +117,12 @@ type controlPanelUser struct {
 
 type controlPanel struct {
 	User *controlPanelUser
+
+	MemoryTTLs        []memoryTTL
+	MaxMemoryTextLen  int
+	SuggestedMemories int
+
+	ViewerBans []*db.ViewerBan
 }
 
 func (api *API) controlPanel(r *http.Request) template.HTML {
@@ -154,11 +162,33 @@ func (api *API) controlPanel(r *http.Request) template.HTML {
 		})
 	}
 
+	suggested, err := api.db.CountSuggestedMemories(r.Context(), targetUser.ID)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+	}
+
+	bans, err := api.db.GetViewerBans(r.Context(), targetUser.ID)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+	}
+
 	return getHtml("control_panel.html", &controlPanel{
 		User: &controlPanelUser{
 			TwitchLogin:  targetUser.TwitchLogin,
 			TwitchUserID: targetUser.TwitchUserID,
 		},
+
+		MemoryTTLs:        memoryTTLs,
+		MaxMemoryTextLen:  db.MaxMemoryTextLen,
+		SuggestedMemories: suggested,
+
+		ViewerBans: bans,
 	})
 }
 
@@ -228,6 +258,7 @@ func (api *API) controlPanelWSConn(w http.ResponseWriter, r *http.Request) {
 	defer ticker.Stop()
 
 	lastUpdated := 0
+	var lastPaused *bool
 
 	lastActive := time.Now()
 
@@ -254,6 +285,8 @@ func (api *API) controlPanelWSConn(w http.ResponseWriter, r *http.Request) {
 			switch upd.Action {
 			case ActionDeleteString:
 				api.connManager.SkipMessage(targetUser.ID, upd.ID)
+			case ActionWrapUpString:
+				api.connManager.WrapUpMessage(targetUser.ID, upd.ID)
 			case ActionImagesShowString:
 				api.connManager.ShowImages(targetUser.ID, upd.ID)
 			case ActionImagesHideString:
@@ -262,6 +295,38 @@ func (api *API) controlPanelWSConn(w http.ResponseWriter, r *http.Request) {
 				api.connManager.CleanOverlay(targetUser.ID)
 			case ActionReloadOverlayString:
 				api.connManager.ReloadOverlay(targetUser.ID)
+			case ActionPauseString, ActionResumeString:
+				if err := api.setQueuePaused(r.Context(), targetUser.ID, upd.Action == ActionPauseString); err != nil {
+					logger.Error("failed to set queue paused", "err", err)
+				}
+			case ActionMoveUpString, ActionMoveDownString, ActionPlayNextString, ActionDeferString:
+				if err := api.handleMove(r.Context(), wsClient, targetUser.ID, upd.ID, queueMoves[upd.Action]); err != nil {
+					logger.Error("failed to send move result", "err", err, "action", upd.Action, "msg_id", upd.ID)
+				}
+			case ActionReplayString:
+				if err := api.replayMsg(r.Context(), targetUser.ID, upd.ID); err != nil {
+					logger.Error("failed to replay message", "err", err, "msg_id", upd.ID)
+				}
+			case ActionBanViewerString, ActionTimeoutViewerString:
+				var timeout time.Duration
+				if upd.Action == ActionTimeoutViewerString {
+					timeout = viewerTimeout
+				}
+				if err := api.banViewer(r.Context(), user, targetUser.ID, upd.ID, timeout); err != nil {
+					logger.Error("failed to ban viewer", "err", err, "action", upd.Action, "msg_id", upd.ID)
+				}
+			case ActionRememberString:
+				if err := api.handleRemember(r.Context(), wsClient, targetUser, upd.ID); err != nil {
+					logger.Error("failed to send memory prefill", "err", err)
+				}
+			case ActionRememberSaveString:
+				if err := api.handleRememberSave(r.Context(), wsClient, user, targetUser, upd.ID, upd.Memory); err != nil {
+					logger.Error("failed to send memory save result", "err", err)
+				}
+			case ActionFlagFilterString:
+				if err := api.handleFilterFlag(r.Context(), wsClient, user, targetUser, upd.ID, upd.FilterFlag); err != nil {
+					logger.Error("failed to send filter flag result", "err", err)
+				}
 			default:
 				logger.Error("unknown action", "action", upd.Action)
 			}
@@ -286,7 +351,26 @@ loop:
 			break loop
 		}
 
-		if len(dbMessages) == 0 {
+		settings, err := api.db.GetUserSettings(r.Context(), targetUser.ID)
+		if err != nil {
+			logger.Error("failed to get user settings", "err", err)
+			break loop
+		}
+
+		var queueState *Update
+		if lastPaused == nil || *lastPaused != settings.QueuePaused {
+			paused := settings.QueuePaused
+			lastPaused = &paused
+
+			data, err := json.Marshal(&msgQueueState{Paused: paused})
+			if err != nil {
+				logger.Error("failed to marshal queue state", "err", err)
+				break loop
+			}
+			queueState = &Update{Action: ActionQueueState, Data: data}
+		}
+
+		if len(dbMessages) == 0 && queueState == nil {
 			if time.Since(lastActive) > 20*time.Second {
 				err = wsClient.Send(&ws.Message{
 					MsgType: websocket.BinaryMessage,
@@ -302,7 +386,10 @@ loop:
 			continue
 		}
 
-		updates := make([]Update, 0, len(dbMessages))
+		updates := make([]Update, 0, len(dbMessages)+1)
+		if queueState != nil {
+			updates = append(updates, *queueState)
+		}
 		for _, dbMessage := range dbMessages {
 			lastUpdated = max(lastUpdated, dbMessage.Updated)
 			var data []byte
@@ -312,9 +399,16 @@ loop:
 			switch dbMessage.Status {
 			case db.MsgStatusProcessed, db.MsgStatusDeleted:
 				action = ActionDelete
-				data, err = json.Marshal(&msgDelete{
-					ID: dbMessage.ID.String(),
-				})
+				del := &msgDelete{
+					ID:        dbMessage.ID.String(),
+					Processed: dbMessage.Status == db.MsgStatusProcessed,
+				}
+				if msgData, err := db.ParseMessageData(dbMessage.Data); err == nil && msgData.SkipReason != "" {
+					del.RequestedBy = dbMessage.TwitchMessage.TwitchLogin
+					del.Request = dbMessage.TwitchMessage.Message
+					del.SkipReason = msgData.SkipReason
+				}
+				data, err = json.Marshal(del)
 				if err != nil {
 					logger.Error("failed to marshal message", "err", err)
 					break loop
@@ -327,7 +421,13 @@ loop:
 					rewardTypeStr string = "unknown"
 				)
 
-				if len(dbMessage.TwitchMessage.RewardID) == 0 {
+				if ev := dbMessage.TwitchMessage.Event; ev != nil {
+					rewardTypeStr = db.TwitchRewardEvent.String()
+					charName = "-"
+					if rule, err := api.db.GetEventRule(r.Context(), dbMessage.UserID, ev.Type); err == nil {
+						charName = rule.CardName
+					}
+				} else if len(dbMessage.TwitchMessage.RewardID) == 0 {
 					rewardTypeStr = "Chat TTS"
 					charName = "-"
 				} else if charCard, rewardType, err := api.db.GetCharCardByTwitchRewardNoPerms(r.Context(), dbMessage.TwitchMessage.RewardID); err == nil {
@@ -401,7 +501,8 @@ loop:
 					FilteredText:    msgData.FilteredText,
 					RequestFiltered: msgData.RequestFiltered,
 
-					Status: dbMessage.Status.String(),
+					Status:   dbMessage.Status.String(),
+					Position: dbMessage.Position,
 
 					ShowImages: msgData.ShowImages != nil && *msgData.ShowImages,
 					ImageURLs:  imageURLs,
@@ -448,6 +549,20 @@ loop:
 
 type msgDelete struct {
 	ID string `json:"id"`
+
+	// Processed rows move to the panel's "recently processed" list so they
+	// can still be remembered; deleted ones just disappear.
+	Processed bool `json:"processed,omitempty"`
+
+	// SkipReason is set when the processor skipped the message on its own;
+	// the panel lists those with who asked for what.
+	SkipReason  string `json:"skip_reason,omitempty"`
+	RequestedBy string `json:"requested_by,omitempty"`
+	Request     string `json:"request,omitempty"`
+}
+
+type msgQueueState struct {
+	Paused bool `json:"paused"`
 }
 
 type msgUpsert struct {
@@ -468,6 +583,10 @@ type msgUpsert struct {
 
 	Status string `json:"status"`
 
+	// Position is the play order sort key, not an index: the panel sorts
+	// waiting rows by it since only moved rows get resent.
+	Position int64 `json:"position"`
+
 	ShowImages bool     `json:"show_images"`
 	ImageURLs  []string `json:"image_urls,omitempty"`
 }
@@ -479,19 +598,44 @@ const (
 	ActionUpsert
 	ActionImagesShow
 	ActionImagesHide
+	ActionMemoryPrefill
+	ActionMemorySaved
+	ActionQueueState
+	ActionFilterFlagged
+	ActionMoveFailed
 )
 
 type ActionString string
 
 const (
-	ActionDeleteString       ActionString = "delete"
-	ActionUpsertString       ActionString = "upsert"
-	ActionImagesShowString   ActionString = "show_images"
-	ActionImagesHideString   ActionString = "hide_images"
-	ActionCleanOverlayString ActionString = "clean_overlay"
+	ActionDeleteString        ActionString = "delete"
+	ActionUpsertString        ActionString = "upsert"
+	ActionImagesShowString    ActionString = "show_images"
+	ActionImagesHideString    ActionString = "hide_images"
+	ActionCleanOverlayString  ActionString = "clean_overlay"
 	ActionReloadOverlayString ActionString = "reload_overlay"
+	ActionRememberString      ActionString = "remember"
+	ActionRememberSaveString  ActionString = "remember_save"
+	ActionPauseString         ActionString = "pause"
+	ActionResumeString        ActionString = "resume"
+	ActionMoveUpString        ActionString = "move_up"
+	ActionMoveDownString      ActionString = "move_down"
+	ActionPlayNextString      ActionString = "play_next"
+	ActionDeferString         ActionString = "defer"
+	ActionReplayString        ActionString = "replay"
+	ActionBanViewerString     ActionString = "ban_viewer"
+	ActionTimeoutViewerString ActionString = "timeout_viewer"
+	ActionFlagFilterString    ActionString = "flag_filter"
+	ActionWrapUpString        ActionString = "wrap_up"
 )
 
+var queueMoves = map[ActionString]db.QueueMove{
+	ActionMoveUpString:   db.QueueMoveUp,
+	ActionMoveDownString: db.QueueMoveDown,
+	ActionPlayNextString: db.QueueMoveNext,
+	ActionDeferString:    db.QueueMoveLast,
+}
+
 func (a ActionString) Action() Action {
 	switch a {
 	case ActionDeleteString:
@@ -507,6 +651,126 @@ func (a ActionString) Action() Action {
 	}
 }
 
+// setQueuePaused persists the flag first so a processor (re)started in
+// between still picks it up, then tells the running one.
+func (api *API) setQueuePaused(ctx context.Context, userID uuid.UUID, paused bool) error {
+	if err := api.db.SetQueuePaused(ctx, userID, paused); err != nil {
+		return err
+	}
+
+	if paused {
+		api.connManager.PauseQueue(userID)
+	} else {
+		api.connManager.ResumeQueue(userID)
+	}
+	api.connManager.NotifyControlPanel(userID)
+
+	return nil
+}
+
+// moveFailed tells the panel a move was refused; ErrorCode is 409 when the
+// message left the queue before the move landed.
+type moveFailed struct {
+	ID        string `json:"id"`
+	ErrorCode int    `json:"error_code"`
+	Error     string `json:"error"`
+}
+
+func moveErrCode(err error) int {
+	if errors.Is(err, db.ErrMsgNotWaiting) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// handleMove answers a failed move on the panel so a stale row does not look
+// moved; a message that already played or was skipped is a conflict.
+func (api *API) handleMove(ctx context.Context, wsClient *ws.Client, userID uuid.UUID, msgID string, move db.QueueMove) error {
+	err := api.moveMsg(ctx, userID, msgID, move)
+	if err == nil {
+		return nil
+	}
+
+	code := moveErrCode(err)
+	if code != http.StatusConflict {
+		api.logger.Error("failed to move message", "err", err, "msg_id", msgID)
+	}
+
+	return api.sendAction(wsClient, ActionMoveFailed, &moveFailed{ID: msgID, ErrorCode: code, Error: err.Error()})
+}
+
+func (api *API) moveMsg(ctx context.Context, userID uuid.UUID, msgID string, move db.QueueMove) error {
+	id, err := uuid.Parse(msgID)
+	if err != nil {
+		return fmt.Errorf("invalid msg id: %w", err)
+	}
+
+	if err := api.db.MoveMsg(ctx, userID, id, move); err != nil {
+		return err
+	}
+	api.connManager.NotifyControlPanel(userID)
+
+	return nil
+}
+
+// checkReplay refuses messages a replay can't reproduce. Agentic runs keep no
+// transcript, and AI replies or event reactions without a stored reply would
+// need a new LLM call.
+func checkReplay(msg *db.Message, rewardType db.TwitchRewardType) error {
+	if rewardType == db.TwitchRewardAgentic {
+		return fmt.Errorf("agentic messages can't be replayed")
+	}
+	if msg.TwitchMessage.Event == nil && rewardType != db.TwitchRewardAI {
+		return nil
+	}
+
+	msgData, err := db.ParseMessageData(msg.Data)
+	if err != nil {
+		return err
+	}
+	if msgData.AIResponse == "" {
+		return db.ErrMsgNoReply
+	}
+
+	return nil
+}
+
+// replayMsg queues a processed message to play next, see checkReplay for
+// what is refused.
+func (api *API) replayMsg(ctx context.Context, userID uuid.UUID, msgID string) error {
+	id, err := uuid.Parse(msgID)
+	if err != nil {
+		return fmt.Errorf("invalid msg id: %w", err)
+	}
+
+	msg, err := api.db.GetMessageByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if msg.UserID != userID {
+		return fmt.Errorf("message belongs to another user")
+	}
+
+	// chat messages without a reward are read out like TTS ones
+	rewardType := db.TwitchRewardTTS
+	if len(msg.TwitchMessage.RewardID) != 0 {
+		_, rewardType, err = api.db.GetRewardByTwitchReward(ctx, msg.TwitchMessage.RewardID)
+		if err != nil {
+			return fmt.Errorf("failed to get reward: %w", err)
+		}
+	}
+	if err := checkReplay(msg, rewardType); err != nil {
+		return err
+	}
+
+	if _, err := api.db.ReplayMsg(ctx, userID, id); err != nil {
+		return err
+	}
+	api.connManager.NotifyControlPanel(userID)
+
+	return nil
+}
+
 type Update struct {
 	Action Action `json:"action"`
 	Data   []byte `json:"data"`
@@ -517,9 +781,33 @@ type Updates struct {
 	Updates  []Update `json:"updates"`
 }
 
+// sendAction sends one action with its payload to a single panel, as the
+// answer to something that panel asked for.
+func (api *API) sendAction(wsClient *ws.Client, action Action, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal action payload: %w", err)
+	}
+
+	msg, err := json.Marshal(&Updates{
+		Updates: []Update{{Action: action, Data: data}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal action update: %w", err)
+	}
+
+	return wsClient.Send(&ws.Message{
+		MsgType: websocket.BinaryMessage,
+		Message: msg,
+	})
+}
+
 type actionMessage struct {
 	ID     string       `json:"id"`
 	Action ActionString `json:"action"`
+
+	Memory     *memoryForm     `json:"memory,omitempty"`      // remember_save only
+	FilterFlag *filterFlagForm `json:"filter_flag,omitempty"` // flag_filter only
 }
 
 func (api *API) controlPanelGrant(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"app/db"
+)
+
+func TestMoveErrCode(t *testing.T) {
+	t.Parallel()
+
+	if got := moveErrCode(fmt.Errorf("move: %w", db.ErrMsgNotWaiting)); got != http.StatusConflict {
+		t.Fatalf("got %d for a message no longer waiting, want 409", got)
+	}
+	if got := moveErrCode(errors.New("connection reset")); got != http.StatusInternalServerError {
+		t.Fatalf("got %d for a database error, want 500", got)
+	}
+}
+
+func TestCheckReplay(t *testing.T) {
+	t.Parallel()
+
+	reply := []byte(`{"ai_response":"Hello chat."}`)
+	event := db.TwitchMessage{Event: &db.MessageEvent{Type: db.MessageEventRaid}}
+
+	for _, tc := range []struct {
+		name       string
+		msg        db.Message
+		rewardType db.TwitchRewardType
+		err        error
+	}{
+		{"tts without reply", db.Message{}, db.TwitchRewardTTS, nil},
+		{"ai with reply", db.Message{Data: reply}, db.TwitchRewardAI, nil},
+		{"ai without reply", db.Message{Data: []byte(`{}`)}, db.TwitchRewardAI, db.ErrMsgNoReply},
+		{"event with reply", db.Message{TwitchMessage: event, Data: reply}, db.TwitchRewardTTS, nil},
+		{"event without reply", db.Message{TwitchMessage: event}, db.TwitchRewardTTS, db.ErrMsgNoReply},
+	} {
+		if err := checkReplay(&tc.msg, tc.rewardType); !errors.Is(err, tc.err) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.err)
+		}
+	}
+
+	if err := checkReplay(&db.Message{Data: reply}, db.TwitchRewardAgentic); err == nil {
+		t.Error("agentic message was accepted")
+	}
+}
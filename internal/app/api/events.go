@@ -0,0 +1,218 @@
+package api
+
+import (
+	"app/db"
+	"app/pkg/ctxstore"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const maxEventPromptLen = 500
+
+type eventKind struct {
+	Label          string
+	DefaultPrompt  string
+	ThresholdLabel string // empty when the event has no size
+}
+
+var eventKinds = map[string]eventKind{
+	db.MessageEventFollow: {
+		Label:         "Follow",
+		DefaultPrompt: "{user} just followed the channel, thank them",
+	},
+	db.MessageEventSub: {
+		Label:         "Sub",
+		DefaultPrompt: "{user} just subscribed at {tier}, thank them",
+	},
+	db.MessageEventGift: {
+		Label:          "Gift subs",
+		DefaultPrompt:  "{user} just gifted {total} {tier} subs to chat, hype it up",
+		ThresholdLabel: "Min gifted subs",
+	},
+	db.MessageEventRaid: {
+		Label:          "Raid",
+		DefaultPrompt:  "{user} just raided with {viewers} viewers, welcome them",
+		ThresholdLabel: "Min raid viewers",
+	},
+}
+
+type eventRuleForm struct {
+	EventType string
+	eventKind
+
+	Configured   bool
+	Enabled      bool
+	CardID       uuid.UUID
+	Prompt       string
+	MinThreshold int
+
+	Cards []db.MemoryCard
+}
+
+type eventsPage struct {
+	Rules []*eventRuleForm
+}
+
+func (api *API) events(r *http.Request) template.HTML {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusUnauthorized,
+			ErrorMessage: "not authorized",
+		})
+	}
+
+	rules, err := api.db.GetEventRules(r.Context(), user.ID)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+	}
+
+	cards, err := api.db.GetMemoryCards(r.Context(), user.ID)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+	}
+
+	page := &eventsPage{}
+	for _, eventType := range db.MessageEventTypes {
+		kind := eventKinds[eventType]
+		form := &eventRuleForm{
+			EventType: eventType,
+			eventKind: kind,
+			Enabled:   true,
+			Prompt:    kind.DefaultPrompt,
+			Cards:     cards,
+		}
+
+		if i := slices.IndexFunc(rules, func(rule *db.EventRule) bool { return rule.EventType == eventType }); i >= 0 {
+			rule := rules[i]
+			form.Configured = true
+			form.Enabled = rule.Enabled
+			form.CardID = rule.CardID
+			form.Prompt = rule.Prompt
+			form.MinThreshold = rule.MinThreshold
+
+			// the reward behind the rule's card may be gone since
+			if !slices.ContainsFunc(cards, func(card db.MemoryCard) bool { return card.ID == rule.CardID }) {
+				form.Cards = append(slices.Clone(cards), db.MemoryCard{ID: rule.CardID, Name: rule.CardName})
+			}
+		}
+
+		page.Rules = append(page.Rules, form)
+	}
+
+	return getHtml("events.html", page)
+}
+
+// parseEventRule reads and validates an event rule form; the card is checked
+// for access separately.
+func parseEventRule(r *http.Request, userID uuid.UUID) (*db.EventRule, error) {
+	eventType := chi.URLParam(r, "event_type")
+	if _, ok := eventKinds[eventType]; !ok {
+		return nil, fmt.Errorf("unknown event %q", eventType)
+	}
+
+	cardID, err := uuid.Parse(strings.TrimSpace(r.FormValue("card_id")))
+	if err != nil {
+		return nil, fmt.Errorf("pick a character")
+	}
+
+	prompt := strings.TrimSpace(r.FormValue("prompt"))
+	if prompt == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+	if utf8.RuneCountInString(prompt) > maxEventPromptLen {
+		return nil, fmt.Errorf("prompt is longer than %d characters", maxEventPromptLen)
+	}
+
+	minThreshold := 0
+	if value := strings.TrimSpace(r.FormValue("min_threshold")); value != "" {
+		minThreshold, err = strconv.Atoi(value)
+		if err != nil || minThreshold < 0 {
+			return nil, fmt.Errorf("min threshold must be a non-negative number")
+		}
+	}
+
+	return &db.EventRule{
+		UserID:       userID,
+		EventType:    eventType,
+		CardID:       cardID,
+		Prompt:       prompt,
+		MinThreshold: minThreshold,
+		Enabled:      r.FormValue("enabled") == "on",
+	}, nil
+}
+
+func (api *API) updateEventRule(w http.ResponseWriter, r *http.Request) {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusUnauthorized,
+			ErrorMessage: "not authorized",
+		})
+		return
+	}
+
+	rule, err := parseEventRule(r, user.ID)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if _, err := api.db.GetCharCardByID(r.Context(), user.ID, rule.CardID); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "character not found",
+		})
+		return
+	}
+
+	if err := api.db.UpsertEventRule(r.Context(), rule); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Add("hx-redirect", "/events")
+	_, _ = w.Write([]byte("success"))
+}
+
+func (api *API) deleteEventRule(w http.ResponseWriter, r *http.Request) {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusUnauthorized,
+			ErrorMessage: "not authorized",
+		})
+		return
+	}
+
+	if err := api.db.DeleteEventRule(r.Context(), user.ID, chi.URLParam(r, "event_type")); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Add("hx-redirect", "/events")
+	_, _ = w.Write([]byte("success"))
+}
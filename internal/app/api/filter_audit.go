@@ -0,0 +1,228 @@
+package api
+
+import (
+	"app/db"
+	"app/pkg/llmfilter"
+	"app/pkg/textfilter"
+	"app/pkg/ws"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const filterFlagNoteMaxLen = 500
+
+// filterFlagForm is the panel's "wrong filter" editor.
+type filterFlagForm struct {
+	Target db.FilterRunKind  `json:"target"`
+	Kind   db.FilterFlagKind `json:"kind"`
+	Text   string            `json:"text"`
+	Note   string            `json:"note"`
+}
+
+type filterFlagged struct {
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+// flagFilterRun stores a mod's verdict on the latest filter run of the
+// message. The flagged text has to be part of what the filter judged, or a
+// replay could not check it.
+func (api *API) flagFilterRun(ctx context.Context, mod, target *db.User, msgIDStr string, form *filterFlagForm) error {
+	if form == nil {
+		return errors.New("empty flag")
+	}
+
+	msgID, err := uuid.Parse(msgIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid msg id: %w", err)
+	}
+	if form.Target != db.FilterRunRequest && form.Target != db.FilterRunReply {
+		return fmt.Errorf("unknown target %q", form.Target)
+	}
+	if !form.Kind.Valid() {
+		return fmt.Errorf("unknown flag %q", form.Kind)
+	}
+
+	text := strings.TrimSpace(form.Text)
+	if text == "" {
+		return errors.New("say which words the filter got wrong")
+	}
+	note := strings.TrimSpace(form.Note)
+	if utf8.RuneCountInString(note) > filterFlagNoteMaxLen {
+		return fmt.Errorf("note is longer than %d characters", filterFlagNoteMaxLen)
+	}
+
+	run, err := api.db.GetLatestFilterRun(ctx, target.ID, msgID, form.Target)
+	if err != nil {
+		if db.ErrCode(err) == db.ErrCodeNoRows {
+			return fmt.Errorf("the %s of this message was never filtered", form.Target)
+		}
+		return err
+	}
+	if !strings.Contains(run.Target, text) {
+		return fmt.Errorf("%q is not in the %s", text, form.Target)
+	}
+
+	return api.db.AddFilterFlag(ctx, &db.FilterFlag{
+		RunID:     run.ID,
+		Kind:      form.Kind,
+		Text:      text,
+		Note:      note,
+		FlaggedBy: mod.TwitchLogin,
+	})
+}
+
+// handleFilterFlag answers the panel's "flag_filter" action; errors ride in
+// the payload so the editor can show them.
+func (api *API) handleFilterFlag(ctx context.Context, wsClient *ws.Client, mod, target *db.User, msgID string, form *filterFlagForm) error {
+	flagged := &filterFlagged{ID: msgID}
+	if err := api.flagFilterRun(ctx, mod, target, msgID, form); err != nil {
+		flagged.Error = err.Error()
+	}
+
+	return api.sendAction(wsClient, ActionFilterFlagged, flagged)
+}
+
+func runeSlice(s string, span textfilter.Span) string {
+	r := []rune(s)
+	if span.Start < 0 || span.End > len(r) || span.Start >= span.End {
+		return ""
+	}
+	return string(r[span.Start:span.End])
+}
+
+func clipRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+// filterCorpusCase turns a flagged run into a replayable case for the LLM
+// filter. Only the LLM passes count against the mask budget; regex spans are
+// not the model's doing.
+func filterCorpusCase(flagged *db.FlaggedFilterRun) *llmfilter.Case {
+	run := flagged.Run
+
+	c := &llmfilter.Case{
+		RequestID: "filter-" + run.ID.String(),
+		Context:   run.Context,
+		Target:    run.Target,
+		Custom:    run.Custom,
+	}
+
+	var llmSpans []textfilter.Span
+	var censored []string
+	for _, span := range run.Spans {
+		text := runeSlice(run.Target, textfilter.Span{Start: span.Start, End: span.End})
+		censored = append(censored, fmt.Sprintf("%s %q", span.Pass, text))
+		if span.Pass != db.FilterPassRegex {
+			llmSpans = append(llmSpans, textfilter.Span{Start: span.Start, End: span.End})
+		}
+	}
+
+	llmSpans = textfilter.Merge(llmSpans)
+	budget := 0
+	for _, span := range llmSpans {
+		budget += span.End - span.Start
+	}
+
+	kinds := map[db.FilterFlagKind]bool{}
+	var body []string
+	for _, flag := range flagged.Flags {
+		kinds[flag.Kind] = true
+
+		line := fmt.Sprintf("%s flagged %q as ", flag.FlaggedBy, flag.Text)
+		switch flag.Kind {
+		case db.FilterFlagFalsePositive:
+			c.Clean = append(c.Clean, flag.Text)
+			// a false positive of the regex pass never took up budget
+			budget -= llmOverlap(run.Target, flag.Text, llmSpans)
+			line += "censored but fine"
+		case db.FilterFlagMissed:
+			c.Flagged = append(c.Flagged, flag.Text)
+			budget += utf8.RuneCountInString(flag.Text)
+			line += "missed"
+		}
+		if flag.Note != "" {
+			line += ": " + flag.Note
+		}
+		body = append(body, line+".")
+	}
+	c.MaxMasked = max(budget, 0)
+
+	if len(censored) > 0 {
+		body = append(body, "Censored by: "+strings.Join(censored, ", ")+".")
+	}
+	if run.LLMSkipped {
+		body = append(body, "The LLM filter was off for this run.")
+	}
+	c.Body = strings.Join(body, "\n")
+
+	var what []string
+	if kinds[db.FilterFlagFalsePositive] {
+		what = append(what, "false positive")
+	}
+	if kinds[db.FilterFlagMissed] {
+		what = append(what, "missed span")
+	}
+	c.Title = fmt.Sprintf("%s in %s: %s", strings.Join(what, " and "), run.Kind, clipRunes(run.Target, 60))
+
+	return c
+}
+
+// llmOverlap counts the runes of text that spans mask in target. Flags only
+// keep the text, so the occurrence the spans cover most is taken.
+func llmOverlap(target, text string, spans []textfilter.Span) int {
+	if text == "" {
+		return 0
+	}
+
+	n := utf8.RuneCountInString(text)
+	most := 0
+	for i := 0; ; {
+		j := strings.Index(target[i:], text)
+		if j < 0 {
+			break
+		}
+		i += j
+		start := utf8.RuneCountInString(target[:i])
+		overlap := 0
+		for _, span := range spans {
+			overlap += max(0, min(span.End, start+n)-max(span.Start, start))
+		}
+		most = max(most, overlap)
+		_, size := utf8.DecodeRuneInString(target[i:])
+		i += size
+	}
+	return most
+}
+
+// filterCorpus downloads every flagged filter run as a JSONL regression
+// corpus for llmfilter's integration tests.
+func (api *API) filterCorpus(w http.ResponseWriter, r *http.Request) {
+	runs, err := api.db.GetFlaggedFilterRuns(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("failed to get flagged filter runs: " + err.Error()))
+		return
+	}
+
+	cases := make([]*llmfilter.Case, 0, len(runs))
+	for _, run := range runs {
+		cases = append(cases, filterCorpusCase(run))
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="filter_corpus.jsonl"`)
+	if err := llmfilter.WriteCorpus(w, cases); err != nil {
+		api.logger.Error("failed to write filter corpus", "err", err)
+	}
+}
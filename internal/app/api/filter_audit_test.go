@@ -0,0 +1,67 @@
+package api
+
+import (
+	"slices"
+	"testing"
+
+	"app/db"
+
+	"github.com/google/uuid"
+)
+
+func TestFilterCorpusCase(t *testing.T) {
+	flagged := &db.FlaggedFilterRun{
+		Run: &db.FilterRun{
+			ID:     uuid.New(),
+			Kind:   db.FilterRunReply,
+			Target: "you absolute donkey, go eat a clown",
+			Spans: []db.FilterRunSpan{
+				{Start: 4, End: 12, Pass: db.FilterPassBuiltin},
+				{Start: 13, End: 19, Pass: db.FilterPassRegex},
+			},
+		},
+		Flags: []*db.FilterFlag{
+			{Kind: db.FilterFlagFalsePositive, Text: "absolute", FlaggedBy: "mod1"},
+			{Kind: db.FilterFlagMissed, Text: "clown", FlaggedBy: "mod2", Note: "slur in chat"},
+		},
+	}
+
+	c := filterCorpusCase(flagged)
+
+	if !slices.Equal(c.Clean, []string{"absolute"}) {
+		t.Errorf("unexpected clean: %q", c.Clean)
+	}
+	if !slices.Equal(c.Flagged, []string{"clown"}) {
+		t.Errorf("unexpected flagged: %q", c.Flagged)
+	}
+	// the builtin span of 8 runes, minus the false positive, plus the miss;
+	// the regex span does not count
+	if c.MaxMasked != 5 {
+		t.Errorf("expected a budget of 5, got %d", c.MaxMasked)
+	}
+	if c.Target != flagged.Run.Target || c.RequestID != "filter-"+flagged.Run.ID.String() {
+		t.Errorf("unexpected case: %+v", c)
+	}
+}
+
+func TestFilterCorpusCaseRegexFalsePositive(t *testing.T) {
+	flagged := &db.FlaggedFilterRun{
+		Run: &db.FilterRun{
+			ID:     uuid.New(),
+			Kind:   db.FilterRunReply,
+			Target: "you absolute donkey, go eat a clown",
+			Spans: []db.FilterRunSpan{
+				{Start: 4, End: 12, Pass: db.FilterPassBuiltin},
+				{Start: 13, End: 19, Pass: db.FilterPassRegex},
+			},
+		},
+		Flags: []*db.FilterFlag{
+			{Kind: db.FilterFlagFalsePositive, Text: "donkey", FlaggedBy: "mod1"},
+		},
+	}
+
+	// only the regex pass masked "donkey", so the builtin budget stays
+	if c := filterCorpusCase(flagged); c.MaxMasked != 8 {
+		t.Errorf("expected a budget of 8, got %d", c.MaxMasked)
+	}
+}
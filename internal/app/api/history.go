@@ -0,0 +1,233 @@
+package api
+
+import (
+	"app/db"
+	"app/pkg/ctxstore"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const historyPageSize = 50
+
+type historyRewardType struct {
+	Value string
+	Label string
+}
+
+// historyRewardTypes are the reward type filter's options; "chat" is chat TTS,
+// which has no reward.
+var historyRewardTypes = []historyRewardType{
+	{"", "All types"},
+	{strconv.Itoa(int(db.TwitchRewardTTS)), db.TwitchRewardTTS.String()},
+	{strconv.Itoa(int(db.TwitchRewardAI)), db.TwitchRewardAI.String()},
+	{strconv.Itoa(int(db.TwitchRewardUniversalTTS)), db.TwitchRewardUniversalTTS.String()},
+	{strconv.Itoa(int(db.TwitchRewardAgentic)), db.TwitchRewardAgentic.String()},
+	{strconv.Itoa(int(db.TwitchRewardEvent)), db.TwitchRewardEvent.String()},
+	{"chat", "Chat TTS"},
+}
+
+type historyRow struct {
+	Time       string
+	Viewer     string
+	Type       string
+	CharName   string
+	Request    string
+	Response   string
+	Skipped    bool
+	SkipReason string
+
+	// ExportLink downloads the message as a clip; empty when no audio was
+	// kept for it.
+	ExportLink string
+}
+
+type historyPage struct {
+	TwitchLogin  string
+	TwitchUserID int
+
+	Viewer     string
+	Character  string
+	Query      string
+	RewardType string
+	From       string
+	To         string
+
+	RewardTypes []historyRewardType
+
+	Rows []*historyRow
+
+	Page     int
+	PrevLink string
+	NextLink string
+
+	RetentionDays int
+}
+
+// parseHistoryFilter reads the history page's query string. Dates are whole
+// UTC days, To included.
+func parseHistoryFilter(query url.Values) (*db.ArchiveFilter, error) {
+	filter := &db.ArchiveFilter{
+		Viewer:   strings.TrimSpace(query.Get("viewer")),
+		CharName: strings.TrimSpace(query.Get("character")),
+		Text:     strings.TrimSpace(query.Get("q")),
+	}
+
+	switch rewardType := query.Get("reward_type"); rewardType {
+	case "":
+	case "chat":
+		chat := db.ChatTTSRewardType
+		filter.RewardType = &chat
+	default:
+		n, err := strconv.Atoi(rewardType)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid reward type %q", rewardType)
+		}
+		t := db.TwitchRewardType(n)
+		filter.RewardType = &t
+	}
+
+	if from := query.Get("from"); from != "" {
+		t, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date %q", from)
+		}
+		filter.From = t
+	}
+	if to := query.Get("to"); to != "" {
+		t, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date %q", to)
+		}
+		filter.To = t.AddDate(0, 0, 1)
+	}
+
+	return filter, nil
+}
+
+func (api *API) history(r *http.Request) template.HTML {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusUnauthorized,
+			ErrorMessage: "unauthorized",
+		})
+	}
+
+	targetTwitchUserID, err := getTwitchUserID(r)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "failed to get twitch user id: " + err.Error(),
+		})
+	}
+
+	if hasPerm, err := api.hasControlPanelPermissions(user, targetTwitchUserID, r); err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "failed to check permission: " + err.Error(),
+		})
+	} else if !hasPerm {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: "you are not moderating this user",
+		})
+	}
+
+	targetUser, err := api.db.GetUserByTwitchUserID(r.Context(), targetTwitchUserID)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "failed to get target user: " + err.Error(),
+		})
+	}
+
+	query := r.URL.Query()
+
+	filter, err := parseHistoryFilter(query)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+	}
+
+	pageNum, err := strconv.Atoi(query.Get("page"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1
+	}
+
+	msgs, err := api.db.SearchArchive(r.Context(), targetUser.ID, filter, historyPageSize+1, (pageNum-1)*historyPageSize)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+	}
+
+	page := &historyPage{
+		TwitchLogin:  targetUser.TwitchLogin,
+		TwitchUserID: targetUser.TwitchUserID,
+
+		Viewer:     filter.Viewer,
+		Character:  filter.CharName,
+		Query:      filter.Text,
+		RewardType: query.Get("reward_type"),
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+
+		RewardTypes: historyRewardTypes,
+
+		Page: pageNum,
+
+		RetentionDays: int(api.db.ArchiveRetention() / (24 * time.Hour)),
+	}
+
+	pageLink := func(n int) string {
+		q := url.Values{}
+		for key, values := range query {
+			q[key] = values
+		}
+		q.Set("page", strconv.Itoa(n))
+		return fmt.Sprintf("/history/%d?%s", targetUser.TwitchUserID, q.Encode())
+	}
+	if pageNum > 1 {
+		page.PrevLink = pageLink(pageNum - 1)
+	}
+	if len(msgs) > historyPageSize {
+		msgs = msgs[:historyPageSize]
+		page.NextLink = pageLink(pageNum + 1)
+	}
+
+	for _, msg := range msgs {
+		row := &historyRow{
+			Time:     msg.CreatedAt.UTC().Format("2006-01-02 15:04"),
+			Viewer:   msg.TwitchLogin,
+			Type:     "Chat TTS",
+			CharName: msg.CharName,
+			Request:  msg.Request,
+			Response: msg.Response,
+			Skipped:  msg.Status == db.MsgStatusDeleted,
+		}
+		if msg.RewardType != nil {
+			row.Type = msg.RewardType.String()
+		}
+		if msg.HasTracks {
+			row.ExportLink = fmt.Sprintf("/history/%d/%s/export", targetUser.TwitchUserID, msg.ID)
+		}
+		if row.CharName == "" {
+			row.CharName = "-"
+		}
+		if msgData, err := db.ParseMessageData(msg.Data); err == nil {
+			row.SkipReason = msgData.SkipReason
+		}
+
+		page.Rows = append(page.Rows, row)
+	}
+
+	return getHtml("history.html", page)
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"app/db"
+	"app/pkg/ctxstore"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ClipExporter renders a finished message's kept audio as a highlight clip.
+type ClipExporter interface {
+	ExportMP3(ctx context.Context, msgID uuid.UUID) ([]byte, []byte, error)
+	ExportMP4(ctx context.Context, msgID uuid.UUID, cardID *uuid.UUID) ([]byte, error)
+}
+
+// clipFileName names a clip after the viewer and when they asked.
+func clipFileName(msg *db.ArchivedMsg, ext string) string {
+	login := msg.TwitchLogin
+	if login == "" {
+		login = "clip"
+	}
+	return fmt.Sprintf("%s_%s_%s.%s", login, msg.CreatedAt.UTC().Format("2006-01-02_15-04"), msg.ID.String()[:8], ext)
+}
+
+// historyExport downloads an archived message as an mp4 (format=mp4), its mp3
+// (format=mp3) or the mp3's karaoke subtitles (format=srt).
+func (api *API) historyExport(w http.ResponseWriter, r *http.Request) {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusUnauthorized,
+			ErrorMessage: "not authorized",
+		})
+		return
+	}
+
+	targetTwitchUserID, err := getTwitchUserID(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "failed to get twitch user id: " + err.Error(),
+		})
+		return
+	}
+
+	msgID, err := uuid.Parse(chi.URLParam(r, "msg_id"))
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "invalid msg id",
+		})
+		return
+	}
+
+	if hasPerm, err := api.hasControlPanelPermissions(user, targetTwitchUserID, r); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "failed to check permission: " + err.Error(),
+		})
+		return
+	} else if !hasPerm {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: "you are not moderating this user",
+		})
+		return
+	}
+
+	targetUser, err := api.db.GetUserByTwitchUserID(r.Context(), targetTwitchUserID)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "failed to get target user: " + err.Error(),
+		})
+		return
+	}
+
+	msg, err := api.db.GetArchivedMsg(r.Context(), targetUser.ID, msgID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if db.ErrCode(err) == db.ErrCodeNoRows {
+			code = http.StatusNotFound
+		}
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    code,
+			ErrorMessage: "failed to get message: " + err.Error(),
+		})
+		return
+	}
+	if !msg.HasTracks {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusNotFound,
+			ErrorMessage: "no audio was kept for this message",
+		})
+		return
+	}
+
+	var data []byte
+	var contentType, ext string
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "mp4":
+		data, err = api.clipExporter.ExportMP4(r.Context(), msg.ID, msg.CardID)
+		contentType, ext = "video/mp4", "mp4"
+	case "mp3":
+		data, _, err = api.clipExporter.ExportMP3(r.Context(), msg.ID)
+		contentType, ext = "audio/mpeg", "mp3"
+	case "srt":
+		_, data, err = api.clipExporter.ExportMP3(r.Context(), msg.ID)
+		contentType, ext = "application/x-subrip", "srt"
+	default:
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: fmt.Sprintf("unknown format %q", format),
+		})
+		return
+	}
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "failed to export clip: " + err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", clipFileName(msg, ext)))
+	_, _ = w.Write(data)
+}
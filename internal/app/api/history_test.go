@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"app/db"
+)
+
+func TestParseHistoryFilter(t *testing.T) {
+	t.Parallel()
+
+	filter, err := parseHistoryFilter(url.Values{
+		"viewer":      {" forsen "},
+		"reward_type": {"chat"},
+		"from":        {"2026-01-02"},
+		"to":          {"2026-01-02"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Viewer != "forsen" || filter.RewardType == nil || *filter.RewardType != db.ChatTTSRewardType {
+		t.Fatalf("got %+v", filter)
+	}
+	if !filter.From.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) || !filter.To.Equal(time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("to should include the whole day: got %v - %v", filter.From, filter.To)
+	}
+
+	filter, err = parseHistoryFilter(url.Values{"reward_type": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.RewardType == nil || *filter.RewardType != db.TwitchRewardAI || !filter.From.IsZero() {
+		t.Fatalf("got %+v", filter)
+	}
+
+	for _, query := range []url.Values{
+		{"reward_type": {"-1"}},
+		{"reward_type": {"ai"}},
+		{"from": {"yesterday"}},
+	} {
+		if _, err := parseHistoryFilter(query); err == nil {
+			t.Fatalf("%v accepted", query)
+		}
+	}
+}
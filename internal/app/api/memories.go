@@ -0,0 +1,733 @@
+package api
+
+import (
+	"app/db"
+	"app/pkg/ctxstore"
+	"app/pkg/imagetag"
+	"app/pkg/textfilter"
+	"app/pkg/ws"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Tokenizer counts tokens the way the character model will, so memory budgets
+// are stored exact at write time.
+type Tokenizer interface {
+	Tokenize(ctx context.Context, text string) (int, error)
+}
+
+type memoryTTL struct {
+	Value    string
+	Label    string
+	Duration time.Duration // 0 = permanent
+}
+
+const (
+	defaultMemoryTTL   = "24h"
+	permanentMemoryTTL = "permanent"
+)
+
+var memoryTTLs = []memoryTTL{
+	{Value: "24h", Label: "24 hours", Duration: 24 * time.Hour},
+	{Value: "168h", Label: "7 days", Duration: 7 * 24 * time.Hour},
+	{Value: "720h", Label: "30 days", Duration: 30 * 24 * time.Hour},
+	{Value: permanentMemoryTTL, Label: "Permanent"},
+}
+
+func parseMemoryTTL(value string, now time.Time) (*time.Time, error) {
+	for _, ttl := range memoryTTLs {
+		if ttl.Value != value {
+			continue
+		}
+		if ttl.Duration == 0 {
+			return nil, nil
+		}
+		expiresAt := now.Add(ttl.Duration)
+		return &expiresAt, nil
+	}
+
+	return nil, fmt.Errorf("unknown ttl %q", value)
+}
+
+// normalizeMemoryText keeps a memory to one line of at most
+// db.MaxMemoryTextLen characters: each memory renders as a single bullet.
+func normalizeMemoryText(text string) (string, error) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return "", errors.New("memory text is empty")
+	}
+	if n := utf8.RuneCountInString(text); n > db.MaxMemoryTextLen {
+		return "", fmt.Errorf("memory is %d characters long, the limit is %d", n, db.MaxMemoryTextLen)
+	}
+
+	return text, nil
+}
+
+// truncateRunes cuts s to at most n runes, marking the cut with an ellipsis.
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
+
+// memoryPrefillText drafts a memory from a processed exchange. It is only a
+// starting point for the mod to rewrite into a fact, so both sides get cut to
+// keep the draft under the length limit.
+func memoryPrefillText(requester, request, response string) string {
+	request = truncateRunes(strings.Join(strings.Fields(request), " "), 50)
+	response = truncateRunes(strings.Join(strings.Fields(response), " "), 80)
+
+	return fmt.Sprintf("%s asked you \"%s\" and you answered \"%s\"", requester, request, response)
+}
+
+// memoryForm is a memory as submitted by the memories page or the control
+// panel's "remember this" editor.
+type memoryForm struct {
+	Text                string `json:"text"`
+	CardID              string `json:"card_id"` // empty = all of the streamer's characters
+	Global              bool   `json:"global"`
+	SubjectTwitchUserID int    `json:"subject_twitch_user_id,omitempty"`
+	TTL                 string `json:"ttl"`
+	Pinned              bool   `json:"pinned"`
+}
+
+func (api *API) isAdmin(ctx context.Context, user *db.User) (bool, error) {
+	perms, err := api.db.GetUserPermissions(ctx, user.ID, db.PermissionStatusGranted)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user permissions: %w", err)
+	}
+
+	return slices.Contains(perms, db.PermissionAdmin), nil
+}
+
+// saveMemory validates and stores a new memory on the target channel. Global
+// memories render on every stream using the card, so only admins write them.
+func (api *API) saveMemory(ctx context.Context, author, target *db.User, isAdmin bool, form *memoryForm) (uuid.UUID, error) {
+	text, err := normalizeMemoryText(form.Text)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	mem := &db.CharMemory{
+		UserID:    &target.ID,
+		Text:      text,
+		Pinned:    form.Pinned,
+		CreatedBy: &author.ID,
+	}
+
+	if form.CardID != "" {
+		cardID, err := uuid.Parse(form.CardID)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("card_id is not a valid uuid: %w", err)
+		}
+		mem.CardID = &cardID
+	}
+
+	if form.Global {
+		if !isAdmin {
+			return uuid.Nil, errors.New("only admins can write global memories")
+		}
+		if mem.CardID == nil {
+			return uuid.Nil, errors.New("global memories need a character")
+		}
+		mem.UserID = nil
+	}
+
+	// the channel must be able to use the card: its own or a public one
+	if mem.CardID != nil {
+		if _, err := api.db.GetCharCardByID(ctx, target.ID, *mem.CardID); err != nil {
+			return uuid.Nil, errors.New("character not found")
+		}
+	}
+
+	if form.SubjectTwitchUserID != 0 {
+		mem.SubjectTwitchUserID = &form.SubjectTwitchUserID
+	}
+
+	if mem.ExpiresAt, err = parseMemoryTTL(form.TTL, time.Now()); err != nil {
+		return uuid.Nil, err
+	}
+
+	if mem.TokenCount, err = api.tokenizer.Tokenize(ctx, text); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to count memory tokens: %w", err)
+	}
+
+	return api.db.InsertCharMemory(ctx, mem)
+}
+
+// memoryTarget resolves the channel a memories request is about and checks
+// the caller may manage it.
+func (api *API) memoryTarget(r *http.Request) (*db.User, *db.User, bool, error) {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		return nil, nil, false, errors.New("unauthorized")
+	}
+
+	targetTwitchUserID, err := getTwitchUserID(r)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to get twitch user id: %w", err)
+	}
+
+	if hasPerm, err := api.hasControlPanelPermissions(user, targetTwitchUserID, r); err != nil {
+		return nil, nil, false, fmt.Errorf("failed to check permission: %w", err)
+	} else if !hasPerm {
+		return nil, nil, false, errors.New("you are not moderating this user")
+	}
+
+	targetUser, err := api.db.GetUserByTwitchUserID(r.Context(), targetTwitchUserID)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to get target user: %w", err)
+	}
+
+	isAdmin, err := api.isAdmin(r.Context(), user)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	return user, targetUser, isAdmin, nil
+}
+
+// memoryFromPath loads the {memory_id} memory and checks it belongs to the
+// target channel, or is global and the caller is an admin.
+func (api *API) memoryFromPath(r *http.Request, target *db.User, isAdmin bool) (*db.CharMemory, error) {
+	memoryID, err := uuid.Parse(chi.URLParam(r, "memory_id"))
+	if err != nil {
+		return nil, fmt.Errorf("memory_id is not a valid uuid: %w", err)
+	}
+
+	mem, err := api.db.GetCharMemory(r.Context(), memoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mem.UserID == nil {
+		if !isAdmin {
+			return nil, errors.New("only admins can change global memories")
+		}
+		return mem, nil
+	}
+
+	if *mem.UserID != target.ID {
+		return nil, errors.New("memory belongs to another channel")
+	}
+
+	return mem, nil
+}
+
+type memoryRow struct {
+	TwitchUserID int
+	TTLs         []memoryTTL
+	MaxTextLen   int
+
+	ID         uuid.UUID
+	Text       string
+	Scope      string
+	TokenCount int
+	Pinned     bool
+	Expiry     string
+	Active     bool
+	Editable   bool
+	Suggested  bool
+}
+
+type memorySlots struct {
+	CardName string
+	Used     int
+}
+
+type memoriesPage struct {
+	TwitchLogin  string
+	TwitchUserID int
+
+	IsAdmin        bool
+	GlobalDisabled bool
+
+	Cards      []db.MemoryCard
+	TTLs       []memoryTTL
+	DefaultTTL string
+	MaxTextLen int
+	MaxSlots   int
+
+	Slots          []memorySlots
+	Suggested      []*memoryRow
+	Memories       []*memoryRow
+	GlobalMemories []*memoryRow
+}
+
+func memoryActive(mem *db.CharMemory, now time.Time) bool {
+	return !mem.Suggested && (mem.ExpiresAt == nil || mem.ExpiresAt.After(now))
+}
+
+func memoryExpiry(mem *db.CharMemory, now time.Time) string {
+	switch {
+	case mem.ExpiresAt == nil:
+		return "permanent"
+	case !mem.ExpiresAt.After(now):
+		return "expired"
+	}
+
+	left := mem.ExpiresAt.Sub(now)
+	switch {
+	case left >= 48*time.Hour:
+		return fmt.Sprintf("%dd left", int(left/(24*time.Hour)))
+	case left >= time.Hour:
+		return fmt.Sprintf("%dh left", int(left/time.Hour))
+	default:
+		return fmt.Sprintf("%dm left", int(left/time.Minute))
+	}
+}
+
+func (api *API) memories(r *http.Request) template.HTML {
+	_, target, isAdmin, err := api.memoryTarget(r)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+	}
+
+	settings, err := api.db.GetUserSettings(r.Context(), target.ID)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "failed to get user settings: " + err.Error(),
+		})
+	}
+
+	cards, err := api.db.GetMemoryCards(r.Context(), target.ID)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+	}
+
+	mems, err := api.db.GetStreamerMemories(r.Context(), target.ID)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+	}
+
+	cardNames := make(map[uuid.UUID]string, len(cards))
+	for _, card := range cards {
+		cardNames[card.ID] = card.Name
+	}
+
+	now := time.Now()
+	page := &memoriesPage{
+		TwitchLogin:    target.TwitchLogin,
+		TwitchUserID:   target.TwitchUserID,
+		IsAdmin:        isAdmin,
+		GlobalDisabled: settings.DisableGlobalMemories,
+		Cards:          cards,
+		TTLs:           memoryTTLs,
+		DefaultTTL:     defaultMemoryTTL,
+		MaxTextLen:     db.MaxMemoryTextLen,
+		MaxSlots:       db.MaxInjectedMemories,
+	}
+
+	toRow := func(mem *db.CharMemory, scope string, editable bool) *memoryRow {
+		return &memoryRow{
+			TwitchUserID: target.TwitchUserID,
+			TTLs:         memoryTTLs,
+			MaxTextLen:   db.MaxMemoryTextLen,
+
+			ID:         mem.ID,
+			Text:       mem.Text,
+			Scope:      scope,
+			TokenCount: mem.TokenCount,
+			Pinned:     mem.Pinned,
+			Expiry:     memoryExpiry(mem, now),
+			Active:     memoryActive(mem, now),
+			Editable:   editable,
+			Suggested:  mem.Suggested,
+		}
+	}
+
+	sharedActive := 0
+	perCardActive := make(map[uuid.UUID]int, len(cards))
+	for _, mem := range mems {
+		scope := "All characters"
+		if mem.CardID != nil {
+			scope = cardNames[*mem.CardID]
+		}
+
+		if mem.Suggested {
+			page.Suggested = append(page.Suggested, toRow(mem, scope, true))
+			continue
+		}
+		page.Memories = append(page.Memories, toRow(mem, scope, true))
+
+		if !memoryActive(mem, now) {
+			continue
+		}
+		if mem.CardID == nil {
+			sharedActive++
+		} else {
+			perCardActive[*mem.CardID]++
+		}
+	}
+
+	for _, card := range cards {
+		globalMems, err := api.db.GetGlobalMemories(r.Context(), card.ID)
+		if err != nil {
+			return getHtml("error.html", &htmlErr{
+				ErrorCode:    http.StatusInternalServerError,
+				ErrorMessage: err.Error(),
+			})
+		}
+
+		used := sharedActive + perCardActive[card.ID]
+		for _, mem := range globalMems {
+			page.GlobalMemories = append(page.GlobalMemories, toRow(mem, card.Name, isAdmin))
+			if !settings.DisableGlobalMemories && memoryActive(mem, now) {
+				used++
+			}
+		}
+
+		page.Slots = append(page.Slots, memorySlots{CardName: card.Name, Used: used})
+	}
+
+	return getHtml("memories.html", page)
+}
+
+func (api *API) createMemory(w http.ResponseWriter, r *http.Request) {
+	user, target, isAdmin, err := api.memoryTarget(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "failed to parse form",
+		})
+		return
+	}
+
+	form := &memoryForm{
+		Text:   r.Form.Get("text"),
+		CardID: strings.TrimSpace(r.Form.Get("card_id")),
+		Global: r.Form.Get("global") == "on",
+		TTL:    r.Form.Get("ttl"),
+		Pinned: r.Form.Get("pinned") == "on",
+	}
+
+	if _, err := api.saveMemory(r.Context(), user, target, isAdmin, form); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Add("hx-redirect", "/memories/"+strconv.Itoa(target.TwitchUserID))
+	_, _ = w.Write([]byte("success"))
+}
+
+func (api *API) updateMemory(w http.ResponseWriter, r *http.Request) {
+	_, target, isAdmin, err := api.memoryTarget(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	mem, err := api.memoryFromPath(r, target, isAdmin)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "failed to parse form",
+		})
+		return
+	}
+
+	text, err := normalizeMemoryText(r.Form.Get("text"))
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if text != mem.Text {
+		tokens, err := api.tokenizer.Tokenize(r.Context(), text)
+		if err != nil {
+			_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+				ErrorCode:    http.StatusInternalServerError,
+				ErrorMessage: "failed to count memory tokens: " + err.Error(),
+			})
+			return
+		}
+		mem.Text, mem.TokenCount = text, tokens
+	}
+
+	// an empty ttl keeps the current expiry
+	if ttl := r.Form.Get("ttl"); ttl != "" {
+		if mem.ExpiresAt, err = parseMemoryTTL(ttl, time.Now()); err != nil {
+			_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: err.Error(),
+			})
+			return
+		}
+	}
+
+	mem.Pinned = r.Form.Get("pinned") == "on"
+
+	if err := api.db.UpdateCharMemory(r.Context(), mem); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Add("hx-redirect", "/memories/"+strconv.Itoa(target.TwitchUserID))
+	_, _ = w.Write([]byte("success"))
+}
+
+func (api *API) deleteMemory(w http.ResponseWriter, r *http.Request) {
+	_, target, isAdmin, err := api.memoryTarget(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	mem, err := api.memoryFromPath(r, target, isAdmin)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.DeleteCharMemory(r.Context(), mem.ID); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Add("hx-redirect", "/memories/"+strconv.Itoa(target.TwitchUserID))
+	_, _ = w.Write([]byte("success"))
+}
+
+// approveMemory makes a suggestion from the offline job live. The ttl starts
+// counting at approval, not at the (possibly old) interaction.
+func (api *API) approveMemory(w http.ResponseWriter, r *http.Request) {
+	_, target, isAdmin, err := api.memoryTarget(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	mem, err := api.memoryFromPath(r, target, isAdmin)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if !mem.Suggested {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "memory is already approved",
+		})
+		return
+	}
+
+	ttl := r.FormValue("ttl")
+	if ttl == "" {
+		ttl = defaultMemoryTTL
+	}
+
+	expiresAt, err := parseMemoryTTL(ttl, time.Now())
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.ApproveCharMemory(r.Context(), mem.ID, expiresAt); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Add("hx-redirect", "/memories/"+strconv.Itoa(target.TwitchUserID))
+	_, _ = w.Write([]byte("success"))
+}
+
+func (api *API) wipeSessionMemories(w http.ResponseWriter, r *http.Request) {
+	_, target, _, err := api.memoryTarget(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if _, err := api.db.DeleteSessionMemories(r.Context(), target.ID); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Add("hx-redirect", "/memories/"+strconv.Itoa(target.TwitchUserID))
+	_, _ = w.Write([]byte("success"))
+}
+
+// memoryPrefill is the editable draft the control panel opens for "remember
+// this". The request and response are censored the same way the overlay read
+// them, so a filtered word can't slip into a memory via the draft.
+type memoryPrefill struct {
+	ID string `json:"id"`
+
+	CardID   string `json:"card_id,omitempty"`
+	CardName string `json:"card_name,omitempty"`
+
+	SubjectTwitchUserID int    `json:"subject_twitch_user_id,omitempty"`
+	SubjectTwitchLogin  string `json:"subject_twitch_login"`
+
+	Text string `json:"text"`
+	TTL  string `json:"ttl"`
+
+	Error string `json:"error,omitempty"`
+}
+
+type memorySaved struct {
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+func (api *API) memoryPrefill(ctx context.Context, target *db.User, msgIDStr string) (*memoryPrefill, error) {
+	msgID, err := uuid.Parse(msgIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("message id is not a valid uuid: %w", err)
+	}
+
+	msg, err := api.db.GetMessageByID(ctx, msgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg.UserID != target.ID {
+		return nil, errors.New("message belongs to another channel")
+	}
+
+	msgData, err := db.ParseMessageData(msg.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message data: %w", err)
+	}
+
+	prefill := &memoryPrefill{
+		ID:                  msgIDStr,
+		SubjectTwitchUserID: msg.TwitchMessage.TwitchUserID,
+		SubjectTwitchLogin:  msg.TwitchMessage.TwitchLogin,
+		TTL:                 defaultMemoryTTL,
+	}
+
+	requester := msg.TwitchMessage.TwitchLogin
+	if msg.TwitchMessage.TwitchUserID != 0 {
+		optOut, err := api.db.IsChatUserMemoryOptOut(ctx, msg.TwitchMessage.TwitchUserID)
+		if err != nil {
+			return nil, err
+		}
+		// the draft neither tags nor names a viewer who did ^^optout
+		if optOut {
+			prefill.SubjectTwitchUserID = 0
+			prefill.SubjectTwitchLogin = ""
+			requester = "a viewer"
+		}
+	}
+
+	if len(msg.TwitchMessage.RewardID) != 0 {
+		if card, rewardType, err := api.db.GetCharCardByTwitchRewardNoPerms(ctx, msg.TwitchMessage.RewardID); err == nil && rewardType == db.TwitchRewardAI {
+			prefill.CardID = card.ID.String()
+			prefill.CardName = card.Name
+		}
+	}
+
+	request := imagetag.ReplaceImageTags(textfilter.Censor(msg.TwitchMessage.Message, msgData.RequestFiltered, "(filtered)"))
+	response := textfilter.Censor(msgData.AIResponse, msgData.FilteredText, "(filtered)")
+	if response == "" {
+		prefill.Text = truncateRunes(fmt.Sprintf("%s said \"%s\"", requester, strings.Join(strings.Fields(request), " ")), db.MaxMemoryTextLen)
+	} else {
+		prefill.Text = memoryPrefillText(requester, request, response)
+	}
+
+	return prefill, nil
+}
+
+// handleRemember answers the panel's "remember" action with a draft; errors
+// ride in the payload so the panel can show them next to the row.
+func (api *API) handleRemember(ctx context.Context, wsClient *ws.Client, target *db.User, msgID string) error {
+	prefill, err := api.memoryPrefill(ctx, target, msgID)
+	if err != nil {
+		prefill = &memoryPrefill{ID: msgID, Error: err.Error()}
+	}
+
+	return api.sendAction(wsClient, ActionMemoryPrefill, prefill)
+}
+
+func (api *API) handleRememberSave(ctx context.Context, wsClient *ws.Client, user, target *db.User, msgID string, form *memoryForm) error {
+	saved := &memorySaved{ID: msgID}
+
+	if form == nil {
+		saved.Error = "empty memory"
+	} else if isAdmin, err := api.isAdmin(ctx, user); err != nil {
+		saved.Error = err.Error()
+	} else if _, err := api.saveMemory(ctx, user, target, isAdmin, form); err != nil {
+		saved.Error = err.Error()
+	}
+
+	return api.sendAction(wsClient, ActionMemorySaved, saved)
+}
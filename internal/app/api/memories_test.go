@@ -0,0 +1,144 @@
+package api
+
+import (
+	"app/db"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+func TestMemoryPrefillTextFitsLimit(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("very long message ", 40)
+	text := memoryPrefillText("viewer", long, long)
+
+	if _, err := normalizeMemoryText(text); err != nil {
+		t.Fatalf("prefill does not pass validation: %v", err)
+	}
+	if !strings.HasPrefix(text, "viewer asked you \"very long") {
+		t.Fatalf("unexpected prefill %q", text)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	t.Parallel()
+
+	if got := truncateRunes("short", 10); got != "short" {
+		t.Fatalf("got %q, want unchanged", got)
+	}
+	if got := truncateRunes("привет мир", 5); got != "прив…" {
+		t.Fatalf("got %q", got)
+	}
+	if got := truncateRunes(strings.Repeat("x", 100), 50); utf8.RuneCountInString(got) != 50 {
+		t.Fatalf("got %d runes, want 50", utf8.RuneCountInString(got))
+	}
+}
+
+func TestNormalizeMemoryText(t *testing.T) {
+	t.Parallel()
+
+	if got, err := normalizeMemoryText("  two\nlines  "); err != nil || got != "two lines" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if _, err := normalizeMemoryText(" \n "); err == nil {
+		t.Fatal("empty memory accepted")
+	}
+	if _, err := normalizeMemoryText(strings.Repeat("a", db.MaxMemoryTextLen+1)); err == nil {
+		t.Fatal("too long memory accepted")
+	}
+}
+
+func TestParseMemoryTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	expiresAt, err := parseMemoryTTL(defaultMemoryTTL, now)
+	if err != nil || expiresAt == nil || !expiresAt.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("got %v, %v", expiresAt, err)
+	}
+	if expiresAt, err := parseMemoryTTL(permanentMemoryTTL, now); err != nil || expiresAt != nil {
+		t.Fatalf("permanent: got %v, %v", expiresAt, err)
+	}
+	if _, err := parseMemoryTTL("1y", now); err == nil {
+		t.Fatal("unknown ttl accepted")
+	}
+}
+
+func TestSaveMemoryGlobalRequiresAdmin(t *testing.T) {
+	t.Parallel()
+
+	user := &db.User{ID: uuid.New()}
+	form := &memoryForm{Text: "lore", CardID: uuid.NewString(), Global: true, TTL: permanentMemoryTTL}
+
+	if _, err := (&API{}).saveMemory(t.Context(), user, user, false, form); err == nil || !strings.Contains(err.Error(), "admins") {
+		t.Fatalf("got %v, want admin error", err)
+	}
+}
+
+func TestMemoryExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	at := func(d time.Duration) *db.CharMemory {
+		t := now.Add(d)
+		return &db.CharMemory{ExpiresAt: &t}
+	}
+
+	for _, tc := range []struct {
+		mem  *db.CharMemory
+		want string
+	}{
+		{&db.CharMemory{}, "permanent"},
+		{at(-time.Minute), "expired"},
+		{at(3*time.Hour + time.Minute), "3h left"},
+		{at(7*24*time.Hour + time.Minute), "7d left"},
+	} {
+		if got := memoryExpiry(tc.mem, now); got != tc.want {
+			t.Fatalf("got %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestMemoriesTemplate(t *testing.T) {
+	t.Parallel()
+
+	body := getString("memories.html", &memoriesPage{
+		TwitchLogin:  "streamer",
+		TwitchUserID: 42,
+		TTLs:         memoryTTLs,
+		DefaultTTL:   defaultMemoryTTL,
+		MaxTextLen:   db.MaxMemoryTextLen,
+		MaxSlots:     db.MaxInjectedMemories,
+		Slots:        []memorySlots{{CardName: "forsen", Used: 3}},
+		Suggested: []*memoryRow{{
+			TwitchUserID: 42,
+			TTLs:         memoryTTLs,
+			ID:           uuid.New(),
+			Text:         "viewer42 told you he never won at chess",
+			Editable:     true,
+			Suggested:    true,
+		}},
+		Memories: []*memoryRow{{
+			TwitchUserID: 42,
+			TTLs:         memoryTTLs,
+			MaxTextLen:   db.MaxMemoryTextLen,
+			ID:           uuid.New(),
+			Text:         "chat likes cheese",
+			Scope:        "All characters",
+			Expiry:       "permanent",
+			Active:       true,
+			Editable:     true,
+		}},
+	})
+
+	for _, want := range []string{"3/20", "chat likes cheese", "/memories/42/wipe_session", "Suggested (1)", "/approve"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("page does not contain %q:\n%s", want, body)
+		}
+	}
+}
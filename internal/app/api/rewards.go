@@ -3,9 +3,11 @@ package api
 import (
 	"app/db"
 	"app/pkg/ctxstore"
+	"app/pkg/twitch"
 	"context"
 	"fmt"
 	"html/template"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -62,6 +64,80 @@ type rewardChooseData struct {
 
 	Error            string
 	ExistingRewardID string
+
+	// Settings prefill the create form; nil shows the defaults.
+	Settings *db.TwitchRewardData
+	Existing []existingReward
+}
+
+// existingReward is an edit form for a reward that is already mapped.
+type existingReward struct {
+	Label          string
+	RewardType     string // form value, character rewards only
+	TwitchRewardID string
+	PostURL        string
+	Settings       *db.TwitchRewardData
+}
+
+// existingRewards lists the user's mapped rewards of the given types for a
+// card, or the card-less ones when cardID is nil.
+func (api *API) existingRewards(ctx context.Context, userID uuid.UUID, cardID *uuid.UUID, postURL string, rewardTypes ...db.TwitchRewardType) ([]existingReward, error) {
+	var existing []existingReward
+	for _, rewardType := range rewardTypes {
+		reward, err := api.db.GetTwitchReward(ctx, userID, cardID, rewardType)
+		if err != nil {
+			if db.ErrCode(err) == db.ErrCodeNoRows {
+				continue
+			}
+			return nil, err
+		}
+
+		existing = append(existing, existingReward{
+			Label:          rewardType.String(),
+			RewardType:     strings.ToLower(rewardType.String()),
+			TwitchRewardID: reward.TwitchRewardID,
+			PostURL:        postURL,
+			Settings:       reward.Data,
+		})
+	}
+
+	return existing, nil
+}
+
+const maxRewardCooldownSeconds = 7 * 24 * 60 * 60 // Twitch's upper bound
+
+// parseRewardSettings reads the reward settings form. Empty fields are zero:
+// the default cost, or a disabled limit.
+func parseRewardSettings(r *http.Request) (*db.TwitchRewardData, error) {
+	var data db.TwitchRewardData
+	fields := []struct {
+		name     string
+		dst      *int
+		min, max int
+	}{
+		{"cost", &data.Cost, 1, math.MaxInt32},
+		{"global_cooldown_seconds", &data.GlobalCooldownSeconds, 0, maxRewardCooldownSeconds},
+		{"max_per_stream", &data.MaxPerStream, 0, math.MaxInt32},
+		{"max_per_user_per_stream", &data.MaxPerUserPerStream, 0, math.MaxInt32},
+	}
+
+	for _, field := range fields {
+		value := strings.TrimSpace(r.FormValue(field.name))
+		if value == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a number", field.name)
+		}
+		if n < field.min || n > field.max {
+			return nil, fmt.Errorf("%s must be between %d and %d", field.name, field.min, field.max)
+		}
+		*field.dst = n
+	}
+
+	return data.WithDefaults(), nil
 }
 
 func parseRewardTypeStr(s string) (db.TwitchRewardType, string, string, error) {
@@ -109,11 +185,20 @@ func (api *API) rewardChoose(r *http.Request) template.HTML {
 		})
 	}
 
+	existing, err := api.existingRewards(r.Context(), user.ID, &characterID, "/characters/"+characterID.String()+"/reward_edit", db.TwitchRewardTTS, db.TwitchRewardAI)
+	if err != nil {
+		return getHtml("error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "get rewards: " + err.Error(),
+		})
+	}
+
 	return getHtml("reward_choose.html", &rewardChooseData{
 		CharacterID:     characterID,
 		CharacterName:   char.Name,
 		RewardType:      rtStr,
 		RewardTypeLabel: rtLabel,
+		Existing:        existing,
 	})
 }
 
@@ -176,13 +261,26 @@ func (api *API) rewardNew(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := api.createRewardAndUpsert(r.Context(), user, &characterID, char.Name, rewardType, ""); err != nil {
+	settings, err := parseRewardSettings(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "reward_choose.html", &rewardChooseData{
+			CharacterID:     characterID,
+			CharacterName:   char.Name,
+			RewardType:      rtStr,
+			RewardTypeLabel: rtLabel,
+			Error:           err.Error(),
+		})
+		return
+	}
+
+	if err := api.createRewardAndUpsert(r.Context(), user, &characterID, char.Name, rewardType, "", settings); err != nil {
 		_ = html.ExecuteTemplate(w, "reward_choose.html", &rewardChooseData{
 			CharacterID:     characterID,
 			CharacterName:   char.Name,
 			RewardType:      rtStr,
 			RewardTypeLabel: rtLabel,
 			Error:           err.Error(),
+			Settings:        settings,
 		})
 		return
 	}
@@ -269,7 +367,7 @@ func (api *API) rewardExisting(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := api.db.UpsertTwitchReward(r.Context(), user.ID, &characterID, existingID, rewardType); err != nil {
+	if err := api.db.UpsertTwitchReward(r.Context(), user.ID, &characterID, existingID, rewardType, nil); err != nil {
 		_ = html.ExecuteTemplate(w, "reward_choose.html", &rewardChooseData{
 			CharacterID:      characterID,
 			CharacterName:    char.Name,
@@ -287,7 +385,7 @@ func (api *API) rewardExisting(w http.ResponseWriter, r *http.Request) {
 
 // createReward is a generic function to create Twitch rewards for both characters and universal rewards
 func (api *API) createReward(ctx context.Context, w http.ResponseWriter, user *db.User, cardID *uuid.UUID, titlePrefix string, rewardType db.TwitchRewardType, prompt string) error {
-	if err := api.createRewardAndUpsert(ctx, user, cardID, titlePrefix, rewardType, prompt); err != nil {
+	if err := api.createRewardAndUpsert(ctx, user, cardID, titlePrefix, rewardType, prompt, nil); err != nil {
 		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
 			ErrorCode:    http.StatusInternalServerError,
 			ErrorMessage: err.Error(),
@@ -299,7 +397,9 @@ func (api *API) createReward(ctx context.Context, w http.ResponseWriter, user *d
 	return nil
 }
 
-func (api *API) createRewardAndUpsert(ctx context.Context, user *db.User, cardID *uuid.UUID, titlePrefix string, rewardType db.TwitchRewardType, prompt string) error {
+// createRewardAndUpsert creates the reward on Twitch and maps it; nil
+// settings are the defaults.
+func (api *API) createRewardAndUpsert(ctx context.Context, user *db.User, cardID *uuid.UUID, titlePrefix string, rewardType db.TwitchRewardType, prompt string, settings *db.TwitchRewardData) error {
 	client, err := api.twitchClient.NewHelixClient(user.TwitchAccessToken, user.TwitchRefreshToken)
 	if err != nil {
 		return fmt.Errorf("create helix client: %w", err)
@@ -313,16 +413,20 @@ func (api *API) createRewardAndUpsert(ctx context.Context, user *db.User, cardID
 		titlePrefix = titlePrefix + " "
 	}
 
-	resp, err := client.CreateCustomReward(&helix.ChannelCustomRewardsParams{
+	settings = settings.WithDefaults()
+
+	params := &helix.ChannelCustomRewardsParams{
 		BroadcasterID:                     strconv.Itoa(user.TwitchUserID),
 		Title:                             titlePrefix + rewardType.String(),
-		Cost:                              10,
 		Prompt:                            prompt,
 		IsEnabled:                         true,
 		BackgroundColor:                   "#A970FF",
 		IsUserInputRequired:               true,
 		ShouldRedemptionsSkipRequestQueue: false,
-	})
+	}
+	twitch.ApplyRewardSettings(params, settings)
+
+	resp, err := client.CreateCustomReward(params)
 	if err != nil {
 		return fmt.Errorf("helix - create custom reward: %w", err)
 	}
@@ -335,10 +439,90 @@ func (api *API) createRewardAndUpsert(ctx context.Context, user *db.User, cardID
 
 	switch rewardType {
 	case db.TwitchRewardUniversalTTS:
-		return api.db.UpsertUniversalTTSReward(ctx, user.ID, rewardID)
+		return api.db.UpsertUniversalTTSReward(ctx, user.ID, rewardID, settings)
 	case db.TwitchRewardAgentic:
-		return api.db.UpsertAgenticReward(ctx, user.ID, rewardID)
+		return api.db.UpsertAgenticReward(ctx, user.ID, rewardID, settings)
 	default:
-		return api.db.UpsertTwitchReward(ctx, user.ID, cardID, rewardID, rewardType)
+		return api.db.UpsertTwitchReward(ctx, user.ID, cardID, rewardID, rewardType, settings)
 	}
 }
+
+// editReward pushes new settings onto the user's mapped reward; the
+// twitch_reward_id mapping itself is left alone.
+func (api *API) editReward(w http.ResponseWriter, r *http.Request, cardID *uuid.UUID, rewardType db.TwitchRewardType) {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusUnauthorized,
+			ErrorMessage: "not authorized",
+		})
+		return
+	}
+
+	settings, err := parseRewardSettings(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	reward, err := api.db.GetTwitchReward(r.Context(), user.ID, cardID, rewardType)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "get reward: " + err.Error(),
+		})
+		return
+	}
+
+	if err := api.twitchClient.UpdateRewardSettings(user, reward.TwitchRewardID, settings); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.UpdateTwitchRewardData(r.Context(), reward.ID, settings); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Add("hx-redirect", "/characters")
+	_, _ = w.Write([]byte("success"))
+}
+
+func (api *API) rewardEdit(w http.ResponseWriter, r *http.Request) {
+	characterID, err := uuid.Parse(chi.URLParam(r, "character_id"))
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "{character_id} is not valid uuid: " + err.Error(),
+		})
+		return
+	}
+
+	rewardType, _, _, err := parseRewardTypeStr(r.FormValue("reward_type"))
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	api.editReward(w, r, &characterID, rewardType)
+}
+
+func (api *API) universalTTSRewardEdit(w http.ResponseWriter, r *http.Request) {
+	api.editReward(w, r, nil, db.TwitchRewardUniversalTTS)
+}
+
+func (api *API) agenticRewardEdit(w http.ResponseWriter, r *http.Request) {
+	api.editReward(w, r, nil, db.TwitchRewardAgentic)
+}
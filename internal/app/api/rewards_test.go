@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"app/db"
+)
+
+func TestParseRewardSettings(t *testing.T) {
+	t.Parallel()
+
+	parse := func(form url.Values) (*db.TwitchRewardData, error) {
+		req := httptest.NewRequest("POST", "/characters/x/reward_new", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return parseRewardSettings(req)
+	}
+
+	data, err := parse(url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Cost != db.DefaultRewardCost || data.GlobalCooldownSeconds != 0 || data.MaxPerStream != 0 {
+		t.Fatalf("empty form: got %+v, want defaults", data)
+	}
+
+	data, err = parse(url.Values{
+		"cost":                    {"500"},
+		"global_cooldown_seconds": {"30"},
+		"max_per_stream":          {"5"},
+		"max_per_user_per_stream": {" 2 "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := db.TwitchRewardData{Cost: 500, GlobalCooldownSeconds: 30, MaxPerStream: 5, MaxPerUserPerStream: 2}
+	if *data != want {
+		t.Fatalf("got %+v, want %+v", data, want)
+	}
+
+	for _, form := range []url.Values{
+		{"cost": {"abc"}},
+		{"cost": {"0"}},
+		{"cost": {"-1"}},
+		{"global_cooldown_seconds": {"604801"}},
+		{"max_per_stream": {"-5"}},
+	} {
+		if _, err := parse(form); err == nil {
+			t.Fatalf("%v accepted", form)
+		}
+	}
+}
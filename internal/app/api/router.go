@@ -59,12 +59,18 @@ type API struct {
 
 	imageCache   *ImageCache
 	voiceSamples *VoiceSampleCache
+
+	clipExporter ClipExporter
+
+	tokenizer Tokenizer
+
+	ttsEngines TTSEngineLister
 }
 
 func NewAPI(cfg *Config, ingestHost string, ingestPort int, logger *slog.Logger, connManager *conns.Manager,
 	twitchClient *twitch.Client, db *db.DB, s3 *s3client.Client,
 	ttsHandler processor.InteractionHandler, aiHandler processor.InteractionHandler, universalHandler processor.InteractionHandler, agenticHandler processor.InteractionHandler,
-	voiceSampler VoiceSampler) *API {
+	voiceSampler VoiceSampler, clipExporter ClipExporter, tokenizer Tokenizer, ttsEngines TTSEngineLister) *API {
 	api := &API{
 		cfg: cfg,
 
@@ -85,6 +91,12 @@ func NewAPI(cfg *Config, ingestHost string, ingestPort int, logger *slog.Logger,
 
 		imageCache:   NewImageCache(db),
 		voiceSamples: NewVoiceSampleCache(voiceSampler, db),
+
+		clipExporter: clipExporter,
+
+		tokenizer: tokenizer,
+
+		ttsEngines: ttsEngines,
 	}
 
 	if ingestPort > 0 {
@@ -127,6 +139,13 @@ func (api *API) NewRouter() *chi.Mux {
 
 	router.Handle("/metrics", promhttp.Handler())
 
+	// token-authenticated control API, no session cookie involved
+	router.Post("/api/v1/{twitch_login}/skip-current", api.controlAPI(api.apiSkipCurrent))
+	router.Post("/api/v1/{twitch_login}/show-images-current", api.controlAPI(api.apiShowImagesCurrent))
+	router.Post("/api/v1/{twitch_login}/clean", api.controlAPI(api.apiClean))
+	router.Post("/api/v1/{twitch_login}/pause", api.controlAPI(api.apiPause))
+	router.Post("/api/v1/{twitch_login}/resume", api.controlAPI(api.apiResume))
+
 	router.Group(func(router chi.Router) {
 		router.Use(api.AuthMiddleware)
 
@@ -160,6 +179,9 @@ func (api *API) NewRouter() *chi.Mux {
 		router.Get("/control", api.nav(api.controlPanelMenu))
 		router.Get("/control/ws/{twitch_user_id}", api.controlPanelWSConn)
 		router.Get("/control/{twitch_user_id}", api.nav(api.controlPanel))
+		router.Get("/history/{twitch_user_id}", api.nav(api.history))
+		router.Get("/history/{twitch_user_id}/{msg_id}/export", http.HandlerFunc(api.historyExport))
+		router.Post("/control/{twitch_user_id}/unban/{viewer_id}", http.HandlerFunc(api.unbanViewer))
 
 		router.Group(func(router chi.Router) {
 			router.Use(api.checkPermissions(db.PermissionStreamer))
@@ -174,14 +196,19 @@ func (api *API) NewRouter() *chi.Mux {
 			router.Get("/characters", api.nav(api.characters))
 
 			router.Get("/characters/{character_id}", api.nav(api.character))
+			router.Post("/characters/import", api.importCharacter)
 			router.Post("/characters/{character_id}", api.upsertCharacter)
+			router.Get("/characters/{character_id}/export", api.exportCharacter)
 
 			router.Get("/characters/{character_id}/try", api.nav(api.tryCharacter))
 			router.Get("/ws/characters/{character_id}/try", api.tryCharacterWS)
+			router.Post("/characters/{character_id}/voice_preview", api.voicePreview)
+			router.Post("/characters/{character_id}/voice_tuning", api.saveVoiceTuning)
 
 			router.Get("/characters/{character_id}/reward", api.nav(api.rewardChoose))
 			router.Post("/characters/{character_id}/reward_new", http.HandlerFunc(api.rewardNew))
 			router.Post("/characters/{character_id}/reward_existing", http.HandlerFunc(api.rewardExisting))
+			router.Post("/characters/{character_id}/reward_edit", http.HandlerFunc(api.rewardEdit))
 
 			router.Get("/universal-tts/try", api.nav(api.tryUniversalTTS))
 			router.Get("/ws/universal-tts/try", api.tryUniversalTTSWS)
@@ -197,17 +224,30 @@ func (api *API) NewRouter() *chi.Mux {
 			router.Post("/universal-tts/reward", http.HandlerFunc(api.universalTTSReward))
 			router.Get("/universal-tts/reward", api.nav(api.universalTTSRewardChoose))
 			router.Post("/universal-tts/reward_existing", http.HandlerFunc(api.universalTTSRewardExisting))
+			router.Post("/universal-tts/reward_edit", http.HandlerFunc(api.universalTTSRewardEdit))
 
 			router.Post("/agentic/reward", http.HandlerFunc(api.agenticReward))
 			router.Get("/agentic/reward", api.nav(api.agenticRewardChoose))
 			router.Post("/agentic/reward_existing", http.HandlerFunc(api.agenticRewardExisting))
+			router.Post("/agentic/reward_edit", http.HandlerFunc(api.agenticRewardEdit))
 
 			router.Post("/control/grant", http.HandlerFunc(api.controlPanelGrant))
 			router.Post("/control/revoke", http.HandlerFunc(api.controlPanelRevoke))
 
 			router.Get("/filters", api.nav(api.filters))
 			router.Post("/filters", api.updateFilters)
+
+			router.Get("/memories/{twitch_user_id}", api.nav(api.memories))
+			router.Post("/memories/{twitch_user_id}", http.HandlerFunc(api.createMemory))
+			router.Post("/memories/{twitch_user_id}/wipe_session", http.HandlerFunc(api.wipeSessionMemories))
+			router.Post("/memories/{twitch_user_id}/{memory_id}", http.HandlerFunc(api.updateMemory))
+			router.Post("/memories/{twitch_user_id}/{memory_id}/delete", http.HandlerFunc(api.deleteMemory))
+			router.Post("/memories/{twitch_user_id}/{memory_id}/approve", http.HandlerFunc(api.approveMemory))
 			router.Post("/token/regenerate", http.HandlerFunc(api.regenerateToken))
+
+			router.Get("/events", api.nav(api.events))
+			router.Post("/events/{event_type}", http.HandlerFunc(api.updateEventRule))
+			router.Post("/events/{event_type}/delete", http.HandlerFunc(api.deleteEventRule))
 		})
 
 		router.Group(func(router chi.Router) {
@@ -226,6 +266,8 @@ func (api *API) NewRouter() *chi.Mux {
 
 			router.Post("/admin/reload_overlays", http.HandlerFunc(api.reloadAllOverlays))
 
+			router.Get("/admin/filter_corpus", http.HandlerFunc(api.filterCorpus))
+
 			router.Post("/characters/{character_id}/admin/update_short_char_name", http.HandlerFunc(api.updateShortCharName))
 		})
 
@@ -94,6 +94,42 @@
         input.focus();
     }
 
+    // Voice A/B: synthesizes the line in the message box (or a stock one)
+    // with the saved voice or the unsaved settings of the tuning form.
+    async function playVoicePreview(variant) {
+        const form = document.getElementById("voice_tuning_form");
+        const audio = document.getElementById("voice_preview_audio");
+        const result = document.getElementById("voice_tuning_result");
+        if (!form || !audio) return;
+
+        const body = new URLSearchParams(new FormData(form));
+        body.set("variant", variant);
+        const input = document.getElementById("message_input");
+        if (input) body.set("text", input.value.trim());
+
+        for (const id of ["voice_preview_a", "voice_preview_b"]) {
+            document.getElementById(id).disabled = true;
+        }
+        if (result) result.textContent = "Generating " + variant.toUpperCase() + "…";
+        try {
+            const resp = await fetch("/characters/" + characterID + "/voice_preview", { method: "POST", body: body });
+            if (!resp.ok) {
+                if (result) result.textContent = await resp.text();
+                return;
+            }
+            if (audio.src) URL.revokeObjectURL(audio.src);
+            audio.src = URL.createObjectURL(await resp.blob());
+            if (result) result.textContent = "Playing " + variant.toUpperCase();
+            audio.play();
+        } catch (e) {
+            if (result) result.textContent = "Preview failed: " + e;
+        } finally {
+            for (const id of ["voice_preview_a", "voice_preview_b"]) {
+                document.getElementById(id).disabled = false;
+            }
+        }
+    }
+
     function handleEnvelope(msg) {
         const dataStr = new TextDecoder('utf-8').decode(base64ToArrayBuffer(msg.data));
 
@@ -229,6 +265,11 @@
         const stopBtn = document.getElementById("stop_button");
         if (stopBtn) stopBtn.addEventListener('click', stopCurrentAction);
 
+        for (const [id, variant] of [["voice_preview_a", "a"], ["voice_preview_b", "b"]]) {
+            const btn = document.getElementById(id);
+            if (btn) btn.addEventListener('click', () => playVoicePreview(variant));
+        }
+
         const input = document.getElementById("message_input");
         if (input) {
             input.addEventListener('input', () => {
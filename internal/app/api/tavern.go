@@ -0,0 +1,221 @@
+package api
+
+import (
+	"app/pkg/agentic"
+	"app/pkg/ctxstore"
+	"app/pkg/tavern"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// cardFileName makes a download name from the card's name.
+func cardFileName(name, ext string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+	if strings.Trim(name, "_") == "" {
+		name = "character"
+	}
+	return name + "." + ext
+}
+
+// importCharacter creates a private character from a Tavern V1, V2 or V3
+// card, JSON or PNG. A PNG card is its own image; a JSON card needs an
+// uploaded image unless it embeds one. Cards carry no voice, so a voice
+// reference is required as for any new character.
+func (api *API) importCharacter(w http.ResponseWriter, r *http.Request) {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusUnauthorized,
+			ErrorMessage: "not authorized",
+		})
+		return
+	}
+
+	if err := r.ParseMultipartForm(20 * 1024 * 1024); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "r.ParseMultipartForm(): " + err.Error(),
+		})
+		return
+	}
+
+	file, _, err := r.FormFile("card")
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "No Card Provided",
+		})
+		return
+	}
+	defer file.Close()
+
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "io.ReadAll(): " + err.Error(),
+		})
+		return
+	}
+
+	tavernCard, err := tavern.Parse(raw)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	card := tavernCard.ToCard()
+	if alias := agentic.NormalizeAlias(tavernCard.Data.Nickname); alias != "" && alias != agentic.NormalizeAlias(card.Name) && utf8.RuneCountInString(alias) <= maxCardAliasRunes {
+		card.Data.Aliases = []string{alias}
+	}
+
+	image := tavernCard.Image()
+	if http.DetectContentType(raw) == "image/png" {
+		image = raw
+	}
+	if _, ok := r.MultipartForm.File["image"]; ok {
+		image, err = api.extractImage(r)
+		if err != nil {
+			_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+				ErrorCode:    http.StatusInternalServerError,
+				ErrorMessage: "extractImage: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	if len(image) == 0 {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "No Image Provided: upload one along with a JSON card",
+		})
+		return
+	}
+
+	voiceRef, err := api.extractVoiceRef(r)
+	if err != nil || len(voiceRef) == 0 {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "No Voice Reference Provided",
+		})
+		return
+	}
+
+	card.OwnerUserID = user.ID
+	card.Data.Image = image
+	card.Data.VoiceReference = voiceRef
+
+	cardID, err := api.db.InsertCharCard(r.Context(), card)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "InsertCharCard: " + err.Error(),
+		})
+		return
+	}
+
+	w.Header().Add("hx-redirect", "/characters/"+cardID.String())
+	_, _ = w.Write([]byte("Success"))
+}
+
+// exportCharacter downloads an owned or public character as a Tavern card:
+// a PNG of its image carrying both the V2 and V3 card (format=png, the
+// default), or V2 or V3 JSON (format=v2, format=v3), V3 with the image
+// embedded. The voice stays behind: the formats have no place for it.
+func (api *API) exportCharacter(w http.ResponseWriter, r *http.Request) {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusUnauthorized,
+			ErrorMessage: "not authorized",
+		})
+		return
+	}
+
+	characterID, err := uuid.Parse(chi.URLParam(r, "character_id"))
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "character_id is not a valid uuid",
+		})
+		return
+	}
+
+	card, err := api.db.GetCharCardByID(r.Context(), user.ID, characterID)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusNotFound,
+			ErrorMessage: "character not found",
+		})
+		return
+	}
+
+	cached, err := api.imageCache.Get(r.Context(), characterID)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "GetCharImage: " + err.Error(),
+		})
+		return
+	}
+	image := cached.Data
+
+	var (
+		out         []byte
+		contentType string
+		ext         string
+	)
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "png":
+		if len(image) == 0 {
+			image, err = staticFS.ReadFile("static/doctorWTF.png")
+		}
+		if err == nil {
+			image, err = tavern.ToPNG(image)
+		}
+		if err == nil {
+			out, err = tavern.EmbedPNG(image, tavern.FromCard(card, tavern.SpecV2, nil), tavern.FromCard(card, tavern.SpecV3, nil))
+		}
+		contentType, ext = "image/png", "png"
+	case "v2", "v3":
+		spec := tavern.SpecV2
+		if format == "v3" {
+			spec = tavern.SpecV3
+		}
+		out, err = json.MarshalIndent(tavern.FromCard(card, spec, image), "", "  ")
+		contentType, ext = "application/json", "json"
+	default:
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: fmt.Sprintf("unknown format %q, want png, v2 or v3", format),
+		})
+		return
+	}
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "failed to export character: " + err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", cardFileName(card.Name, ext)))
+	_, _ = w.Write(out)
+}
@@ -0,0 +1,16 @@
+package api
+
+import "testing"
+
+func TestCardFileName(t *testing.T) {
+	for name, want := range map[string]string{
+		"Dr. Les Carter": "Dr__Les_Carter.png",
+		"forsen":         "forsen.png",
+		" ../ ":          "character.png",
+		"Ωmega":          "Ωmega.png",
+	} {
+		if got := cardFileName(name, "png"); got != want {
+			t.Errorf("cardFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
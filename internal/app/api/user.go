@@ -3,23 +3,112 @@ package api
 import (
 	"app/db"
 	"app/pkg/ctxstore"
+	"app/pkg/textfilter"
+	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type filters struct {
 	Filters                   string
+	FilterWords               string
+	InvalidFilters            []string
 	CustomFilterPrompt        string
 	TtsLimit                  int
 	MaxSfxCount               int
 	SfxTotalLimit             int
+	AgenticMaxTurns           int
+	MaxAgenticMaxTurns        int
+	AgenticAudioLimit         int
 	Token                     string
 	IngestAllMessages         bool
 	DisableAudioNormalization bool
 	DisableLLMFilter          bool
 	DisableRegexFilter        bool
+	DisableGlobalMemories     bool
+
+	RedeemLimits                []redeemLimitField
+	MaxRedeemLimitWindowMinutes int
+}
+
+type redeemLimitField struct {
+	RewardType    db.TwitchRewardType
+	Label         string
+	Count         int
+	WindowMinutes int
+}
+
+// limitedRewardTypes are the reward types a per-viewer redeem limit can be
+// set for, in the order the settings page lists them.
+var limitedRewardTypes = []db.TwitchRewardType{
+	db.TwitchRewardTTS,
+	db.TwitchRewardAI,
+	db.TwitchRewardUniversalTTS,
+	db.TwitchRewardAgentic,
+}
+
+const defaultRedeemLimitWindowMinutes = 60
+
+func redeemLimitFields(limits map[db.TwitchRewardType]db.RedeemLimit) []redeemLimitField {
+	fields := make([]redeemLimitField, 0, len(limitedRewardTypes))
+	for _, rewardType := range limitedRewardTypes {
+		field := redeemLimitField{
+			RewardType:    rewardType,
+			Label:         rewardType.String(),
+			WindowMinutes: defaultRedeemLimitWindowMinutes,
+		}
+		if limit, ok := limits[rewardType]; ok && limit.Enabled() {
+			field.Count = limit.Count
+			field.WindowMinutes = limit.WindowSeconds / 60
+		}
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// parseRedeemLimits reads the per-viewer redeem limits off the settings form.
+// Reward types left at a zero count are unlimited and not stored.
+func parseRedeemLimits(form url.Values) (map[db.TwitchRewardType]db.RedeemLimit, error) {
+	maxMinutes := int(db.MaxRedeemLimitWindow / time.Minute)
+
+	limits := make(map[db.TwitchRewardType]db.RedeemLimit)
+	for _, rewardType := range limitedRewardTypes {
+		countStr := strings.TrimSpace(form.Get(fmt.Sprintf("redeem_limit_count_%d", rewardType)))
+		if countStr == "" {
+			continue
+		}
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid %s redeem limit %q", rewardType, countStr)
+		}
+		if count == 0 {
+			continue
+		}
+
+		minutes := defaultRedeemLimitWindowMinutes
+		if windowStr := strings.TrimSpace(form.Get(fmt.Sprintf("redeem_limit_window_%d", rewardType))); windowStr != "" {
+			minutes, err = strconv.Atoi(windowStr)
+			if err != nil || minutes < 1 || minutes > maxMinutes {
+				return nil, fmt.Errorf("%s redeem limit window must be 1 to %d minutes", rewardType, maxMinutes)
+			}
+		}
+
+		limits[rewardType] = db.RedeemLimit{
+			Count:         count,
+			WindowSeconds: minutes * 60,
+		}
+	}
+
+	if len(limits) == 0 {
+		return nil, nil
+	}
+
+	return limits, nil
 }
 
 func (api *API) filters(r *http.Request) template.HTML {
@@ -54,17 +143,36 @@ func (api *API) filters(r *http.Request) template.HTML {
 		sfxTotalLimit = *settings.SfxTotalLimit
 	}
 
+	agenticMaxTurns := db.DefaultAgenticMaxTurns
+	if settings.AgenticMaxTurns != nil && *settings.AgenticMaxTurns > 0 {
+		agenticMaxTurns = *settings.AgenticMaxTurns
+	}
+
+	agenticAudioLimit := db.DefaultAgenticAudioLimit
+	if settings.AgenticAudioLimit != nil {
+		agenticAudioLimit = *settings.AgenticAudioLimit
+	}
+
 	return getHtml("filters.html", &filters{
 		Filters:                   settings.Filters,
+		FilterWords:               settings.FilterWords,
+		InvalidFilters:            invalidFilters(settings.Filters),
 		CustomFilterPrompt:        settings.CustomFilterPrompt,
 		TtsLimit:                  ttsLimit,
 		MaxSfxCount:               maxSfxCount,
 		SfxTotalLimit:             sfxTotalLimit,
+		AgenticMaxTurns:           agenticMaxTurns,
+		MaxAgenticMaxTurns:        db.MaxAgenticMaxTurns,
+		AgenticAudioLimit:         agenticAudioLimit,
 		Token:                     settings.Token,
 		IngestAllMessages:         settings.IngestAllMessages,
 		DisableAudioNormalization: settings.DisableAudioNormalization,
 		DisableLLMFilter:          settings.DisableLLMFilter,
 		DisableRegexFilter:        settings.DisableRegexFilter,
+		DisableGlobalMemories:     settings.DisableGlobalMemories,
+
+		RedeemLimits:                redeemLimitFields(settings.RedeemLimits),
+		MaxRedeemLimitWindowMinutes: int(db.MaxRedeemLimitWindow / time.Minute),
 	})
 }
 
@@ -114,11 +222,18 @@ func (api *API) updateFilters(w http.ResponseWriter, r *http.Request) {
 	}
 
 	settings.Filters = normalizeFilters(r.Form.Get("filters"))
+	if invalid := invalidFilters(settings.Filters); len(invalid) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(template.HTMLEscapeString("invalid filter patterns: " + strings.Join(invalid, "; "))))
+		return
+	}
+	settings.FilterWords = normalizeFilters(r.Form.Get("filter_words"))
 	settings.CustomFilterPrompt = strings.TrimSpace(r.Form.Get("custom_filter_prompt"))
 	settings.IngestAllMessages = r.Form.Get("ingest_all_messages") == "on"
 	settings.DisableAudioNormalization = r.Form.Get("disable_audio_normalization") == "on"
 	settings.DisableLLMFilter = r.Form.Get("disable_llm_filter") == "on"
 	settings.DisableRegexFilter = r.Form.Get("disable_regex_filter") == "on"
+	settings.DisableGlobalMemories = r.Form.Get("disable_global_memories") == "on"
 
 	ttsLimitStr := r.Form.Get("tts_limit")
 	if ttsLimitStr != "" {
@@ -153,6 +268,36 @@ func (api *API) updateFilters(w http.ResponseWriter, r *http.Request) {
 		settings.SfxTotalLimit = &sfxTotalLimit
 	}
 
+	agenticMaxTurnsStr := r.Form.Get("agentic_max_turns")
+	if agenticMaxTurnsStr != "" {
+		agenticMaxTurns, err := strconv.Atoi(agenticMaxTurnsStr)
+		if err != nil || agenticMaxTurns < 1 || agenticMaxTurns > db.MaxAgenticMaxTurns {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(fmt.Sprintf("agentic_max_turns must be a number from 1 to %d", db.MaxAgenticMaxTurns)))
+			return
+		}
+		settings.AgenticMaxTurns = &agenticMaxTurns
+	}
+
+	agenticAudioLimitStr := r.Form.Get("agentic_audio_limit")
+	if agenticAudioLimitStr != "" {
+		agenticAudioLimit, err := strconv.Atoi(agenticAudioLimitStr)
+		if err != nil || agenticAudioLimit < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("invalid agentic_audio_limit value: must be 0 or more seconds"))
+			return
+		}
+		settings.AgenticAudioLimit = &agenticAudioLimit
+	}
+
+	redeemLimits, err := parseRedeemLimits(r.Form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	settings.RedeemLimits = redeemLimits
+
 	err = api.db.UpdateUserData(r.Context(), user.ID, settings)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -165,6 +310,19 @@ func (api *API) updateFilters(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("success"))
 }
 
+// invalidFilters lists the comma-separated patterns that fail to compile,
+// with the reason.
+func invalidFilters(filters string) []string {
+	_, errs := textfilter.Compile(strings.Split(filters, ","), nil)
+
+	invalid := make([]string, 0, len(errs))
+	for _, err := range errs {
+		invalid = append(invalid, err.Error())
+	}
+
+	return invalid
+}
+
 func normalizeFilters(raw string) string {
 	parts := strings.Split(raw, ",")
 	clean := parts[:0]
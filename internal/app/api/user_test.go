@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+
+	"app/db"
+)
+
+func TestParseRedeemLimits(t *testing.T) {
+	t.Parallel()
+
+	limits, err := parseRedeemLimits(url.Values{
+		"redeem_limit_count_0":  {"0"},
+		"redeem_limit_window_0": {"10"},
+		"redeem_limit_count_1":  {"3"},
+		"redeem_limit_window_1": {"30"},
+		"redeem_limit_count_2":  {" 2 "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[db.TwitchRewardType]db.RedeemLimit{
+		db.TwitchRewardAI:           {Count: 3, WindowSeconds: 30 * 60},
+		db.TwitchRewardUniversalTTS: {Count: 2, WindowSeconds: defaultRedeemLimitWindowMinutes * 60},
+	}
+	if len(limits) != len(want) {
+		t.Fatalf("got %v, want %v", limits, want)
+	}
+	for rewardType, limit := range want {
+		if limits[rewardType] != limit {
+			t.Fatalf("%s: got %+v, want %+v", rewardType, limits[rewardType], limit)
+		}
+	}
+
+	if limits, err := parseRedeemLimits(url.Values{}); err != nil || limits != nil {
+		t.Fatalf("empty form: got %v, %v", limits, err)
+	}
+
+	for _, form := range []url.Values{
+		{"redeem_limit_count_1": {"-1"}},
+		{"redeem_limit_count_1": {"x"}},
+		{"redeem_limit_count_1": {"1"}, "redeem_limit_window_1": {"0"}},
+		{"redeem_limit_count_1": {"1"}, "redeem_limit_window_1": {"10081"}},
+	} {
+		if _, err := parseRedeemLimits(form); err == nil {
+			t.Fatalf("%v accepted", form)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"app/db"
+	"app/pkg/ctxstore"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const viewerTimeout = time.Hour
+
+// banViewer bans the viewer behind a queued message from the channel's AI,
+// or times them out when timeout is set. Their messages still in the queue
+// are skipped by the processor when they come up.
+func (api *API) banViewer(ctx context.Context, mod *db.User, userID uuid.UUID, msgID string, timeout time.Duration) error {
+	id, err := uuid.Parse(msgID)
+	if err != nil {
+		return fmt.Errorf("invalid msg id: %w", err)
+	}
+
+	msg, err := api.db.GetMessageByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if msg.UserID != userID {
+		return fmt.Errorf("message belongs to another user")
+	}
+	if msg.TwitchMessage.TwitchUserID == 0 {
+		return fmt.Errorf("message has no twitch user id")
+	}
+
+	var until *time.Time
+	if timeout > 0 {
+		t := time.Now().Add(timeout)
+		until = &t
+	}
+
+	return api.db.BanViewer(ctx, userID, msg.TwitchMessage.TwitchUserID, msg.TwitchMessage.TwitchLogin, until, mod.TwitchLogin)
+}
+
+func (api *API) unbanViewer(w http.ResponseWriter, r *http.Request) {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusUnauthorized,
+			ErrorMessage: "not authorized",
+		})
+		return
+	}
+
+	targetTwitchUserID, err := getTwitchUserID(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "failed to get twitch user id: " + err.Error(),
+		})
+		return
+	}
+
+	viewerID, err := strconv.Atoi(chi.URLParam(r, "viewer_id"))
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: "viewer id is not int",
+		})
+		return
+	}
+
+	if hasPerm, err := api.hasControlPanelPermissions(user, targetTwitchUserID, r); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "failed to check permission: " + err.Error(),
+		})
+		return
+	} else if !hasPerm {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: "you are not moderating this user",
+		})
+		return
+	}
+
+	targetUser, err := api.db.GetUserByTwitchUserID(r.Context(), targetTwitchUserID)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "failed to get target user: " + err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.UnbanViewer(r.Context(), targetUser.ID, viewerID); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	w.Header().Add("hx-redirect", fmt.Sprintf("/control/%d", targetTwitchUserID))
+	_, _ = w.Write([]byte("success"))
+}
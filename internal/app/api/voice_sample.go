@@ -26,7 +26,7 @@ const filterSampleVoice = "les"
 
 // VoiceSampler synthesizes speech and applies TTS filters to audio.
 type VoiceSampler interface {
-	TTSWithTimings(ctx context.Context, msg string, refAudio []byte) ([]byte, []whisperx.Timiing, error)
+	TTSWithTimings(ctx context.Context, msg string, card *db.CardData) ([]byte, []whisperx.Timiing, error)
 	ChatTTSWithTimings(ctx context.Context, msg string, refAudio []byte) ([]byte, []whisperx.Timiing, error)
 	ApplyFilters(ctx context.Context, audio []byte, filters ...string) ([]byte, error)
 }
@@ -108,7 +108,7 @@ func (c *VoiceSampleCache) Get(ctx context.Context, voice string) ([]byte, error
 			return nil, fmt.Errorf("voice '%s' has no voice reference", voice)
 		}
 
-		audio, _, err := c.sampler.TTSWithTimings(ctx, voiceSampleText, card.VoiceReference)
+		audio, _, err := c.sampler.TTSWithTimings(ctx, voiceSampleText, card)
 		if err != nil {
 			return nil, fmt.Errorf("failed to synthesize sample: %w", err)
 		}
@@ -130,7 +130,7 @@ func (c *VoiceSampleCache) GetEmotion(ctx context.Context, voice, emotion string
 			return nil, fmt.Errorf("voice '%s' has no voice reference", voice)
 		}
 
-		audio, _, err := c.sampler.TTSWithTimings(ctx, ai.InsertEmotions(voiceSampleText, []string{emotion}), card.VoiceReference)
+		audio, _, err := c.sampler.TTSWithTimings(ctx, ai.InsertEmotions(voiceSampleText, []string{emotion}), card)
 		if err != nil {
 			return nil, fmt.Errorf("failed to synthesize emotion sample: %w", err)
 		}
@@ -160,6 +160,33 @@ func (c *VoiceSampleCache) GetOld(ctx context.Context, voice string) ([]byte, er
 	})
 }
 
+// Preview synthesizes text as the card would speak it, bypassing the cache:
+// the card may carry voice tuning that was never saved.
+func (c *VoiceSampleCache) Preview(ctx context.Context, card *db.CardData, text string) ([]byte, error) {
+	if len(card.VoiceReference) == 0 {
+		return nil, fmt.Errorf("character has no voice reference")
+	}
+
+	audio, _, err := c.sampler.TTSWithTimings(ctx, text, card)
+	if err != nil {
+		return nil, fmt.Errorf("failed to synthesize preview: %w", err)
+	}
+
+	return audio, nil
+}
+
+// Invalidate drops the samples of a voice whose tuning changed.
+func (c *VoiceSampleCache) Invalidate(voice string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.samples {
+		if key == "voice:"+voice || key == "old:"+voice || strings.HasPrefix(key, "emotion:"+voice+":") {
+			delete(c.samples, key)
+		}
+	}
+}
+
 // GetFiltered applies a TTS filter to the cached voice sample. Only the base
 // TTS synthesis is cached — the ffmpeg filter pass is cheap and runs per request.
 func (c *VoiceSampleCache) GetFiltered(ctx context.Context, voice string, filterID int) ([]byte, error) {
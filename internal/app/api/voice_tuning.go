@@ -0,0 +1,261 @@
+package api
+
+import (
+	"app/db"
+	"app/pkg/ai"
+	"app/pkg/ctxstore"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// voicePreviewMaxRunes keeps previews to a sentence or two of GPU time.
+const voicePreviewMaxRunes = 300
+
+// TTSEngineLister tells which TTS engines a character can pick.
+type TTSEngineLister interface {
+	TTSEngineNames() []string
+	HasTTSEngine(name string) bool
+}
+
+type emotionWeight struct {
+	Name   string
+	Weight float64
+}
+
+// voiceTuningView feeds the voice_tuning form on the character editor and
+// the try page.
+type voiceTuningView struct {
+	Engines  []string
+	TTS      *db.CardTTS
+	Emotions []emotionWeight
+}
+
+func (api *API) voiceTuningView(tts *db.CardTTS) *voiceTuningView {
+	if tts == nil {
+		tts = &db.CardTTS{}
+	}
+
+	emotions := make([]emotionWeight, len(ai.EmotionNames))
+	for i, name := range ai.EmotionNames {
+		emotions[i].Name = name
+		if i < len(tts.EmoVector) {
+			emotions[i].Weight = tts.EmoVector[i]
+		}
+	}
+
+	return &voiceTuningView{
+		Engines:  api.ttsEngines.TTSEngineNames(),
+		TTS:      tts,
+		Emotions: emotions,
+	}
+}
+
+// formFloat reads an optional number within [lo, hi]; ok is false when the
+// field is left empty.
+func formFloat(form url.Values, key, name string, lo, hi float64) (v float64, ok bool, err error) {
+	s := strings.TrimSpace(form.Get(key))
+	if s == "" {
+		return 0, false, nil
+	}
+	v, err = strconv.ParseFloat(s, 64)
+	if err != nil || v < lo || v > hi {
+		return 0, false, fmt.Errorf("%s must be a number from %g to %g", name, lo, hi)
+	}
+	return v, true, nil
+}
+
+// formToCardTTS reads the voice_tuning form; nil when all of it is left
+// empty, so the card follows the default engine as it is.
+func formToCardTTS(form url.Values) (*db.CardTTS, error) {
+	tts := &db.CardTTS{
+		Engine: strings.TrimSpace(form.Get("tts_engine")),
+		Voice:  strings.TrimSpace(form.Get("tts_voice")),
+	}
+	set := tts.Engine != "" || tts.Voice != ""
+
+	if v := strings.TrimSpace(form.Get("tts_max_tokens")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("max tokens per sentence must be a positive number")
+		}
+		tts.MaxTextTokensPerSentence = n
+		set = set || n != 0
+	}
+
+	for _, f := range []struct {
+		key, name string
+		lo, hi    float64
+		dst       *float64
+	}{
+		{"tts_emo_weight", "emotion weight", 0, 1, &tts.EmoWeight},
+		{"tts_speed", "speed", 0.5, 2, &tts.Speed},
+		{"tts_pitch", "pitch", -12, 12, &tts.Pitch},
+		{"tts_gain", "loudness offset", -20, 20, &tts.Gain},
+	} {
+		v, _, err := formFloat(form, f.key, f.name, f.lo, f.hi)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = v
+		set = set || v != 0
+	}
+
+	for _, f := range []struct {
+		key, name string
+		dst       **float64
+	}{
+		{"tts_alpha", "alpha", &tts.Alpha},
+		{"tts_beta", "beta", &tts.Beta},
+	} {
+		v, ok, err := formFloat(form, f.key, f.name, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			*f.dst = &v
+			set = true
+		}
+	}
+
+	vec := make([]float64, len(ai.EmotionNames))
+	hasEmotion := false
+	for i, name := range ai.EmotionNames {
+		v, _, err := formFloat(form, "tts_emotion_"+name, name, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		vec[i] = v
+		hasEmotion = hasEmotion || v != 0
+	}
+	if hasEmotion {
+		tts.EmoVector = vec
+		set = true
+	}
+
+	if !set {
+		return nil, nil
+	}
+
+	return tts, nil
+}
+
+func (api *API) checkCardTTS(tts *db.CardTTS) error {
+	if tts != nil && tts.Engine != "" && !api.ttsEngines.HasTTSEngine(tts.Engine) {
+		return fmt.Errorf("unknown voice engine: %s", tts.Engine)
+	}
+	return nil
+}
+
+// voiceTuningCard loads the card of a voice tuning request along with the
+// submitted tuning; on failure it returns the status to answer with.
+func (api *API) voiceTuningCard(r *http.Request) (*db.User, *db.Card, *db.CardTTS, int, error) {
+	user := ctxstore.GetUser(r.Context())
+	if user == nil {
+		return nil, nil, nil, http.StatusUnauthorized, fmt.Errorf("not authorized")
+	}
+
+	characterID, err := uuid.Parse(chi.URLParam(r, "character_id"))
+	if err != nil {
+		return nil, nil, nil, http.StatusBadRequest, fmt.Errorf("character_id is not a valid uuid")
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, nil, http.StatusBadRequest, fmt.Errorf("failed to parse form")
+	}
+
+	tts, err := formToCardTTS(r.Form)
+	if err == nil {
+		err = api.checkCardTTS(tts)
+	}
+	if err != nil {
+		return nil, nil, nil, http.StatusBadRequest, err
+	}
+
+	// only cards the user owns or public ones
+	card, err := api.db.GetCharCardByID(r.Context(), user.ID, characterID)
+	if err != nil {
+		return nil, nil, nil, http.StatusNotFound, fmt.Errorf("character not found")
+	}
+
+	return user, card, tts, http.StatusOK, nil
+}
+
+// voicePreview synthesizes a line as the character for the try page's A/B
+// comparison: variant "a" is the saved voice, anything else the submitted
+// tuning. Nothing is cached or stored.
+func (api *API) voicePreview(w http.ResponseWriter, r *http.Request) {
+	_, card, tts, status, err := api.voiceTuningCard(r)
+	if err != nil {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+
+	text := strings.TrimSpace(r.Form.Get("text"))
+	if text == "" {
+		text = voiceSampleText
+	}
+	if utf8.RuneCountInString(text) > voicePreviewMaxRunes {
+		text = string([]rune(text)[:voicePreviewMaxRunes])
+	}
+
+	data := *card.Data
+	if r.Form.Get("variant") != "a" {
+		data.TTS = tts
+	}
+
+	audio, err := api.voiceSamples.Preview(r.Context(), &data, text)
+	if err != nil {
+		api.logger.Error("voice preview failed", "err", err, "character_id", card.ID)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(audio))
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(audio)
+}
+
+// saveVoiceTuning stores the submitted tuning on the card, leaving the rest
+// of it alone.
+func (api *API) saveVoiceTuning(w http.ResponseWriter, r *http.Request) {
+	user, card, tts, status, err := api.voiceTuningCard(r)
+	if err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    status,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+
+	if card.OwnerUserID != user.ID {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: "only the owner can change the voice",
+		})
+		return
+	}
+
+	card.Data.TTS = tts
+	if err := api.db.UpdateCharCard(r.Context(), user.ID, card); err != nil {
+		_ = html.ExecuteTemplate(w, "error.html", &htmlErr{
+			ErrorCode:    http.StatusInternalServerError,
+			ErrorMessage: "UpdateCharCard: " + err.Error(),
+		})
+		return
+	}
+
+	if card.ShortCharName.Valid {
+		api.voiceSamples.Invalidate(card.ShortCharName.String)
+	}
+
+	_, _ = w.Write([]byte("Saved"))
+}
@@ -334,6 +334,11 @@ func (m *Manager) SkipMessage(userID uuid.UUID, msgID string) {
 	m.publishControl(userID, &Update{UpdateType: SkipMessage, Data: msgID})
 }
 
+// WrapUpMessage asks a running agentic dialogue for a closing line.
+func (m *Manager) WrapUpMessage(userID uuid.UUID, msgID string) {
+	m.publishControl(userID, &Update{UpdateType: WrapUpMessage, Data: msgID})
+}
+
 func (m *Manager) ShowImages(userID uuid.UUID, msgID string) {
 	m.publishControl(userID, &Update{UpdateType: ShowImages, Data: msgID})
 }
@@ -354,6 +359,16 @@ func (m *Manager) ShowImagesCurrent(userID uuid.UUID, token, msgID string) {
 	m.publishControl(userID, &Update{UpdateType: ShowImagesCurrent, Data: token, MsgID: msgID})
 }
 
+// PauseQueue and ResumeQueue only reach a running processor; the paused flag
+// itself lives in the user settings so a restarted processor comes back paused.
+func (m *Manager) PauseQueue(userID uuid.UUID) {
+	m.publishControl(userID, &Update{UpdateType: PauseQueue})
+}
+
+func (m *Manager) ResumeQueue(userID uuid.UUID) {
+	m.publishControl(userID, &Update{UpdateType: ResumeQueue})
+}
+
 func (m *Manager) DisableUser(userID uuid.UUID) {
 	m.rwMutex.Lock()
 	if cancel, ok := m.userCancels[userID]; ok {
@@ -21,6 +21,9 @@ const (
 	CleanOverlay
 	SkipCurrent
 	ShowImagesCurrent
+	PauseQueue
+	ResumeQueue
+	WrapUpMessage
 )
 
 type UpdateType int
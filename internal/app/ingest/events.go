@@ -0,0 +1,112 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/db"
+	"app/pkg/twitch"
+
+	gempir "github.com/gempir/go-twitch-irc/v4"
+)
+
+// handleUserNotice queues the subs, gifts and raids IRC announces, unless
+// the channel's EventSub session already delivers that kind of event.
+func (s *Service) handleUserNotice(msg gempir.UserNoticeMessage) {
+	s.activeUsersLock.RLock()
+	userCfg, ok := s.activeUsers[strings.ToLower(msg.Channel)]
+	s.activeUsersLock.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	twitchMsg, subType := userNoticeToMessage(msg)
+	if twitchMsg == nil || queuedByEventSub(userCfg, subType) {
+		return
+	}
+
+	s.pushEvent(s.logger.With("user", msg.Channel), msg.Channel, userCfg, twitchMsg, msg.ID)
+}
+
+// userNoticeToMessage maps a USERNOTICE onto an event message and the
+// EventSub type carrying the same event. The single subgift notices that
+// follow a mystery gift are dropped; the mystery gift itself counts them.
+func userNoticeToMessage(msg gempir.UserNoticeMessage) (*db.TwitchMessage, string) {
+	param := func(name string) string { return msg.MsgParams["msg-param-"+name] }
+	number := func(name string) int {
+		n, _ := strconv.Atoi(param(name))
+		return n
+	}
+
+	name := msg.User.DisplayName
+	if name == "" {
+		name = msg.User.Name
+	}
+
+	switch msg.MsgID {
+	case "sub", "resub":
+		tier := param("sub-plan")
+		return eventMessage(name, msg.User.ID, "subscribed at "+db.TierName(tier), &db.MessageEvent{
+			Type: db.MessageEventSub,
+			Tier: tier,
+		}), twitch.EventSubSub
+	case "subgift", "anonsubgift", "submysterygift", "anonsubmysterygift":
+		total := 1
+		if strings.HasSuffix(msg.MsgID, "mysterygift") {
+			total = number("mass-gift-count")
+		} else if param("community-gift-id") != "" {
+			return nil, ""
+		}
+
+		anonymous := strings.HasPrefix(msg.MsgID, "anon")
+		if anonymous {
+			name = "Anonymous"
+		}
+
+		tier := param("sub-plan")
+		return eventMessage(name, msg.User.ID, fmt.Sprintf("gifted %d %s subs", total, db.TierName(tier)), &db.MessageEvent{
+			Type:        db.MessageEventGift,
+			Tier:        tier,
+			Total:       total,
+			IsAnonymous: anonymous,
+		}), twitch.EventSubGift
+	case "raid":
+		viewers := number("viewerCount")
+		return eventMessage(name, msg.User.ID, fmt.Sprintf("raided with %d viewers", viewers), &db.MessageEvent{
+			Type:    db.MessageEventRaid,
+			Viewers: viewers,
+		}), twitch.EventSubRaid
+	default:
+		return nil, ""
+	}
+}
+
+// pushEvent queues an event if the channel has an enabled rule it clears;
+// anything else would only clutter the control panel.
+func (s *Service) pushEvent(logger *slog.Logger, login string, userCfg *ingestUserConfig, twitchMsg *db.TwitchMessage, uniqueID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rule, err := s.db.GetEventRule(ctx, userCfg.id, twitchMsg.Event.Type)
+	if err != nil {
+		if db.ErrCode(err) != db.ErrCodeNoRows {
+			logger.Error("failed to get event rule", "type", twitchMsg.Event.Type, "err", err)
+		}
+		return
+	}
+	if !rule.Matches(twitchMsg.Event) {
+		return
+	}
+
+	if _, err := s.db.PushIngestMsg(ctx, userCfg.id, *twitchMsg, &db.MessageData{}, uniqueID); err != nil {
+		logger.Error("failed to push event", "type", twitchMsg.Event.Type, "err", err)
+		return
+	}
+
+	logger.Info("ingested event", "user", login, "type", twitchMsg.Event.Type, "unique_id", uniqueID)
+}
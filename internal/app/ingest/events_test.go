@@ -0,0 +1,88 @@
+package ingest
+
+import (
+	"testing"
+
+	"app/db"
+	"app/pkg/twitch"
+
+	gempir "github.com/gempir/go-twitch-irc/v4"
+)
+
+func TestUserNoticeToMessage(t *testing.T) {
+	t.Parallel()
+
+	notice := func(msgID string, params map[string]string) gempir.UserNoticeMessage {
+		return gempir.UserNoticeMessage{
+			User:      gempir.User{ID: "7", Name: "viewer", DisplayName: "Viewer"},
+			MsgID:     msgID,
+			MsgParams: params,
+		}
+	}
+
+	cases := []struct {
+		name        string
+		msg         gempir.UserNoticeMessage
+		wantText    string
+		wantSubType string
+		rule        db.EventRule
+		wantPrompt  string
+		wantMatch   bool
+	}{
+		{
+			name:        "resub",
+			msg:         notice("resub", map[string]string{"msg-param-sub-plan": "Prime"}),
+			wantText:    "subscribed at Prime",
+			wantSubType: twitch.EventSubSub,
+			rule:        db.EventRule{EventType: db.MessageEventSub, Enabled: true, Prompt: "{user} subbed with {tier}"},
+			wantPrompt:  "Viewer subbed with Prime",
+			wantMatch:   true,
+		},
+		{
+			name:        "mystery gift below threshold",
+			msg:         notice("anonsubmysterygift", map[string]string{"msg-param-mass-gift-count": "3", "msg-param-sub-plan": "1000"}),
+			wantText:    "gifted 3 Tier 1 subs",
+			wantSubType: twitch.EventSubGift,
+			rule:        db.EventRule{EventType: db.MessageEventGift, Enabled: true, Prompt: "{user} gifted {total}", MinThreshold: 5},
+			wantPrompt:  "Anonymous gifted 3",
+		},
+		{
+			name:        "raid",
+			msg:         notice("raid", map[string]string{"msg-param-viewerCount": "120"}),
+			wantText:    "raided with 120 viewers",
+			wantSubType: twitch.EventSubRaid,
+			rule:        db.EventRule{EventType: db.MessageEventRaid, Enabled: true, Prompt: "{user} brought {viewers}", MinThreshold: 100},
+			wantPrompt:  "Viewer brought 120",
+			wantMatch:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		msg, subType := userNoticeToMessage(tc.msg)
+		if msg == nil {
+			t.Fatalf("%s: not queued", tc.name)
+		}
+		if msg.Message != tc.wantText || subType != tc.wantSubType {
+			t.Fatalf("%s: got %q as %q, want %q as %q", tc.name, msg.Message, subType, tc.wantText, tc.wantSubType)
+		}
+		if got := tc.rule.RenderPrompt(msg); got != tc.wantPrompt {
+			t.Fatalf("%s: prompt %q, want %q", tc.name, got, tc.wantPrompt)
+		}
+		if got := tc.rule.Matches(msg.Event); got != tc.wantMatch {
+			t.Fatalf("%s: matches %v, want %v", tc.name, got, tc.wantMatch)
+		}
+	}
+}
+
+func TestUserNoticeToMessageSkips(t *testing.T) {
+	t.Parallel()
+
+	for _, msg := range []gempir.UserNoticeMessage{
+		{MsgID: "subgift", MsgParams: map[string]string{"msg-param-community-gift-id": "123"}},
+		{MsgID: "announcement"},
+	} {
+		if got, _ := userNoticeToMessage(msg); got != nil {
+			t.Fatalf("%s: queued %+v", msg.MsgID, got)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"app/db"
+	"app/pkg/imagetag"
+	"app/pkg/twitch"
+
+	"github.com/nicklaw5/helix/v2"
+)
+
+const (
+	eventSubMinBackoff = 5 * time.Second
+	eventSubMaxBackoff = 5 * time.Minute
+)
+
+// eventSubListener is the EventSub session of one channel.
+type eventSubListener struct {
+	cancel context.CancelFunc
+
+	live atomic.Bool
+	// subTypes are the subscriptions live on the session. The IRC path
+	// leaves those events to EventSub meanwhile, so each one is queued once.
+	subTypes atomic.Pointer[[]string]
+}
+
+func (l *eventSubListener) subscribed(subType string) bool {
+	subTypes := l.subTypes.Load()
+	return subTypes != nil && slices.Contains(*subTypes, subType)
+}
+
+func (s *Service) startEventSub(ctx context.Context, login string, userCfg *ingestUserConfig) *eventSubListener {
+	ctx, cancel := context.WithCancel(ctx)
+	listener := &eventSubListener{cancel: cancel}
+
+	go s.runEventSub(ctx, login, userCfg, listener)
+
+	return listener
+}
+
+// runEventSub keeps the channel's EventSub session up, backing off while
+// Twitch keeps refusing it (e.g. a token without the scopes).
+func (s *Service) runEventSub(ctx context.Context, login string, userCfg *ingestUserConfig, listener *eventSubListener) {
+	logger := s.logger.With("user", login, "component", "eventsub")
+	backoff := eventSubMinBackoff
+
+	for {
+		started := time.Now()
+
+		user, err := s.db.GetUserByID(ctx, userCfg.id)
+		if err == nil {
+			err = s.twitchClient.ListenEventSub(ctx, logger, user, twitch.EventSubHandler{
+				OnSubscribed: func(subTypes []string) {
+					logger.Info("eventsub subscribed", "types", subTypes)
+					listener.subTypes.Store(&subTypes)
+					if !listener.live.Swap(true) {
+						metrics.EventSubSessions.Inc()
+					}
+				},
+				OnNotification: func(n *twitch.EventSubNotification) {
+					s.handleEventSubNotification(logger, login, userCfg, n)
+				},
+			})
+		}
+
+		listener.subTypes.Store(nil)
+		if listener.live.Swap(false) {
+			metrics.EventSubSessions.Dec()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(started) > eventSubMaxBackoff {
+			backoff = eventSubMinBackoff
+		}
+		logger.Warn("eventsub session ended", "err", err, "retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, eventSubMaxBackoff)
+	}
+}
+
+func (s *Service) handleEventSubNotification(logger *slog.Logger, login string, userCfg *ingestUserConfig, n *twitch.EventSubNotification) {
+	metrics.EventSubNotifications.WithLabelValues(n.Type).Inc()
+
+	twitchMsg, data, uniqueID, err := eventSubToMessage(n)
+	if err != nil {
+		logger.Error("failed to parse eventsub notification", "type", n.Type, "err", err)
+		return
+	}
+	if twitchMsg == nil {
+		return
+	}
+
+	if twitchMsg.Event != nil {
+		s.pushEvent(logger, login, userCfg, twitchMsg, uniqueID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if data.RedemptionID != "" {
+		_, inserted, err := s.db.PushRedemptionMsg(ctx, userCfg.id, *twitchMsg, data)
+		if err != nil {
+			logger.Error("failed to push eventsub message", "type", n.Type, "err", err)
+			return
+		}
+		if !inserted {
+			logger.Info("redemption already queued", "user", login, "unique_id", uniqueID)
+			return
+		}
+	} else if _, err := s.db.PushIngestMsg(ctx, userCfg.id, *twitchMsg, data, uniqueID); err != nil {
+		logger.Error("failed to push eventsub message", "type", n.Type, "err", err)
+		return
+	}
+
+	logger.Info("ingested eventsub message", "user", login, "type", n.Type, "unique_id", uniqueID)
+}
+
+// eventSubToMessage maps a notification onto a queue message. Redemptions
+// are keyed by redemption id, everything else by the notification id, so
+// redeliveries, and redemptions the IRC fallback queued first, land on the
+// row already queued. A nil message means the
+// notification is not queued.
+func eventSubToMessage(n *twitch.EventSubNotification) (*db.TwitchMessage, *db.MessageData, string, error) {
+	switch n.Type {
+	case twitch.EventSubRedemption:
+		var ev helix.EventSubChannelPointsCustomRewardRedemptionEvent
+		if err := json.Unmarshal(n.Event, &ev); err != nil {
+			return nil, nil, "", err
+		}
+		// IRC only ever saw redemptions with text; keep it that way
+		if ev.UserInput == "" {
+			return nil, nil, "", nil
+		}
+
+		twitchUserID, _ := strconv.Atoi(ev.UserID)
+		showImages := false
+		data := &db.MessageData{
+			ImageIDs:     imagetag.ExtractIDs(ev.UserInput, 2),
+			ShowImages:   &showImages,
+			RedemptionID: ev.ID,
+		}
+		// skip-the-queue rewards arrive fulfilled; nothing left to settle.
+		// EventSub spells statuses in lowercase, Helix in uppercase.
+		if ev.Status != "" && !strings.EqualFold(ev.Status, twitch.RedemptionUnfulfilled) {
+			data.RedemptionStatus = strings.ToUpper(ev.Status)
+		}
+
+		return &db.TwitchMessage{
+			TwitchLogin:  ev.UserName,
+			TwitchUserID: twitchUserID,
+			Message:      ev.UserInput,
+			RewardID:     ev.Reward.ID,
+		}, data, "redemption:" + ev.ID, nil
+	case twitch.EventSubFollow:
+		var ev helix.EventSubChannelFollowEvent
+		if err := json.Unmarshal(n.Event, &ev); err != nil {
+			return nil, nil, "", err
+		}
+
+		return eventMessage(ev.UserName, ev.UserID, "followed", &db.MessageEvent{
+			Type: db.MessageEventFollow,
+		}), &db.MessageData{}, "eventsub:" + n.MessageID, nil
+	case twitch.EventSubSub:
+		var ev helix.EventSubChannelSubscribeEvent
+		if err := json.Unmarshal(n.Event, &ev); err != nil {
+			return nil, nil, "", err
+		}
+		// gifted subs are queued once, as the gifter's gift event
+		if ev.IsGift {
+			return nil, nil, "", nil
+		}
+
+		return eventMessage(ev.UserName, ev.UserID, "subscribed at "+db.TierName(ev.Tier), &db.MessageEvent{
+			Type: db.MessageEventSub,
+			Tier: ev.Tier,
+		}), &db.MessageData{}, "eventsub:" + n.MessageID, nil
+	case twitch.EventSubGift:
+		var ev helix.EventSubChannelSubscriptionGiftEvent
+		if err := json.Unmarshal(n.Event, &ev); err != nil {
+			return nil, nil, "", err
+		}
+
+		name := ev.UserName
+		if ev.IsAnonymous || name == "" {
+			name = "Anonymous"
+		}
+
+		return eventMessage(name, ev.UserID, fmt.Sprintf("gifted %d %s subs", ev.Total, db.TierName(ev.Tier)), &db.MessageEvent{
+			Type:        db.MessageEventGift,
+			Tier:        ev.Tier,
+			Total:       ev.Total,
+			IsAnonymous: ev.IsAnonymous,
+		}), &db.MessageData{}, "eventsub:" + n.MessageID, nil
+	case twitch.EventSubRaid:
+		var ev helix.EventSubChannelRaidEvent
+		if err := json.Unmarshal(n.Event, &ev); err != nil {
+			return nil, nil, "", err
+		}
+
+		return eventMessage(ev.FromBroadcasterUserName, ev.FromBroadcasterUserID, fmt.Sprintf("raided with %d viewers", ev.Viewers), &db.MessageEvent{
+			Type:    db.MessageEventRaid,
+			Viewers: ev.Viewers,
+		}), &db.MessageData{}, "eventsub:" + n.MessageID, nil
+	default:
+		return nil, nil, "", nil
+	}
+}
+
+func eventMessage(userName, userID, text string, event *db.MessageEvent) *db.TwitchMessage {
+	twitchUserID, _ := strconv.Atoi(userID)
+
+	return &db.TwitchMessage{
+		TwitchLogin:  userName,
+		TwitchUserID: twitchUserID,
+		Message:      text,
+		Event:        event,
+	}
+}
+
+// queuedByEventSub reports whether the channel's EventSub session queues
+// subType. The IRC copy of an event is then dropped; the IRC copy of a
+// redemption only queues as a fallback, keyed on the redemption id.
+func queuedByEventSub(userCfg *ingestUserConfig, subType string) bool {
+	return userCfg.eventSub != nil && userCfg.eventSub.subscribed(subType)
+}
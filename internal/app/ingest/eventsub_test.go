@@ -0,0 +1,76 @@
+package ingest
+
+import (
+	"testing"
+
+	"app/db"
+	"app/pkg/twitch"
+)
+
+func TestEventSubToMessage(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		n        twitch.EventSubNotification
+		wantText string
+		wantKey  string
+		wantType string
+	}{
+		{
+			name:     "redemption",
+			n:        twitch.EventSubNotification{MessageID: "m1", Type: twitch.EventSubRedemption, Event: []byte(`{"id":"r1","user_id":"7","user_name":"Viewer","user_input":"hello","status":"unfulfilled","reward":{"id":"reward"}}`)},
+			wantText: "hello",
+			wantKey:  "redemption:r1",
+		},
+		{
+			name:     "raid",
+			n:        twitch.EventSubNotification{MessageID: "m2", Type: twitch.EventSubRaid, Event: []byte(`{"from_broadcaster_user_id":"9","from_broadcaster_user_name":"xqc","viewers":5000}`)},
+			wantText: "raided with 5000 viewers",
+			wantKey:  "eventsub:m2",
+			wantType: db.MessageEventRaid,
+		},
+		{
+			name:     "anonymous gift",
+			n:        twitch.EventSubNotification{MessageID: "m3", Type: twitch.EventSubGift, Event: []byte(`{"total":5,"tier":"1000","is_anonymous":true}`)},
+			wantText: "gifted 5 Tier 1 subs",
+			wantKey:  "eventsub:m3",
+			wantType: db.MessageEventGift,
+		},
+	}
+
+	for _, tc := range cases {
+		msg, data, key, err := eventSubToMessage(&tc.n)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if msg == nil {
+			t.Fatalf("%s: not queued", tc.name)
+		}
+		if msg.Message != tc.wantText || key != tc.wantKey {
+			t.Fatalf("%s: got %q under %q, want %q under %q", tc.name, msg.Message, key, tc.wantText, tc.wantKey)
+		}
+		if tc.wantType == "" {
+			if msg.Event != nil || msg.RewardID != "reward" || data.RedemptionID != "r1" || data.RedemptionStatus != "" {
+				t.Fatalf("%s: got %+v %+v", tc.name, msg, data)
+			}
+		} else if msg.Event == nil || msg.Event.Type != tc.wantType {
+			t.Fatalf("%s: got event %+v, want %s", tc.name, msg.Event, tc.wantType)
+		}
+	}
+}
+
+func TestEventSubToMessageSkips(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []twitch.EventSubNotification{
+		{Type: twitch.EventSubRedemption, Event: []byte(`{"id":"r1","user_input":"","reward":{"id":"reward"}}`)},
+		{Type: twitch.EventSubSub, Event: []byte(`{"user_name":"Viewer","tier":"1000","is_gift":true}`)},
+		{Type: "channel.update", Event: []byte(`{}`)},
+	} {
+		msg, _, _, err := eventSubToMessage(&n)
+		if err != nil || msg != nil {
+			t.Fatalf("%s: got %+v, %v, want it skipped", n.Type, msg, err)
+		}
+	}
+}
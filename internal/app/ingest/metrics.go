@@ -12,6 +12,9 @@ type Metrics struct {
 	MessagesIngested     prometheus.Counter
 	ConnectedClients     prometheus.Gauge
 	ShardCount           prometheus.Gauge
+
+	EventSubSessions      prometheus.Gauge
+	EventSubNotifications *prometheus.CounterVec
 }
 
 var metrics = &Metrics{
@@ -57,6 +60,18 @@ var metrics = &Metrics{
 		Name:      "active_count",
 		Help:      "Number of active Twitch IRC shards (connections managed by the sharded client)",
 	}),
+	EventSubSessions: prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "twitch_ingest",
+		Subsystem: "eventsub",
+		Name:      "sessions_count",
+		Help:      "Number of channels with a subscribed EventSub WebSocket session",
+	}),
+	EventSubNotifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "twitch_ingest",
+		Subsystem: "eventsub",
+		Name:      "notifications_total",
+		Help:      "Number of EventSub notifications received, labeled by subscription type",
+	}, []string{"type"}),
 }
 
 func RegisterMetrics(reg prometheus.Registerer) {
@@ -67,4 +82,6 @@ func RegisterMetrics(reg prometheus.Registerer) {
 	reg.MustRegister(metrics.MessagesIngested)
 	reg.MustRegister(metrics.ConnectedClients)
 	reg.MustRegister(metrics.ShardCount)
+	reg.MustRegister(metrics.EventSubSessions)
+	reg.MustRegister(metrics.EventSubNotifications)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"strconv"
 	"strings"
 	"sync"
@@ -21,6 +22,8 @@ type ingestUserConfig struct {
 	id                uuid.UUID
 	twitchUserID      int
 	ingestAllMessages bool
+
+	eventSub *eventSubListener
 }
 
 type Service struct {
@@ -28,23 +31,32 @@ type Service struct {
 	db     *db.DB
 	cfg    *twitch.Config
 
-	chatClient *twitch.ShardedClient
+	eventSubEnabled bool
+
+	chatClient   *twitch.ShardedClient
+	twitchClient *twitch.Client
 
 	activeUsers     map[string]*ingestUserConfig
 	activeUsersLock sync.RWMutex
 }
 
-func NewService(logger *slog.Logger, database *db.DB, cfg *twitch.Config) *Service {
+// NewService builds the ingest. With eventSub set, every joined channel also
+// gets an EventSub session for redemptions, follows, subs, gifts and raids.
+func NewService(logger *slog.Logger, database *db.DB, cfg *twitch.Config, eventSub bool) *Service {
 	s := &Service{
-		logger:      logger,
-		db:          database,
-		cfg:         cfg,
-		activeUsers: make(map[string]*ingestUserConfig),
+		logger:          logger,
+		db:              database,
+		cfg:             cfg,
+		eventSubEnabled: eventSub,
+		activeUsers:     make(map[string]*ingestUserConfig),
+
+		twitchClient: twitch.New(&http.Client{Timeout: 10 * time.Second}, cfg),
 	}
 
 	s.chatClient = twitch.NewShardedClient(
 		logger,
 		s.handleMessage,
+		s.handleUserNotice,
 		func() { metrics.ConnectedClients.Inc() },
 		func() { metrics.ConnectedClients.Dec() },
 		func(channel, reason string) {
@@ -123,6 +135,9 @@ func (s *Service) syncUsers(ctx context.Context) error {
 				s.logger.Error("failed to join channel", "login", login, "err", err)
 				continue
 			}
+			if s.eventSubEnabled {
+				cfg.eventSub = s.startEventSub(ctx, login, cfg)
+			}
 			s.activeUsers[login] = cfg
 		} else {
 			existing.ingestAllMessages = cfg.ingestAllMessages
@@ -130,10 +145,13 @@ func (s *Service) syncUsers(ctx context.Context) error {
 		}
 	}
 
-	for login := range s.activeUsers {
+	for login, cfg := range s.activeUsers {
 		if _, ok := desiredUsers[login]; !ok {
 			s.logger.Info("departing channel", "login", login)
 			s.departChannel(login)
+			if cfg.eventSub != nil {
+				cfg.eventSub.cancel()
+			}
 			delete(s.activeUsers, login)
 		}
 	}
@@ -177,7 +195,17 @@ func (s *Service) handleMessage(msg gempir.PrivateMessage) {
 		return
 	}
 
-	// Route ^^ commands (except ^^voice) to clanker queue
+	if optOut, ok := parseMemoryOptCommand(msg.Message); ok {
+		s.handleMemoryOptCommand(twitchUserID, msg.User.Name, optOut)
+		return
+	}
+
+	if text, ok := parseSayCommand(msg.Message); ok {
+		s.handleSayCommand(userCfg, twitchUserID, msg.User.Name, text)
+		return
+	}
+
+	// Route ^^ commands (except ^^voice, ^^optout, ^^optin and ^^say) to clanker queue
 	if strings.HasPrefix(msg.Message, "^^") {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -210,15 +238,130 @@ func (s *Service) handleMessage(msg gempir.PrivateMessage) {
 		RewardID:     msg.CustomRewardID,
 	}
 
+	if len(twitchMsg.RewardID) != 0 && queuedByEventSub(userCfg, twitch.EventSubRedemption) {
+		go s.fallbackRedemption(msg.Channel, userCfg.id, twitchMsg, data)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := s.db.PushIngestMsg(ctx, userCfg.id, twitchMsg, data, msg.ID)
+	msgID, err := s.db.PushIngestMsg(ctx, userCfg.id, twitchMsg, data, msg.ID)
 	if err != nil {
 		s.logger.Error("failed to push message", "err", err, "user", msg.Channel)
 	} else {
 		s.logger.Info("ingested message", "user", msg.Channel, "msg_id", msg.ID)
+
+		if len(twitchMsg.RewardID) != 0 {
+			go s.captureRedemption(userCfg.id, msgID, twitchMsg)
+		}
+	}
+}
+
+const (
+	redemptionLookupAttempts = 3
+	redemptionLookupDelay    = 2 * time.Second
+
+	// redemptionFallbackDelay is how long the IRC copy of a redemption gives
+	// EventSub to queue it first.
+	redemptionFallbackDelay = 5 * time.Second
+)
+
+// captureRedemption records the redemption behind a reward message so the
+// processor can fulfill or refund it.
+func (s *Service) captureRedemption(userID, msgID uuid.UUID, twitchMsg db.TwitchMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	logger := s.logger.With("msg_id", msgID, "reward_id", twitchMsg.RewardID)
+
+	redemptionID := s.findRedemption(ctx, logger, userID, twitchMsg)
+	if redemptionID == "" {
+		return
+	}
+
+	if err := s.db.UpdateMessageData(ctx, msgID, &db.MessageData{RedemptionID: redemptionID}); err != nil {
+		logger.Warn("failed to store redemption id", "err", err)
+	}
+}
+
+// fallbackRedemption queues a reward message seen on IRC while EventSub is
+// subscribed to redemptions, in case the notification never comes. It waits
+// for EventSub first, then queues the message under the redemption id it
+// resolves to, so whichever path is second finds the row taken. Redemptions
+// EventSub already queued are claimed and not found again; rewards this app
+// did not create cannot be looked up and are left to EventSub.
+func (s *Service) fallbackRedemption(channel string, userID uuid.UUID, twitchMsg db.TwitchMessage, data *db.MessageData) {
+	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
+	defer cancel()
+
+	logger := s.logger.With("user", channel, "reward_id", twitchMsg.RewardID)
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(redemptionFallbackDelay):
+	}
+
+	data.RedemptionID = s.findRedemption(ctx, logger, userID, twitchMsg)
+	if data.RedemptionID == "" {
+		return
+	}
+
+	msgID, inserted, err := s.db.PushRedemptionMsg(ctx, userID, twitchMsg, data)
+	if err != nil {
+		logger.Error("failed to push message", "err", err)
+		return
+	}
+	if inserted {
+		logger.Info("ingested redemption eventsub missed", "msg_id", msgID, "redemption_id", data.RedemptionID)
+	}
+}
+
+// findRedemption resolves the redemption behind a reward message, or "".
+// Helix can list the redemption a moment after the chat message arrives,
+// hence the retries. Rewards this app did not create are skipped: Helix
+// refuses to touch them anyway.
+func (s *Service) findRedemption(ctx context.Context, logger *slog.Logger, userID uuid.UUID, twitchMsg db.TwitchMessage) string {
+	if _, _, err := s.db.GetRewardByTwitchReward(ctx, twitchMsg.RewardID); err != nil {
+		if db.ErrCode(err) != db.ErrCodeNoRows {
+			logger.Warn("failed to get reward", "err", err)
+		}
+		return ""
+	}
+
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Warn("failed to get user", "err", err)
+		return ""
+	}
+
+	for attempt := range redemptionLookupAttempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ""
+			case <-time.After(redemptionLookupDelay):
+			}
+		}
+
+		claimed, err := s.db.GetClaimedRedemptionIDs(ctx, userID)
+		if err != nil {
+			logger.Warn("failed to get claimed redemptions", "err", err)
+			return ""
+		}
+		redemptionID, err := s.twitchClient.FindRedemption(user, twitchMsg.RewardID, twitchMsg.TwitchUserID, twitchMsg.Message, claimed)
+		if err != nil {
+			logger.Warn("failed to find redemption", "err", err)
+			return ""
+		}
+		if redemptionID != "" {
+			return redemptionID
+		}
 	}
+
+	logger.Info("redemption not found", "user", user.TwitchLogin)
+	return ""
 }
 
 func parseVoiceCommand(message string) (string, bool) {
@@ -262,3 +405,89 @@ func (s *Service) handleVoiceCommand(twitchUserID int, twitchLogin, voiceName st
 
 	s.logger.Info("voice set", "user", twitchLogin, "voice", voiceName)
 }
+
+// parseMemoryOptCommand reports whether message is ^^optout (true) or ^^optin
+// (false).
+func parseMemoryOptCommand(message string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(message)) {
+	case "^^optout":
+		return true, true
+	case "^^optin":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+func (s *Service) handleMemoryOptCommand(twitchUserID int, twitchLogin string, optOut bool) {
+	if twitchUserID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if !optOut {
+		if err := s.db.OptInChatUserMemory(ctx, twitchUserID, twitchLogin); err != nil {
+			s.logger.Error("failed to opt in to memories", "err", err, "user", twitchLogin)
+			return
+		}
+
+		s.logger.Info("opted in to memories", "user", twitchLogin)
+		return
+	}
+
+	memories, interactions, err := s.db.OptOutChatUserMemory(ctx, twitchUserID, twitchLogin)
+	if err != nil {
+		s.logger.Error("failed to opt out of memories", "err", err, "user", twitchLogin)
+		return
+	}
+
+	s.logger.Info("opted out of memories", "user", twitchLogin, "deleted_memories", memories, "deleted_interactions", interactions)
+}
+
+// maxSayRunes keeps an interjection to a line of chat.
+const maxSayRunes = 200
+
+// parseSayCommand returns the text of a ^^say command, cut to maxSayRunes.
+func parseSayCommand(message string) (string, bool) {
+	if len(message) < len("^^say ") || !strings.EqualFold(message[:len("^^say ")], "^^say ") {
+		return "", false
+	}
+
+	text := strings.TrimSpace(message[len("^^say "):])
+	if len(text) == 0 {
+		return "", false
+	}
+
+	if runes := []rune(text); len(runes) > maxSayRunes {
+		text = strings.TrimSpace(string(runes[:maxSayRunes]))
+	}
+
+	return text, true
+}
+
+// handleSayCommand queues a viewer's line for the channel's running Agent BAJ
+// dialogue. The processor filters it between turns; lines sent while no
+// dialogue runs are never taken and get pruned.
+func (s *Service) handleSayCommand(userCfg *ingestUserConfig, twitchUserID int, twitchLogin, text string) {
+	if twitchUserID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pushed, err := s.db.PushAgenticInterjection(ctx, userCfg.id, twitchUserID, twitchLogin, text)
+	if err != nil {
+		s.logger.Error("failed to push interjection", "err", err, "user", twitchLogin)
+		return
+	}
+
+	if !pushed {
+		s.logger.Info("interjection on cooldown", "user", twitchLogin)
+		return
+	}
+
+	s.logger.Info("interjection queued", "user", twitchLogin)
+}
@@ -0,0 +1,37 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseSayCommand(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		msg    string
+		want   string
+		wantOk bool
+	}{
+		{msg: "^^say forsen, look behind you", want: "forsen, look behind you", wantOk: true},
+		{msg: "^^SAY   hello  ", want: "hello", wantOk: true},
+		{msg: "^^say ", wantOk: false},
+		{msg: "^^say", wantOk: false},
+		{msg: "^^sayhello", wantOk: false},
+		{msg: "^^voice forsen", wantOk: false},
+		{msg: "say hello", wantOk: false},
+	}
+
+	for _, tc := range cases {
+		got, ok := parseSayCommand(tc.msg)
+		if ok != tc.wantOk || got != tc.want {
+			t.Fatalf("%q: got %q, %v, want %q, %v", tc.msg, got, ok, tc.want, tc.wantOk)
+		}
+	}
+
+	long, ok := parseSayCommand("^^say " + strings.Repeat("ä", maxSayRunes+10))
+	if !ok || utf8.RuneCountInString(long) != maxSayRunes {
+		t.Fatalf("long line: got %d runes, %v, want %d", utf8.RuneCountInString(long), ok, maxSayRunes)
+	}
+}
@@ -168,7 +168,7 @@ func TestAgenticHandle_DBIntegration(t *testing.T) {
 
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	svc := NewService(logger, database, nil, ff, &stubTTSEngine{}, &stubTTSEngine{}, nil, nil, nil, nil, nil)
+	svc := NewService(logger, database, nil, ff, &stubTTSEngine{}, &stubTTSEngine{}, nil, nil, nil, nil, nil, nil)
 	h := NewAgenticHandler(logger, database, detector, planner, dialogueLLM, svc)
 
 	msgID := uuid.New().String()
@@ -278,7 +278,7 @@ func TestAgenticHandle_RealLLM_DBIntegration_PrintDialoguePrompts(t *testing.T)
 	shortWav, err := ff.TrimToWav(ctx, refWav, 250*time.Millisecond)
 	require.NoError(t, err)
 
-	svc := NewService(logger, database, nil, ff, &fixedAudioTTSEngine{audio: shortWav}, &fixedAudioTTSEngine{audio: shortWav}, nil, nil, nil, nil, nil)
+	svc := NewService(logger, database, nil, ff, &fixedAudioTTSEngine{audio: shortWav}, &fixedAudioTTSEngine{audio: shortWav}, nil, nil, nil, nil, nil, nil)
 
 	h := NewAgenticHandler(logger, database, detector, planner, dialogueLLM, svc)
 
@@ -539,12 +539,10 @@ func newAgenticGuidedStubServer(t *testing.T, nameA, nameB string) *httptest.Ser
 			}
 		case props != nil && props["next_speaker_name"] != nil:
 			nextSpeakerCalls++
-			next := "END"
-			if nextSpeakerCalls == 1 {
-				next = nameB
-			}
 			content = map[string]any{
-				"next_speaker_name": next,
+				"reason":            "stub",
+				"end_conversation":  nextSpeakerCalls != 1,
+				"next_speaker_name": nameB,
 			}
 		default:
 			content = map[string]any{}
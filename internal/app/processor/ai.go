@@ -2,14 +2,13 @@ package processor
 
 import (
 	"context"
-	"fmt"
-	"regexp"
 	"slices"
-	"strings"
-	"unicode/utf8"
 
 	"app/db"
+	"app/pkg/llmfilter"
 	"app/pkg/textfilter"
+
+	"github.com/google/uuid"
 )
 
 func (s *Service) FilterText(_ context.Context, userSettings *db.UserSettings, text string) string {
@@ -18,29 +17,65 @@ func (s *Service) FilterText(_ context.Context, userSettings *db.UserSettings, t
 }
 
 // filterSpans marks a standalone message: regex patterns plus the context-aware
-// LLM filter, merged.
-func (s *Service) filterSpans(ctx context.Context, userSettings *db.UserSettings, text string, skipLLM bool) ([]textfilter.Span, error) {
+// LLM filter, merged. The run is recorded for the filter audit log.
+func (s *Service) filterSpans(ctx context.Context, userID, msgID uuid.UUID, userSettings *db.UserSettings, text string, skipLLM bool) ([]textfilter.Span, error) {
+	return s.filterTextSpans(ctx, &db.FilterRun{UserID: userID, MsgID: msgID, Kind: db.FilterRunRequest, Target: text}, userSettings, skipLLM)
+}
+
+// filterInterjectionSpans marks a viewer's line in a running dialogue like
+// filterSpans, recording the run as an interjection so the dialogue's own
+// request run stays the latest of its kind.
+func (s *Service) filterInterjectionSpans(ctx context.Context, userID, msgID uuid.UUID, userSettings *db.UserSettings, text string, skipLLM bool) ([]textfilter.Span, error) {
+	return s.filterTextSpans(ctx, &db.FilterRun{UserID: userID, MsgID: msgID, Kind: db.FilterRunInterjection, Target: text}, userSettings, skipLLM)
+}
+
+func (s *Service) filterTextSpans(ctx context.Context, run *db.FilterRun, userSettings *db.UserSettings, skipLLM bool) ([]textfilter.Span, error) {
+	text := run.Target
+	regexSpans := textfilter.Merge(s.regexSpans(userSettings, text))
 	if skipLLM {
-		return textfilter.Merge(s.regexSpans(userSettings, text)), nil
+		s.recordFilterRun(ctx, run, regexSpans, nil)
+		return regexSpans, nil
 	}
-	llmSpans, err := s.llmFilter.Spans(ctx, text, userSettings.CustomFilterPrompt)
+	decision, err := s.llmFilter.Decide(ctx, text, userSettings.CustomFilterPrompt)
 	if err != nil {
 		return nil, err
 	}
-	return textfilter.Merge(s.regexSpans(userSettings, text), llmSpans), nil
+	run.Custom = userSettings.CustomFilterPrompt
+	s.recordFilterRun(ctx, run, regexSpans, decision)
+	return textfilter.Merge(regexSpans, decision.Spans()), nil
 }
 
 // filterReplySpans marks an AI reply, judging it against the prompt it answers
 // so context-dependent hate ("I hate them") is caught.
-func (s *Service) filterReplySpans(ctx context.Context, userSettings *db.UserSettings, prompt, reply string, skipLLM bool) ([]textfilter.Span, error) {
+func (s *Service) filterReplySpans(ctx context.Context, userID, msgID uuid.UUID, userSettings *db.UserSettings, prompt, reply string, skipLLM bool) ([]textfilter.Span, error) {
+	run := &db.FilterRun{UserID: userID, MsgID: msgID, Kind: db.FilterRunReply, Target: reply, Context: prompt}
+	regexSpans := textfilter.Merge(s.regexSpans(userSettings, reply))
 	if skipLLM {
-		return textfilter.Merge(s.regexSpans(userSettings, reply)), nil
+		s.recordFilterRun(ctx, run, regexSpans, nil)
+		return regexSpans, nil
 	}
-	llmSpans, err := s.llmFilter.ReplySpans(ctx, prompt, reply, userSettings.CustomFilterPrompt)
+	decision, err := s.llmFilter.DecideReply(ctx, prompt, reply, userSettings.CustomFilterPrompt)
 	if err != nil {
 		return nil, err
 	}
-	return textfilter.Merge(s.regexSpans(userSettings, reply), llmSpans), nil
+	run.Custom = userSettings.CustomFilterPrompt
+	s.recordFilterRun(ctx, run, regexSpans, decision)
+	return textfilter.Merge(regexSpans, decision.Spans()), nil
+}
+
+// replyPieceSpans marks one piece of a reply still being generated, judged
+// against the prompt and what the reply said before it. The run is not
+// recorded: the caller records the whole reply once it is complete.
+func (s *Service) replyPieceSpans(ctx context.Context, userSettings *db.UserSettings, prompt, earlier, piece string, skipLLM bool) ([]textfilter.Span, *llmfilter.Decision, error) {
+	regexSpans := textfilter.Merge(s.regexSpans(userSettings, piece))
+	if skipLLM {
+		return regexSpans, nil, nil
+	}
+	decision, err := s.llmFilter.DecideContinuation(ctx, prompt, earlier, piece, userSettings.CustomFilterPrompt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return regexSpans, decision, nil
 }
 
 // spansAfterPrefix re-bases spans over (prefix+body) onto body alone: it drops
@@ -59,35 +94,11 @@ func spansAfterPrefix(spans []textfilter.Span, prefixLen int) []textfilter.Span
 }
 
 // regexSpans returns the ranges matched by the built-in and per-user filter
-// patterns, as rune offsets over text.
+// patterns and words, as rune offsets over text.
 func (s *Service) regexSpans(userSettings *db.UserSettings, text string) []textfilter.Span {
 	if userSettings.DisableRegexFilter {
 		return nil
 	}
 
-	patterns := GlobalSwears
-	if len(userSettings.Filters) != 0 {
-		patterns = slices.Concat(patterns, strings.Split(userSettings.Filters, ","))
-	}
-
-	var spans []textfilter.Span
-	for _, exp := range patterns {
-		exp = strings.TrimSpace(exp)
-		if exp == "" {
-			continue
-		}
-
-		r, err := regexp.Compile("(?i)" + exp)
-		if err != nil {
-			s.logger.Warn(fmt.Sprintf("failed compiling reg expression '%s'", exp), "err", err)
-			continue
-		}
-		for _, m := range r.FindAllStringIndex(text, -1) {
-			spans = append(spans, textfilter.Span{
-				Start: utf8.RuneCountInString(text[:m[0]]),
-				End:   utf8.RuneCountInString(text[:m[1]]),
-			})
-		}
-	}
-	return spans
+	return slices.Concat(globalFilterSet().Spans(text), s.filterSets.get(s.logger, userSettings).Spans(text))
 }
@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"app/db"
+	"app/internal/app/conns"
+	"app/internal/app/monitoring"
+)
+
+// processEvent has the rule's character react to a follow, sub, gift or
+// raid through the AI handler. The rule is looked up again: it may have been
+// disabled or pointed at another card since the event was queued.
+func (p *Processor) processEvent(ctx context.Context, logger *slog.Logger, eventWriter conns.EventWriter, broadcaster *db.User, state *ProcessorState, msg *db.Message, userSettings *db.UserSettings, replay bool) error {
+	rule, err := p.db.GetEventRule(ctx, broadcaster.ID, msg.TwitchMessage.Event.Type)
+	if err != nil {
+		if db.ErrCode(err) != db.ErrCodeNoRows {
+			logger.Error("error getting event rule", "err", err)
+		}
+		return nil
+	}
+	if !rule.Matches(msg.TwitchMessage.Event) {
+		return nil
+	}
+
+	charCard, err := p.db.GetCharCardByID(ctx, broadcaster.ID, rule.CardID)
+	if err != nil {
+		logger.Error("error getting character card", "err", err)
+		return nil
+	}
+
+	if !replay {
+		monitoring.AppMetrics.RewardRedeems.WithLabelValues(broadcaster.TwitchLogin, db.TwitchRewardEvent.String()).Inc()
+	}
+
+	input := InteractionInput{
+		Requester:    msg.TwitchMessage.TwitchLogin,
+		TwitchUserID: msg.TwitchMessage.TwitchUserID,
+		Broadcaster:  broadcaster,
+		Message:      rule.RenderPrompt(&msg.TwitchMessage),
+		Character:    charCard,
+		UserSettings: userSettings,
+		MsgID:        msg.ID.String(),
+		State:        state,
+		AudioWriter:  p.overlayAudioWriter(broadcaster.ID),
+		Replay:       replay,
+		Event:        true,
+	}
+	if err := p.aiHandler.Handle(ctx, input, eventWriter); err != nil {
+		recordHandlerError(ctx, "event")
+		return fmt.Errorf("event handler error: %w", err)
+	}
+
+	return nil
+}
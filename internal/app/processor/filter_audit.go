@@ -0,0 +1,39 @@
+package processor
+
+import (
+	"context"
+
+	"app/db"
+	"app/pkg/llmfilter"
+	"app/pkg/textfilter"
+)
+
+// filterRunSpans labels each span with the pass that produced it. A range
+// two passes both caught is kept once per pass.
+func filterRunSpans(regexSpans []textfilter.Span, decision *llmfilter.Decision) []db.FilterRunSpan {
+	var spans []db.FilterRunSpan
+	add := func(pass db.FilterPass, set []textfilter.Span) {
+		for _, span := range set {
+			spans = append(spans, db.FilterRunSpan{Start: span.Start, End: span.End, Pass: pass})
+		}
+	}
+
+	add(db.FilterPassRegex, regexSpans)
+	if decision != nil {
+		add(db.FilterPassBuiltin, decision.Builtin)
+		add(db.FilterPassStreamer, decision.Streamer)
+	}
+
+	return spans
+}
+
+// recordFilterRun stores the run for the audit log. A nil decision means the
+// LLM filter was skipped. Failing to record never blocks the message.
+func (s *Service) recordFilterRun(ctx context.Context, run *db.FilterRun, regexSpans []textfilter.Span, decision *llmfilter.Decision) {
+	run.LLMSkipped = decision == nil
+	run.Spans = filterRunSpans(regexSpans, decision)
+
+	if err := s.db.InsertFilterRun(ctx, run); err != nil {
+		s.logger.Error("failed to record filter run", "msg_id", run.MsgID, "err", err)
+	}
+}
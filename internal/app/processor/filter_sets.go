@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"app/db"
+	"app/pkg/textfilter"
+)
+
+// the built-in list never changes, so it is compiled once for everyone
+var globalFilterSet = sync.OnceValue(func() *textfilter.Set {
+	set, _ := textfilter.Compile(GlobalSwears, nil)
+	return set
+})
+
+// bounds the cache; past it everything is recompiled on demand
+const maxCachedFilterSets = 1024
+
+type filterSource struct {
+	patterns string
+	words    string
+}
+
+// filterSetCache holds compiled per-user filters keyed by their source text.
+// Saving the filters page changes the key, so the next message compiles the
+// new set and the old one is never looked up again.
+type filterSetCache struct {
+	mu   sync.Mutex
+	sets map[filterSource]*textfilter.Set
+}
+
+func (c *filterSetCache) get(logger *slog.Logger, userSettings *db.UserSettings) *textfilter.Set {
+	src := filterSource{patterns: userSettings.Filters, words: userSettings.FilterWords}
+	if src == (filterSource{}) {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if set, ok := c.sets[src]; ok {
+		return set
+	}
+
+	set, errs := textfilter.Compile(strings.Split(src.patterns, ","), strings.Split(src.words, ","))
+	for _, err := range errs {
+		logger.Warn("skipping invalid filter pattern", "pattern", err.Pattern, "err", err.Err)
+	}
+
+	if c.sets == nil || len(c.sets) >= maxCachedFilterSets {
+		c.sets = make(map[filterSource]*textfilter.Set)
+	}
+	c.sets[src] = set
+
+	return set
+}
@@ -0,0 +1,37 @@
+package processor
+
+import (
+	"log/slog"
+	"testing"
+
+	"app/db"
+	"app/pkg/textfilter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalSwearsCompile(t *testing.T) {
+	t.Parallel()
+
+	_, errs := textfilter.Compile(GlobalSwears, nil)
+	require.Empty(t, errs)
+}
+
+func TestFilterSetCache(t *testing.T) {
+	t.Parallel()
+
+	var cache filterSetCache
+	logger := slog.New(slog.DiscardHandler)
+
+	require.Nil(t, cache.get(logger, &db.UserSettings{}))
+
+	settings := &db.UserSettings{Filters: "kurwa,(broken", FilterWords: "matka"}
+	set := cache.get(logger, settings)
+	require.Same(t, set, cache.get(logger, &db.UserSettings{Filters: "kurwa,(broken", FilterWords: "matka"}))
+	require.Len(t, set.Spans("kurwa m4tka"), 2)
+
+	// a saved change is a new key
+	changed := cache.get(logger, &db.UserSettings{Filters: "kurwa"})
+	require.NotSame(t, set, changed)
+	require.Len(t, changed.Spans("kurwa m4tka"), 1)
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"app/db"
 	"app/internal/app/conns"
 	"app/pkg/agentic"
 	"app/pkg/llm"
+	"app/pkg/textfilter"
 
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -18,7 +20,12 @@ import (
 	"github.com/google/uuid"
 )
 
-const MaxAgenticTurns = 20
+// agenticClosingNote is added to the topic of a dialogue's last line.
+const agenticClosingNote = "\nThe conversation is ending now: this is the final line, so wrap it up and say goodbye in character."
+
+// maxInterjectionsPerTurn keeps chat from drowning out the characters; the
+// rest waits for the next turn.
+const maxInterjectionsPerTurn = 3
 
 type AgenticHandler struct {
 	logger   *slog.Logger
@@ -46,12 +53,16 @@ func (h *AgenticHandler) Handle(ctx context.Context, input InteractionInput, eve
 	timer := prometheus.NewTimer(monitoring.AppMetrics.AgenticQueryTime)
 	defer timer.ObserveDuration()
 
-	allChars, err := h.db.GetAllCharacterBasicInfo(ctx)
+	// chat may interject from the redeem on, not just once the first line plays
+	opened := time.Now()
+
+	// only a shortlist of the public catalog fits the detector's prompt
+	candidates, err := h.db.GetCharacterCandidates(ctx, agentic.CandidateTerms(input.Message), agentic.MaxCandidateMatches, agentic.CandidateFill)
 	if err != nil {
-		return fmt.Errorf("failed to get all characters: %w", err)
+		return fmt.Errorf("failed to get character candidates: %w", err)
 	}
 
-	detectedChars, err := h.detector.DetectCharacters(ctx, input.Message, allChars)
+	detectedChars, err := h.detector.DetectCharacters(ctx, input.Message, candidates)
 	if err != nil {
 		logger.Error("failed to detect characters", "err", err)
 		return fmt.Errorf("agentic flow failed: %w", err)
@@ -75,7 +86,7 @@ func (h *AgenticHandler) Handle(ctx context.Context, input InteractionInput, eve
 			logger.Error("failed to prefetch character card", "id", c.ID, "name", c.Name, "err", err)
 			continue
 		}
-		charCards[c.ID] = card
+		charCards[c.ID] = h.service.withMemories(ctx, logger, input.Broadcaster, card, input.UserSettings)
 	}
 
 	charCardsSlice := make([]*db.Card, 0, len(charCards))
@@ -119,11 +130,47 @@ func (h *AgenticHandler) Handle(ctx context.Context, input InteractionInput, eve
 	curCard := firstCard
 	var prevDone <-chan struct{}
 
-	for turn := 0; turn < MaxAgenticTurns; turn++ {
+	maxTurns := db.DefaultAgenticMaxTurns
+	if n := input.UserSettings.AgenticMaxTurns; n != nil && *n > 0 {
+		maxTurns = min(*n, db.MaxAgenticMaxTurns)
+	}
+	audioLimit := time.Duration(db.DefaultAgenticAudioLimit) * time.Second
+	if n := input.UserSettings.AgenticAudioLimit; n != nil {
+		audioLimit = time.Duration(*n) * time.Second
+	}
+
+	// turns play back to back, so the wall clock since the first line is
+	// the dialogue's air time
+	started := time.Now()
+	closing := false
+
+	for turn := 0; turn < maxTurns; turn++ {
 		if input.State.IsSkipped(msgUUID) {
 			return nil
 		}
 
+		// a wrap up requested while the queued line was written replaces it
+		// with a closing one before it plays
+		if prevDone != nil && !closing && input.State.IsWrapUp(msgUUID) {
+			closing = true
+			logger.Info("wrapping up agentic dialogue", "reason", "requested", "turn", turn)
+
+			history = history[:len(history)-1]
+			nextText, nextCard, err := h.prepareNextAgenticTurnText(ctx, logger, input.Message, &history, charNames, charCards, nameToID, input.UserSettings, closing)
+			if err != nil {
+				logger.Error("failed to prepare closing agentic turn", "err", err)
+			} else {
+				curText, curCard = nextText, nextCard
+			}
+			if curCard == nil {
+				select {
+				case <-prevDone:
+				case <-ctx.Done():
+				}
+				return nil
+			}
+		}
+
 		var gate chan struct{}
 		if prevDone == nil {
 			eventWriter(characterImageEvent(curCard.ID))
@@ -140,7 +187,7 @@ func (h *AgenticHandler) Handle(ctx context.Context, input InteractionInput, eve
 			}(prevDone, curCard.ID)
 		}
 
-		done, err := h.service.playTTSStreaming(ctx, logger, eventWriter, input.AudioWriter, curText, msgUUID, curCard.Data.VoiceReference, input.State, input.UserSettings, gate)
+		done, err := h.service.playTTSStreaming(ctx, logger, eventWriter, input.AudioWriter, curText, msgUUID, h.service.cardVoice(logger, curCard.Data), input.State, input.UserSettings, gate)
 		if err != nil {
 			logger.Error("failed to play TTS", "err", err)
 			if prevDone != nil {
@@ -154,8 +201,22 @@ func (h *AgenticHandler) Handle(ctx context.Context, input InteractionInput, eve
 
 		// next turn's LLM call and gated synthesis run while this turn plays
 		curText, curCard = "", nil
-		if turn+1 < MaxAgenticTurns {
-			nextText, nextCard, err := h.prepareNextAgenticTurnText(ctx, input.Message, &history, charNames, charCards, nameToID, input.UserSettings)
+		if !closing && turn+1 < maxTurns {
+			switch {
+			case input.State.IsWrapUp(msgUUID):
+				closing = true
+				logger.Info("wrapping up agentic dialogue", "reason", "requested", "turn", turn)
+			case audioLimit > 0 && time.Since(started) >= audioLimit:
+				closing = true
+				logger.Info("wrapping up agentic dialogue", "reason", "air time budget", "turn", turn, "air_time", time.Since(started))
+			case turn+2 == maxTurns:
+				closing = true
+				logger.Info("wrapping up agentic dialogue", "reason", "turn budget", "turn", turn)
+			}
+
+			h.takeInterjections(ctx, logger, input, msgUUID, opened, &history)
+
+			nextText, nextCard, err := h.prepareNextAgenticTurnText(ctx, logger, input.Message, &history, charNames, charCards, nameToID, input.UserSettings, closing)
 			if err != nil {
 				logger.Error("failed to prepare next agentic turn", "err", err)
 			} else {
@@ -185,33 +246,41 @@ func characterImageEvent(cardID uuid.UUID) *conns.DataEvent {
 }
 
 // prepareNextAgenticTurnText picks the next speaker and generates their
-// filtered line; ("", nil, nil) means the planner ended the dialogue.
+// filtered line; ("", nil, nil) means the planner ended the dialogue. A
+// closing line is written even when the planner would rather end.
 func (h *AgenticHandler) prepareNextAgenticTurnText(
 	ctx context.Context,
+	logger *slog.Logger,
 	scenario string,
 	history *[]llm.Message,
 	charNames []string,
 	charCards map[uuid.UUID]*db.Card,
 	nameToID map[string]uuid.UUID,
 	userSettings *db.UserSettings,
+	closing bool,
 ) (string, *db.Card, error) {
-	nextSpeakerName, err := h.planner.SelectNextSpeaker(ctx, scenario, *history, charNames)
+	next, err := h.planner.SelectNextSpeaker(ctx, scenario, *history, charNames)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to select next speaker: %w", err)
 	}
 
-	if nextSpeakerName == "END" {
+	if next.EndConversation && (!closing || next.NextSpeakerName == "") {
+		logger.Info("planner ended agentic dialogue", "reason", next.Reason)
 		return "", nil, nil
 	}
 
-	nextSpeakerID, ok := nameToID[strings.ToLower(nextSpeakerName)]
+	nextSpeakerID, ok := nameToID[strings.ToLower(next.NextSpeakerName)]
 	if !ok {
-		return "", nil, fmt.Errorf("planner returned unknown next speaker: %s", nextSpeakerName)
+		return "", nil, fmt.Errorf("planner returned unknown next speaker: %s", next.NextSpeakerName)
 	}
 
 	nextCard, ok := charCards[nextSpeakerID]
 	if !ok {
-		return "", nil, fmt.Errorf("character card not found for speaker %s", nextSpeakerName)
+		return "", nil, fmt.Errorf("character card not found for speaker %s", next.NextSpeakerName)
+	}
+
+	if closing {
+		scenario += agenticClosingNote
 	}
 
 	response, err := h.llmModel.DialogueReply(ctx, nextCard, scenario, collectHistoryTurns(*history)...)
@@ -225,6 +294,34 @@ func (h *AgenticHandler) prepareNextAgenticTurnText(
 	return h.service.FilterText(ctx, userSettings, cleanResponse), nextCard, nil
 }
 
+// takeInterjections adds the lines chat sent into the dialogue to history,
+// filtered like a request, so the planner and the next speaker can react.
+func (h *AgenticHandler) takeInterjections(ctx context.Context, logger *slog.Logger, input InteractionInput, msgID uuid.UUID, opened time.Time, history *[]llm.Message) {
+	interjections, err := h.db.TakeAgenticInterjections(ctx, input.Broadcaster.ID, time.Since(opened), maxInterjectionsPerTurn)
+	if err != nil {
+		logger.Error("failed to take interjections", "err", err)
+		return
+	}
+
+	skipLLMFilter := input.SkipLLMFilterFully || input.UserSettings.DisableLLMFilter
+	for _, interjection := range interjections {
+		spans, err := h.service.filterInterjectionSpans(ctx, input.Broadcaster.ID, msgID, input.UserSettings, interjection.Message, skipLLMFilter)
+		if err != nil {
+			logger.Error("failed to filter interjection", "err", err, "viewer", interjection.TwitchLogin)
+			continue
+		}
+
+		logger.Info("viewer interjected", "viewer", interjection.TwitchLogin)
+		appendHistoryTurn(history, interjectionSpeaker(interjection.TwitchLogin), textfilter.Censor(interjection.Message, spans, "(filtered)"))
+	}
+}
+
+// interjectionSpeaker names a viewer's turn in the history so nobody mistakes
+// it for a character's line.
+func interjectionSpeaker(twitchLogin string) string {
+	return "Viewer interjection from " + twitchLogin
+}
+
 func appendHistoryTurn(history *[]llm.Message, speakerName, text string) {
 	if history == nil {
 		return
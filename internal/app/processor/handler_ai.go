@@ -12,6 +12,7 @@ import (
 
 	"app/db"
 	"app/internal/app/conns"
+	"app/pkg/ai"
 	"app/pkg/imagetag"
 	"app/pkg/llm"
 	"app/pkg/s3client"
@@ -51,7 +52,7 @@ func (h *AIHandler) Handle(ctx context.Context, input InteractionInput, eventWri
 	timer := prometheus.NewTimer(monitoring.AppMetrics.AIQueryTime)
 	defer timer.ObserveDuration()
 
-	if input.Character != nil {
+	if input.Character != nil && !input.Replay {
 		if err := h.db.IncrementCharRedeems(ctx, input.Character.ID); err != nil {
 			logger.Warn("failed to increment ai redeems", "err", err)
 		}
@@ -72,11 +73,19 @@ func (h *AIHandler) Handle(ctx context.Context, input InteractionInput, eventWri
 	showImages := false
 	updatedMessage := input.Message
 
+	// replayed holds the stored reply and spans of a replay; nil means the
+	// model is asked as usual
+	var replayed *db.MessageData
+
 	if msg != nil {
 		if msgData, err := db.ParseMessageData(msg.Data); err == nil {
 			imageIDs = msgData.ImageIDs
 			showImages = msgData.ShowImages != nil && *msgData.ShowImages
 			logger.Info("parsed image ids from message data", "ids", imageIDs)
+
+			if input.Replay && msgData.AIResponse != "" {
+				replayed = msgData
+			}
 		}
 	}
 
@@ -93,7 +102,7 @@ func (h *AIHandler) Handle(ctx context.Context, input InteractionInput, eventWri
 
 	var attachments []llm.Attachment
 	imagesDone := make(chan struct{})
-	if len(imageIDs) == 0 || h.s3 == nil || (!h.nativeImages && h.imageLlm == nil) {
+	if replayed != nil || len(imageIDs) == 0 || h.s3 == nil || (!h.nativeImages && h.imageLlm == nil) {
 		close(imagesDone)
 	} else {
 		go func(origMsg string, ids []string) {
@@ -146,25 +155,46 @@ func (h *AIHandler) Handle(ctx context.Context, input InteractionInput, eventWri
 	// up with what the control panel displays; image tags survive censoring
 	// (disjoint spans) and are replaced afterward for speech.
 	requestPrefix := input.Requester + " asked me: "
-	requestText := requestPrefix + input.Message
-	requestSpans, err := h.service.filterSpans(ctx, input.UserSettings, requestText, skipLLMFilter)
-	if err != nil {
-		return fmt.Errorf("failed to filter request: %w", err)
-	}
-	filteredRequestText := imagetag.ReplaceImageTags(textfilter.Censor(requestText, requestSpans, "(filtered)"))
+	var filteredRequestText string
+	var requestFiltered []textfilter.Span
+	switch {
+	case input.Event:
+		// an event rule's prompt is an instruction to the character, not
+		// something a viewer said: it is neither filtered nor read out
+	case replayed != nil:
+		requestFiltered = replayed.RequestFiltered
+		filteredRequestText = imagetag.ReplaceImageTags(h.service.FilterText(ctx, input.UserSettings, requestPrefix) + textfilter.Censor(input.Message, requestFiltered, "(filtered)"))
+	default:
+		requestText := requestPrefix + input.Message
+		requestSpans, err := h.service.filterSpans(ctx, input.Broadcaster.ID, msgID, input.UserSettings, requestText, skipLLMFilter)
+		if err != nil {
+			return fmt.Errorf("failed to filter request: %w", err)
+		}
+		filteredRequestText = imagetag.ReplaceImageTags(textfilter.Censor(requestText, requestSpans, "(filtered)"))
 
-	if requestFiltered := spansAfterPrefix(requestSpans, utf8.RuneCountInString(requestPrefix)); len(requestFiltered) > 0 {
-		h.db.UpdateMessageData(ctx, msgID, &db.MessageData{RequestFiltered: requestFiltered})
-		h.service.connManager.NotifyControlPanel(input.Broadcaster.ID)
+		requestFiltered = spansAfterPrefix(requestSpans, utf8.RuneCountInString(requestPrefix))
+		if len(requestFiltered) > 0 {
+			h.db.UpdateMessageData(ctx, msgID, &db.MessageData{RequestFiltered: requestFiltered})
+			h.service.connManager.NotifyControlPanel(input.Broadcaster.ID)
+		}
 	}
 
 	if input.State.IsSkipped(msgID) {
 		return nil
 	}
 
-	requestTtsDone, err := h.service.playTTSStreaming(ctx, logger, eventWriter, input.AudioWriter, filteredRequestText, msgID, input.Character.Data.VoiceReference, input.State, input.UserSettings, nil)
-	if err != nil {
-		return err
+	voice := h.service.cardVoice(logger, input.Character.Data)
+
+	var requestTtsDone <-chan struct{}
+	if input.Event {
+		done := make(chan struct{})
+		close(done)
+		requestTtsDone = done
+	} else {
+		requestTtsDone, err = h.service.playTTSStreaming(ctx, logger, eventWriter, input.AudioWriter, filteredRequestText, msgID, voice, input.State, input.UserSettings, nil)
+		if err != nil {
+			return err
+		}
 	}
 
 	select {
@@ -177,52 +207,103 @@ func (h *AIHandler) Handle(ctx context.Context, input InteractionInput, eventWri
 		return nil
 	}
 
+	// response synthesis starts as soon as there is text, hidden under
+	// request playback; emission waits for the request track plus a
+	// one-second breather
+	responseGate := make(chan struct{})
 	go func() {
-		defer close(llmResultDone)
-		llmResult, llmResultErr = h.llmModel.CharacterReply(ctx, input.Character, input.Requester, updatedMessage, attachments)
+		defer close(responseGate)
+		select {
+		case <-requestTtsDone:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+		}
 	}()
 
-	select {
-	case <-llmResultDone:
-		if llmResultErr != nil {
-			return llmResultErr
+	// a model that streams is spoken sentence by sentence while it writes,
+	// which needs a voice that takes text piecemeal too
+	streamer, canStream := h.llmModel.(StreamingCharacterLLM)
+	if _, ok := h.service.voiceEngine(voice).(ai.StreamingTTSEngine); !ok {
+		canStream = false
+	}
+	// a skit is parsed as a whole: voice switches and filters span sentences
+	scripted := input.Character.Data.Script != nil
+	if scripted {
+		canStream = false
+	}
+
+	var responseSpans []textfilter.Span
+	var responseTtsDone <-chan struct{}
+	switch {
+	case replayed != nil:
+		llmResult, responseSpans = replayed.AIResponse, replayed.FilteredText
+	case canStream:
+		card := h.service.withMemories(ctx, logger, input.Broadcaster, input.Character, input.UserSettings)
+
+		reply, err := h.streamReply(ctx, logger, streamer, input, eventWriter, msgID, voice, card, updatedMessage, attachments, ttsUserMsg, skipLLMFilter, responseGate)
+		if err != nil {
+			return err
 		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		llmResult, responseSpans = reply.text, reply.spans
 		if len(llmResult) == 0 {
+			// nothing was spoken; the stand-in plays like a regular reply
 			llmResult = "empty response"
+		} else {
+			responseTtsDone = reply.done
 		}
-	case <-ctx.Done():
-		return nil
-	}
-
-	responseSpans, err := h.service.filterReplySpans(ctx, input.UserSettings, ttsUserMsg, llmResult, skipLLMFilter)
-	if err != nil {
-		return fmt.Errorf("failed to filter response: %w", err)
-	}
 
-	h.db.UpdateMessageData(ctx, msgID, &db.MessageData{AIResponse: llmResult, FilteredText: responseSpans})
-	h.service.connManager.NotifyControlPanel(input.Broadcaster.ID)
+		h.db.UpdateMessageData(ctx, msgID, &db.MessageData{AIResponse: llmResult, FilteredText: responseSpans})
+		h.service.connManager.NotifyControlPanel(input.Broadcaster.ID)
+	default:
+		card := withScriptGuide(h.service.withMemories(ctx, logger, input.Broadcaster, input.Character, input.UserSettings))
 
-	filteredResponse := textfilter.Censor(llmResult, responseSpans, "(filtered)")
+		go func() {
+			defer close(llmResultDone)
+			llmResult, llmResultErr = h.llmModel.CharacterReply(ctx, card, input.Requester, updatedMessage, attachments)
+		}()
 
-	// response synthesis starts now, hidden under request playback; emission
-	// waits for the request track plus a one-second breather
-	responseGate := make(chan struct{})
-	go func() {
-		defer close(responseGate)
 		select {
-		case <-requestTtsDone:
+		case <-llmResultDone:
+			if llmResultErr != nil {
+				return llmResultErr
+			}
+			if len(llmResult) == 0 {
+				llmResult = "empty response"
+			}
 		case <-ctx.Done():
-			return
+			return nil
 		}
-		select {
-		case <-time.After(time.Second):
-		case <-ctx.Done():
+
+		responseSpans, err = h.service.filterReplySpans(ctx, input.Broadcaster.ID, msgID, input.UserSettings, ttsUserMsg, llmResult, skipLLMFilter)
+		if err != nil {
+			return fmt.Errorf("failed to filter response: %w", err)
 		}
-	}()
 
-	responseTtsDone, err := h.service.playTTSStreaming(ctx, logger, eventWriter, input.AudioWriter, filteredResponse, msgID, input.Character.Data.VoiceReference, input.State, input.UserSettings, responseGate)
-	if err != nil {
-		return err
+		h.db.UpdateMessageData(ctx, msgID, &db.MessageData{AIResponse: llmResult, FilteredText: responseSpans})
+		h.service.connManager.NotifyControlPanel(input.Broadcaster.ID)
+	}
+
+	filteredResponse := textfilter.Censor(llmResult, responseSpans, "(filtered)")
+
+	if responseTtsDone == nil && scripted {
+		responseTtsDone, err = h.service.playScript(ctx, logger, eventWriter, input.AudioWriter, filteredResponse, msgID, voice, input.State, input.UserSettings, responseGate)
+		if err != nil {
+			return err
+		}
+	}
+	if responseTtsDone == nil {
+		responseTtsDone, err = h.service.playTTSStreaming(ctx, logger, eventWriter, input.AudioWriter, filteredResponse, msgID, voice, input.State, input.UserSettings, responseGate)
+		if err != nil {
+			return err
+		}
 	}
 
 	select {
@@ -231,6 +312,21 @@ func (h *AIHandler) Handle(ctx context.Context, input InteractionInput, eventWri
 		return nil
 	}
 
+	// try-page runs have no queued message and are not part of the stream;
+	// skipped replies stay out since a skip is a mod's verdict on the content,
+	// replays were logged the first time, and an event's request is the
+	// rule's prompt, not anything the viewer said
+	if msg != nil && !input.Replay && !input.Event && !input.State.IsSkipped(msgID) {
+		h.service.logInteraction(ctx, logger, &db.CharInteraction{
+			UserID:         input.Broadcaster.ID,
+			CardID:         input.Character.ID,
+			TwitchUserID:   input.TwitchUserID,
+			RequesterLogin: input.Requester,
+			Request:        imagetag.ReplaceImageTags(textfilter.Censor(input.Message, requestFiltered, "(filtered)")),
+			Reply:          filteredResponse,
+		})
+	}
+
 	eventWriter(cleanEvent())
 
 	eventWriter(&conns.DataEvent{
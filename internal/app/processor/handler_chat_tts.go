@@ -91,13 +91,13 @@ func (h *ChatTTSHandler) Handle(ctx context.Context, input InteractionInput, eve
 		}
 	}
 
-	_, voiceRef, err := h.service.getVoiceReference(ctx, logger, voice)
+	_, chatVoice, err := h.service.getVoiceReference(ctx, logger, voice)
 	if err != nil {
 		logger.Error("failed to get voice reference", "err", err, "voice", voice)
 		return nil
 	}
 
-	requestAudio, textTimings, err := h.service.ChatTTSWithTimings(ctx, filteredRequest, voiceRef)
+	requestAudio, textTimings, err := h.service.ChatTTSWithTimings(ctx, filteredRequest, chatVoice.ref)
 	if err != nil {
 		logger.Error("chat TTS error", "err", err)
 		return nil
@@ -107,7 +107,7 @@ func (h *ChatTTSHandler) Handle(ctx context.Context, input InteractionInput, eve
 		return nil
 	}
 
-	requestTtsDone, err := h.service.playTTS(ctx, logger, eventWriter, input.AudioWriter, filteredRequest, msgID, requestAudio, textTimings, input.State, input.UserSettings)
+	requestTtsDone, err := h.service.playTTS(ctx, logger, eventWriter, input.AudioWriter, filteredRequest, msgID, requestAudio, textTimings, 0, input.State, input.UserSettings)
 	if err != nil {
 		return err
 	}
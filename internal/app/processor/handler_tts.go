@@ -35,7 +35,7 @@ func (h *TTSHandler) Handle(ctx context.Context, input InteractionInput, eventWr
 	timer := prometheus.NewTimer(monitoring.AppMetrics.TTSQueryTime)
 	defer timer.ObserveDuration()
 
-	if input.Character != nil {
+	if input.Character != nil && !input.Replay {
 		if err := h.db.IncrementCharTTSRedeems(ctx, input.Character.ID); err != nil {
 			logger.Warn("failed to increment tts_redeems", "err", err)
 		}
@@ -58,7 +58,7 @@ func (h *TTSHandler) Handle(ctx context.Context, input InteractionInput, eventWr
 		return nil
 	}
 
-	requestTtsDone, err := h.service.playTTSStreaming(ctx, logger, eventWriter, input.AudioWriter, filteredRequest, msgID, input.Character.Data.VoiceReference, input.State, input.UserSettings, nil)
+	requestTtsDone, err := h.service.playTTSStreaming(ctx, logger, eventWriter, input.AudioWriter, filteredRequest, msgID, h.service.cardVoice(logger, input.Character.Data), input.State, input.UserSettings, nil)
 	if err != nil {
 		return err
 	}
@@ -48,7 +48,7 @@ func (h *UniversalHandler) Handle(ctx context.Context, input InteractionInput, e
 	// Filter the raw message (not the image-tag-replaced one) so the spans line
 	// up with what the control panel displays; image tags survive censoring
 	// (disjoint spans) and are replaced afterward for speech.
-	requestSpans, err := h.service.filterSpans(ctx, input.UserSettings, input.Message, skipLLMFilter)
+	requestSpans, err := h.service.filterSpans(ctx, input.Broadcaster.ID, msgID, input.UserSettings, input.Message, skipLLMFilter)
 	if err != nil {
 		return fmt.Errorf("failed to filter request: %w", err)
 	}
@@ -65,30 +65,32 @@ func (h *UniversalHandler) Handle(ctx context.Context, input InteractionInput, e
 		return nil
 	}
 
-	actions, err := h.service.processUniversalTTSMessage(ctx, filteredRequest, input.UserSettings)
+	actions, err := h.service.processUniversalTTSMessage(ctx, filteredRequest, input.UserSettings, "")
 	if err != nil {
 		return err
 	}
 
-	// Increment TTS redeems once per unique referenced voice
-	uniqueVoiceIDs := make(map[uuid.UUID]struct{})
-	for _, action := range actions {
-		if strings.TrimSpace(action.Text) == "" {
-			continue
+	if !input.Replay {
+		// Increment TTS redeems once per unique referenced voice
+		uniqueVoiceIDs := make(map[uuid.UUID]struct{})
+		for _, action := range actions {
+			if strings.TrimSpace(action.Text) == "" {
+				continue
+			}
+			voice := action.Voice
+			if voice == "" {
+				voice = "obiwan"
+			}
+			if voiceID, _, vErr := h.service.getVoiceReference(ctx, logger, voice); vErr == nil {
+				uniqueVoiceIDs[voiceID] = struct{}{}
+			} else {
+				logger.Debug("voice not found for increment", "voice", voice, "err", vErr)
+			}
 		}
-		voice := action.Voice
-		if voice == "" {
-			voice = "obiwan"
-		}
-		if voiceID, _, vErr := h.service.getVoiceReference(ctx, logger, voice); vErr == nil {
-			uniqueVoiceIDs[voiceID] = struct{}{}
-		} else {
-			logger.Debug("voice not found for increment", "voice", voice, "err", vErr)
-		}
-	}
-	for voiceID := range uniqueVoiceIDs {
-		if err := h.db.IncrementCharTTSRedeems(ctx, voiceID); err != nil {
-			logger.Warn("failed to increment universal tts redeems", "voice_id", voiceID, "err", err)
+		for voiceID := range uniqueVoiceIDs {
+			if err := h.db.IncrementCharTTSRedeems(ctx, voiceID); err != nil {
+				logger.Warn("failed to increment universal tts redeems", "voice_id", voiceID, "err", err)
+			}
 		}
 	}
 
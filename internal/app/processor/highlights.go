@@ -0,0 +1,212 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"app/db"
+
+	"github.com/google/uuid"
+)
+
+const (
+	saveTrackTimeout = time.Minute
+
+	// silence between the request and reply tracks of an exported clip
+	clipTrackGap = 500 * time.Millisecond
+
+	subtitleLineWords = 8
+	subtitleLineGap   = time.Second
+	subtitleHighlight = "#ffcc00"
+)
+
+// saveTrack keeps a played track for highlight export. Only queued messages
+// are kept: try-page runs have no msg_queue row and are dropped here. It
+// runs detached, so a skip that cancels playback still saves what played.
+func (s *Service) saveTrack(logger *slog.Logger, msgID, trackID uuid.UUID, text string, words []trackWord, duration time.Duration, mp3Chunks ...[]byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), saveTrackTimeout)
+	defer cancel()
+
+	msg, err := s.db.GetMessageByID(ctx, msgID)
+	if err != nil {
+		if db.ErrCode(err) != db.ErrCodeNoRows {
+			logger.Error("failed to get message for track", "err", err)
+		}
+		return
+	}
+
+	mp3, err := s.ffmpeg.ConcatenateAudio(ctx, 0, mp3Chunks...)
+	if err != nil {
+		logger.Error("failed to join track chunks", "err", err)
+		return
+	}
+
+	dbWords := make([]db.TrackWord, len(words))
+	for i, w := range words {
+		dbWords[i] = db.TrackWord{W: w.W, S: w.S, E: w.E}
+	}
+
+	err = s.db.SaveMsgTrack(ctx, &db.MsgTrack{
+		ID:       trackID,
+		MsgID:    msgID,
+		UserID:   msg.UserID,
+		Text:     text,
+		Words:    dbWords,
+		Duration: duration,
+	}, mp3)
+	if err != nil {
+		logger.Error("failed to save track", "err", err)
+	}
+}
+
+// loadClip joins the message's tracks into one mp3 and one word timeline.
+func (s *Service) loadClip(ctx context.Context, msgID uuid.UUID) ([]byte, []db.TrackWord, error) {
+	tracks, err := s.db.GetMsgTracks(ctx, msgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tracks) == 0 {
+		return nil, nil, errors.New("no audio was kept for this message")
+	}
+
+	audios := make([][]byte, len(tracks))
+	durations := make([]time.Duration, len(tracks))
+	for i, track := range tracks {
+		audios[i], err = s.db.GetMsgTrackAudio(ctx, track)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the encoded length, not the played one, is what the subtitles
+		// have to line up with
+		durations[i], err = s.getAudioLength(ctx, audios[i])
+		if err != nil {
+			durations[i] = track.Duration
+		}
+	}
+
+	audio, err := s.ffmpeg.ConcatenateAudio(ctx, clipTrackGap, audios...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to join tracks: %w", err)
+	}
+
+	return audio, clipWords(tracks, durations), nil
+}
+
+// clipWords shifts each track's words onto the joined clip's timeline.
+func clipWords(tracks []*db.MsgTrack, durations []time.Duration) []db.TrackWord {
+	var words []db.TrackWord
+	var offset time.Duration
+	for i, track := range tracks {
+		for _, w := range track.Words {
+			words = append(words, db.TrackWord{W: w.W, S: w.S + offset.Milliseconds(), E: w.E + offset.Milliseconds()})
+		}
+		offset += durations[i] + clipTrackGap
+	}
+	return words
+}
+
+// subtitleLines breaks words into lines at sentence ends, pauses and every
+// subtitleLineWords words.
+func subtitleLines(words []db.TrackWord) [][]db.TrackWord {
+	var lines [][]db.TrackWord
+	var line []db.TrackWord
+	for i, w := range words {
+		if len(line) > 0 && time.Duration(w.S-words[i-1].E)*time.Millisecond >= subtitleLineGap {
+			lines = append(lines, line)
+			line = nil
+		}
+
+		line = append(line, w)
+
+		if len(line) >= subtitleLineWords || endsSentence(w.W) {
+			lines = append(lines, line)
+			line = nil
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func endsSentence(word string) bool {
+	word = strings.TrimRight(word, `"')`)
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "!") || strings.HasSuffix(word, "?")
+}
+
+func srtTime(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// buildSRT renders karaoke subtitles: each line is one cue per word, with
+// the word being said highlighted, held until the next word starts.
+func buildSRT(words []db.TrackWord) []byte {
+	var sb strings.Builder
+	n := 0
+	for _, line := range subtitleLines(words) {
+		for i, w := range line {
+			end := w.E
+			if i+1 < len(line) {
+				end = line[i+1].S
+			}
+			if end <= w.S {
+				continue
+			}
+
+			text := make([]string, len(line))
+			for j, lw := range line {
+				text[j] = lw.W
+				if j == i {
+					text[j] = fmt.Sprintf(`<font color="%s">%s</font>`, subtitleHighlight, lw.W)
+				}
+			}
+
+			n++
+			fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", n, srtTime(w.S), srtTime(end), strings.Join(text, " "))
+		}
+	}
+	return []byte(sb.String())
+}
+
+// ExportMP3 returns the message's request and reply audio as one mp3, with
+// karaoke subtitles as an SRT sidecar.
+func (s *Service) ExportMP3(ctx context.Context, msgID uuid.UUID) ([]byte, []byte, error) {
+	audio, words, err := s.loadClip(ctx, msgID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return audio, buildSRT(words), nil
+}
+
+// ExportMP4 renders the message as a video: the character's image (black
+// when there is none) under the burned-in karaoke subtitles.
+func (s *Service) ExportMP4(ctx context.Context, msgID uuid.UUID, cardID *uuid.UUID) ([]byte, error) {
+	audio, words, err := s.loadClip(ctx, msgID)
+	if err != nil {
+		return nil, err
+	}
+
+	var image []byte
+	if cardID != nil {
+		image, err = s.db.GetCharImage(ctx, *cardID)
+		if err != nil && db.ErrCode(err) != db.ErrCodeNoRows {
+			return nil, err
+		}
+	}
+
+	video, err := s.ffmpeg.RenderClip(ctx, audio, image, buildSRT(words))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render clip: %w", err)
+	}
+
+	return video, nil
+}
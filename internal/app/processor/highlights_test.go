@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"app/db"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClipWords(t *testing.T) {
+	t.Parallel()
+
+	tracks := []*db.MsgTrack{
+		{Words: []db.TrackWord{{W: "hi", S: 0, E: 400}, {W: "chat", S: 400, E: 900}}},
+		{Words: []db.TrackWord{{W: "hello", S: 100, E: 600}}},
+	}
+
+	words := clipWords(tracks, []time.Duration{time.Second, time.Second})
+	require.Equal(t, []db.TrackWord{
+		{W: "hi", S: 0, E: 400},
+		{W: "chat", S: 400, E: 900},
+		{W: "hello", S: 1600, E: 2100},
+	}, words)
+}
+
+func TestSubtitleLines(t *testing.T) {
+	t.Parallel()
+
+	words := []db.TrackWord{
+		{W: "Hello", S: 0, E: 300},
+		{W: "chat.", S: 300, E: 600},
+		{W: "Welcome", S: 700, E: 1000},
+		{W: "back", S: 1000, E: 1200},
+		// a long pause starts a new line mid-sentence
+		{W: "everyone", S: 2500, E: 3000},
+	}
+
+	lines := subtitleLines(words)
+	require.Len(t, lines, 3)
+	require.Len(t, lines[0], 2)
+	require.Len(t, lines[1], 2)
+	require.Len(t, lines[2], 1)
+
+	var long []db.TrackWord
+	for i := range 20 {
+		long = append(long, db.TrackWord{W: "word", S: int64(i * 100), E: int64(i*100 + 100)})
+	}
+	for _, line := range subtitleLines(long) {
+		require.LessOrEqual(t, len(line), subtitleLineWords)
+	}
+}
+
+func TestBuildSRT(t *testing.T) {
+	t.Parallel()
+
+	srt := string(buildSRT([]db.TrackWord{
+		{W: "Hello", S: 0, E: 300},
+		{W: "chat!", S: 400, E: 61250},
+	}))
+
+	cues := strings.Split(strings.TrimSpace(srt), "\n\n")
+	require.Len(t, cues, 2)
+
+	// a word holds until the next one starts
+	require.Equal(t, "1\n00:00:00,000 --> 00:00:00,400\n"+`<font color="#ffcc00">Hello</font> chat!`, cues[0])
+	require.Equal(t, "2\n00:00:00,400 --> 00:01:01,250\n"+`Hello <font color="#ffcc00">chat!</font>`, cues[1])
+}
@@ -6,6 +6,7 @@ import (
 	"app/db"
 	"app/internal/app/conns"
 	"app/pkg/llm"
+	"app/pkg/memdistill"
 	"app/pkg/whisperx"
 )
 
@@ -30,6 +31,14 @@ type InteractionInput struct {
 
 	SkipLLMFilterFully bool
 
+	// Replay marks a re-run of an already processed message: redeem counters
+	// and the interaction log stay untouched, and the AI reply is reused.
+	Replay bool
+
+	// Event marks a reaction to a follow, sub, gift or raid: Message is the
+	// rule's rendered prompt, which is sent to the model but not spoken.
+	Event bool
+
 	State *ProcessorState
 }
 
@@ -46,6 +55,30 @@ type CharacterLLM interface {
 	DialogueReply(ctx context.Context, card *db.Card, scenario string, history ...string) (string, error)
 }
 
+// StreamingCharacterLLM is implemented by models that can hand out a reply
+// while it generates; fn gets each text delta in order, and an error from fn
+// stops the generation.
+type StreamingCharacterLLM interface {
+	CharacterReplyStream(ctx context.Context, card *db.Card, requester, message string, images []llm.Attachment, fn func(delta string) error) (string, error)
+}
+
 type TTSClient interface {
 	TTS(ctx context.Context, text string, refAudio []byte) ([]byte, []whisperx.Timiing, error)
 }
+
+// MemoryDistiller proposes at most one memory for a finished exchange; ""
+// means nothing worth remembering.
+type MemoryDistiller interface {
+	Distill(ctx context.Context, ex *memdistill.Exchange) (string, error)
+}
+
+// RedemptionSettler fulfills or refunds the channel point redemption behind a
+// message.
+type RedemptionSettler interface {
+	FindRedemption(user *db.User, rewardID string, twitchUserID int, input string, claimed map[string]bool) (string, error)
+	UpdateRedemptionStatus(user *db.User, rewardID, redemptionID, status string) error
+}
+
+type Tokenizer interface {
+	Tokenize(ctx context.Context, text string) (int, error)
+}
@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"app/db"
+	"app/pkg/twitch"
+)
+
+// viewerLimitReason reports why the viewer behind msg may not have it played:
+// a ban or timeout from the AI, or a full redeem limit for the reward type.
+// Empty means it plays, and a limited redeem has been counted.
+func (p *Processor) viewerLimitReason(ctx context.Context, broadcaster *db.User, msg *db.Message, userSettings *db.UserSettings) (string, error) {
+	twitchUserID := msg.TwitchMessage.TwitchUserID
+	if twitchUserID == 0 {
+		return "", nil
+	}
+
+	ban, err := p.db.GetViewerBan(ctx, broadcaster.ID, twitchUserID)
+	if err == nil {
+		return ban.Reason(), nil
+	}
+	if db.ErrCode(err) != db.ErrCodeNoRows {
+		return "", err
+	}
+
+	if msg.TwitchMessage.Event != nil || len(msg.TwitchMessage.RewardID) == 0 {
+		return "", nil
+	}
+
+	_, rewardType, err := p.db.GetRewardByTwitchReward(ctx, msg.TwitchMessage.RewardID)
+	if err != nil {
+		if db.ErrCode(err) == db.ErrCodeNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+
+	limit := userSettings.RedeemLimits[rewardType]
+	if !limit.Enabled() {
+		return "", nil
+	}
+
+	taken, err := p.db.TakeViewerRedeem(ctx, broadcaster.ID, twitchUserID, rewardType, limit)
+	if err != nil {
+		return "", err
+	}
+	if !taken {
+		return fmt.Sprintf("%s is over %d %s redeems per %s", msg.TwitchMessage.TwitchLogin, limit.Count, rewardType, formatWindow(limit.Window())), nil
+	}
+
+	return "", nil
+}
+
+// formatWindow prints whole hours and minutes as "1h" and "30m" rather
+// than time.Duration's "1h0m0s".
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
+
+// autoSkip drops a message the processor refused to play, the same way a mod
+// skip does, and keeps the reason for the control panel.
+func (p *Processor) autoSkip(ctx context.Context, logger *slog.Logger, broadcaster *db.User, state *ProcessorState, msg *db.Message, reason string) {
+	logger.Info("auto-skipped message", "reason", reason)
+
+	// keeps processLoop from fulfilling it; the overlay never saw it
+	state.AddSkipped(msg.ID)
+
+	if err := p.db.UpdateMessageData(ctx, msg.ID, &db.MessageData{SkipReason: reason}); err != nil {
+		logger.Error("error saving skip reason", "err", err)
+	}
+	if err := p.db.UpdateMessageStatus(ctx, msg.ID, db.MsgStatusDeleted); err != nil {
+		logger.Error("error updating message status", "err", err)
+	}
+	p.connManager.NotifyControlPanel(broadcaster.ID)
+
+	go p.settleRedemption(logger, broadcaster.ID, msg.ID, twitch.RedemptionCanceled)
+}
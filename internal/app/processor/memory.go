@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+
+	"app/db"
+
+	"github.com/google/uuid"
+)
+
+// withMemories returns a copy of card carrying what the character remembers on
+// the broadcaster's channel. The text is regex-filtered again on every
+// injection: a memory written before a word got blocklisted must not smuggle
+// it back into TTS. A failed lookup only costs the reply its memories.
+func (s *Service) withMemories(ctx context.Context, logger *slog.Logger, broadcaster *db.User, card *db.Card, userSettings *db.UserSettings) *db.Card {
+	if card == nil || card.ID == uuid.Nil || broadcaster == nil {
+		return card
+	}
+
+	mems, err := s.db.GetActiveMemories(ctx, broadcaster.ID, card.ID, !userSettings.DisableGlobalMemories)
+	if err != nil {
+		logger.Warn("failed to get character memories", "card_id", card.ID, "err", err)
+		return card
+	}
+	if len(mems) == 0 {
+		return card
+	}
+
+	withMem := *card
+	withMem.Memories = make([]string, 0, len(mems))
+	for _, mem := range mems {
+		withMem.Memories = append(withMem.Memories, s.FilterText(ctx, userSettings, mem.Text))
+	}
+
+	return &withMem
+}
+
+// logInteraction appends a finished exchange to the interaction log the
+// suggestion job reads, unless the requester opted out of memories.
+func (s *Service) logInteraction(ctx context.Context, logger *slog.Logger, interaction *db.CharInteraction) {
+	if interaction.TwitchUserID != 0 {
+		optOut, err := s.db.IsChatUserMemoryOptOut(ctx, interaction.TwitchUserID)
+		if err != nil {
+			logger.Warn("failed to check memory opt out", "err", err)
+			return
+		}
+		if optOut {
+			return
+		}
+	}
+
+	if err := s.db.InsertCharInteraction(ctx, interaction); err != nil {
+		logger.Warn("failed to log interaction", "err", err)
+	}
+}
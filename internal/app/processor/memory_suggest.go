@@ -0,0 +1,89 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"app/db"
+	"app/pkg/memdistill"
+)
+
+// memorySuggestBatch bounds one run of the suggestion job; a backlog of old
+// interactions drains over several runs instead of one long burst of calls.
+const memorySuggestBatch = 200
+
+// MemorySuggester is the offline half of memory capture: it walks the
+// interaction log and leaves suggestions for a mod to approve on the memories
+// page. Nothing it writes is injected until approved.
+type MemorySuggester struct {
+	logger    *slog.Logger
+	db        *db.DB
+	distiller MemoryDistiller
+	tokenizer Tokenizer
+}
+
+func NewMemorySuggester(logger *slog.Logger, db *db.DB, distiller MemoryDistiller, tokenizer Tokenizer) *MemorySuggester {
+	return &MemorySuggester{
+		logger:    logger,
+		db:        db,
+		distiller: distiller,
+		tokenizer: tokenizer,
+	}
+}
+
+// SuggestMemories distills the oldest pending interactions. An LLM or
+// tokenizer failure stops the run and leaves the interaction pending, so it
+// is retried next time instead of being silently dropped.
+func (m *MemorySuggester) SuggestMemories(ctx context.Context) error {
+	interactions, err := m.db.GetUndistilledInteractions(ctx, memorySuggestBatch)
+	if err != nil {
+		return err
+	}
+
+	suggested := 0
+	for _, interaction := range interactions {
+		text, err := m.distiller.Distill(ctx, &memdistill.Exchange{
+			CharName:  interaction.CardName,
+			Requester: interaction.RequesterLogin,
+			Request:   interaction.Request,
+			Reply:     interaction.Reply,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to distill interaction %s: %w", interaction.ID, err)
+		}
+
+		if text == "" {
+			if err := m.db.MarkInteractionDistilled(ctx, interaction.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tokens, err := m.tokenizer.Tokenize(ctx, text)
+		if err != nil {
+			return fmt.Errorf("failed to count memory tokens: %w", err)
+		}
+
+		mem := &db.CharMemory{
+			UserID:     &interaction.UserID,
+			CardID:     &interaction.CardID,
+			Text:       text,
+			TokenCount: tokens,
+		}
+		if interaction.TwitchUserID != 0 {
+			mem.SubjectTwitchUserID = &interaction.TwitchUserID
+		}
+
+		if err := m.db.InsertSuggestedMemory(ctx, interaction.ID, mem); err != nil {
+			return err
+		}
+		suggested++
+	}
+
+	if len(interactions) != 0 {
+		m.logger.Info("distilled interactions", "interactions", len(interactions), "suggested", suggested)
+	}
+
+	return nil
+}
@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"app/db"
 	"app/internal/app/conns"
 	"app/internal/app/monitoring"
+	"app/pkg/twitch"
 
 	"github.com/google/uuid"
 )
@@ -37,9 +39,11 @@ type Processor struct {
 	universalHandler InteractionHandler
 	agenticHandler   InteractionHandler
 	chatTTSHandler   InteractionHandler
+
+	redemptions RedemptionSettler
 }
 
-func NewProcessor(logger *slog.Logger, db *db.DB, connManager *conns.Manager, aiHandler InteractionHandler, ttsHandler InteractionHandler, universalHandler InteractionHandler, agenticHandler InteractionHandler, chatTTSHandler InteractionHandler) *Processor {
+func NewProcessor(logger *slog.Logger, db *db.DB, connManager *conns.Manager, aiHandler InteractionHandler, ttsHandler InteractionHandler, universalHandler InteractionHandler, agenticHandler InteractionHandler, chatTTSHandler InteractionHandler, redemptions RedemptionSettler) *Processor {
 	return &Processor{
 		logger:           logger,
 		db:               db,
@@ -49,6 +53,7 @@ func NewProcessor(logger *slog.Logger, db *db.DB, connManager *conns.Manager, ai
 		universalHandler: universalHandler,
 		agenticHandler:   agenticHandler,
 		chatTTSHandler:   chatTTSHandler,
+		redemptions:      redemptions,
 	}
 }
 
@@ -61,6 +66,11 @@ type ProcessorState struct {
 
 	shownImages     map[uuid.UUID]struct{}
 	shownImagesLock sync.Mutex
+
+	wrapUpMsgIDs     map[uuid.UUID]struct{}
+	wrapUpMsgIDsLock sync.Mutex
+
+	paused atomic.Bool
 }
 
 func NewProcessorState() *ProcessorState {
@@ -68,6 +78,7 @@ func NewProcessorState() *ProcessorState {
 		skippedMsgIDs: make(map[uuid.UUID]struct{}),
 		currentMsgID:  uuid.Nil,
 		shownImages:   make(map[uuid.UUID]struct{}),
+		wrapUpMsgIDs:  make(map[uuid.UUID]struct{}),
 	}
 }
 
@@ -84,6 +95,28 @@ func (s *ProcessorState) IsSkipped(id uuid.UUID) bool {
 	return ok
 }
 
+// AddWrapUp asks the dialogue of a message to end with a closing line.
+func (s *ProcessorState) AddWrapUp(id uuid.UUID) {
+	s.wrapUpMsgIDsLock.Lock()
+	defer s.wrapUpMsgIDsLock.Unlock()
+	s.wrapUpMsgIDs[id] = struct{}{}
+}
+
+func (s *ProcessorState) IsWrapUp(id uuid.UUID) bool {
+	s.wrapUpMsgIDsLock.Lock()
+	defer s.wrapUpMsgIDsLock.Unlock()
+	_, ok := s.wrapUpMsgIDs[id]
+	return ok
+}
+
+// ClearWrapUp forgets a wrap up request once its message finished or was
+// skipped.
+func (s *ProcessorState) ClearWrapUp(id uuid.UUID) {
+	s.wrapUpMsgIDsLock.Lock()
+	defer s.wrapUpMsgIDsLock.Unlock()
+	delete(s.wrapUpMsgIDs, id)
+}
+
 // SkippedList snapshots the skip set for a freshly connected overlay.
 func (s *ProcessorState) SkippedList() []string {
 	s.skippedMsgIDsLock.Lock()
@@ -131,6 +164,16 @@ func (s *ProcessorState) CurrentID() string {
 	return s.currentMsgID.String()
 }
 
+// SetPaused holds the queue: the message already playing finishes, nothing
+// new starts until resumed.
+func (s *ProcessorState) SetPaused(paused bool) {
+	s.paused.Store(paused)
+}
+
+func (s *ProcessorState) IsPaused() bool {
+	return s.paused.Load()
+}
+
 func (s *ProcessorState) SetCurrent(id uuid.UUID) {
 	s.currentMsgIDLock.Lock()
 	defer s.currentMsgIDLock.Unlock()
@@ -163,6 +206,12 @@ func (p *Processor) Process(ctx context.Context, updates chan *conns.Update, eve
 	})
 
 	state := NewProcessorState()
+	if settings, err := p.db.GetUserSettings(ctx, broadcaster.ID); err != nil {
+		logger.Warn("failed to get user settings, starting unpaused", "err", err)
+	} else {
+		state.SetPaused(settings.QueuePaused)
+	}
+
 	p.connManager.RegisterOverlayState(broadcaster.ID, state)
 	defer p.connManager.UnregisterOverlayState(broadcaster.ID)
 
@@ -194,6 +243,15 @@ func (p *Processor) processLoop(ctx context.Context, eventWriter conns.EventWrit
 			p.connManager.NotifyControlPanel(broadcaster.ID)
 		}
 
+		if state.IsPaused() {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
+				continue
+			}
+		}
+
 		msg, err := p.db.GetNextMsg(ctx, broadcaster.ID)
 		if err != nil {
 			if errors.Is(err, db.ErrNoRows) {
@@ -208,10 +266,21 @@ func (p *Processor) processLoop(ctx context.Context, eventWriter conns.EventWrit
 			return fmt.Errorf("error getting next message from db: %w", err)
 		}
 
-		if err := p.processNextMessage(ctx, eventWriter, broadcaster, state, msg); err != nil {
+		err = p.processNextMessage(ctx, eventWriter, broadcaster, state, msg)
+		state.ClearWrapUp(msg.ID)
+		if err != nil {
 			logger.Error("error processing message", "msg_id", msg.ID, "err", err)
+			if ctx.Err() == nil {
+				go p.settleRedemption(logger, broadcaster.ID, msg.ID, twitch.RedemptionCanceled)
+			}
 			continue
 		}
+
+		// skips were refunded when they happened; an aborted run is left
+		// unsettled rather than guessed at
+		if ctx.Err() == nil && !state.IsSkipped(msg.ID) {
+			go p.settleRedemption(logger, broadcaster.ID, msg.ID, twitch.RedemptionFulfilled)
+		}
 	}
 }
 
@@ -224,6 +293,22 @@ func (p *Processor) processNextMessage(ctx context.Context, eventWriter conns.Ev
 		userSettings = &db.UserSettings{}
 	}
 
+	replay := false
+	if msgData, err := db.ParseMessageData(msg.Data); err == nil {
+		replay = msgData.ReplayOf != ""
+	}
+
+	// a replay is the mods' call, not the viewer's
+	if !replay {
+		reason, err := p.viewerLimitReason(ctx, broadcaster, msg, userSettings)
+		if err != nil {
+			logger.Warn("failed to check viewer limits, letting the message through", "err", err)
+		} else if reason != "" {
+			p.autoSkip(ctx, logger, broadcaster, state, msg, reason)
+			return nil
+		}
+	}
+
 	if err := p.db.UpdateMessageStatus(ctx, msg.ID, db.MsgStatusCurrent); err != nil {
 		return fmt.Errorf("error updating message status: %w", err)
 	}
@@ -233,6 +318,10 @@ func (p *Processor) processNextMessage(ctx context.Context, eventWriter conns.Ev
 
 	p.connManager.NotifyControlPanel(broadcaster.ID)
 
+	if msg.TwitchMessage.Event != nil {
+		return p.processEvent(ctx, logger, eventWriter, broadcaster, state, msg, userSettings, replay)
+	}
+
 	if len(msg.TwitchMessage.RewardID) == 0 {
 		if !userSettings.IngestAllMessages {
 			if _, err := p.db.SkipWaitingNoRewardMessages(ctx, broadcaster.ID); err != nil {
@@ -262,6 +351,7 @@ func (p *Processor) processNextMessage(ctx context.Context, eventWriter conns.Ev
 			MsgID:        msg.ID.String(),
 			State:        state,
 			AudioWriter:  p.overlayAudioWriter(broadcaster.ID),
+			Replay:       replay,
 		}
 		if err := p.chatTTSHandler.Handle(ctx, input, eventWriter); err != nil {
 			recordHandlerError(ctx, "chat_tts")
@@ -286,14 +376,16 @@ func (p *Processor) processNextMessage(ctx context.Context, eventWriter conns.Ev
 		p.connManager.NotifyControlPanel(broadcaster.ID)
 	}
 
-	// Track reward usage per chatter
-	if msg.TwitchMessage.TwitchUserID != 0 {
-		if err := p.db.IncrementChatUserRewardCount(ctx, msg.TwitchMessage.TwitchUserID, msg.TwitchMessage.TwitchLogin); err != nil {
-			logger.Warn("failed to increment reward count", "err", err)
+	// Track reward usage per chatter; a replay is not a new redeem
+	if !replay {
+		if msg.TwitchMessage.TwitchUserID != 0 {
+			if err := p.db.IncrementChatUserRewardCount(ctx, msg.TwitchMessage.TwitchUserID, msg.TwitchMessage.TwitchLogin); err != nil {
+				logger.Warn("failed to increment reward count", "err", err)
+			}
 		}
-	}
 
-	monitoring.AppMetrics.RewardRedeems.WithLabelValues(broadcaster.TwitchLogin, rewardType.String()).Inc()
+		monitoring.AppMetrics.RewardRedeems.WithLabelValues(broadcaster.TwitchLogin, rewardType.String()).Inc()
+	}
 
 	var charCard *db.Card
 	if cardID != nil {
@@ -314,6 +406,7 @@ func (p *Processor) processNextMessage(ctx context.Context, eventWriter conns.Ev
 		MsgID:        msg.ID.String(),
 		State:        state,
 		AudioWriter:  p.overlayAudioWriter(broadcaster.ID),
+		Replay:       replay,
 	}
 
 	switch rewardType {
@@ -360,11 +453,13 @@ func (p *Processor) handleControlSignals(ctx context.Context, updates chan *conn
 	// Helpers for control actions
 	skipMessage := func(msgID uuid.UUID) {
 		state.AddSkipped(msgID)
+		state.ClearWrapUp(msgID)
 		eventWriter(skipEvent(msgID, state.GetCurrent() == msgID))
 		if err := p.db.UpdateMessageStatus(ctx, msgID, db.MsgStatusDeleted); err != nil {
 			logger.Error("error updating message status", "err", err)
 		}
 		p.connManager.NotifyControlPanel(broadcaster.ID)
+		go p.settleRedemption(logger, broadcaster.ID, msgID, twitch.RedemptionCanceled)
 	}
 
 	updateImageState := func(msgID uuid.UUID, show bool) {
@@ -404,6 +499,18 @@ func (p *Processor) handleControlSignals(ctx context.Context, updates chan *conn
 				}
 				skipMessage(msgID)
 
+			case conns.WrapUpMessage:
+				msgID, err := uuid.Parse(upd.Data)
+				if err != nil {
+					logger.Error("msg id is not valid uuid", "err", err)
+					continue
+				}
+				// only a running dialogue can wrap up; anything else would
+				// linger in the set
+				if state.GetCurrent() == msgID {
+					state.AddWrapUp(msgID)
+				}
+
 			case conns.ShowImages:
 				msgID, err := uuid.Parse(upd.Data)
 				if err != nil {
@@ -420,6 +527,12 @@ func (p *Processor) handleControlSignals(ctx context.Context, updates chan *conn
 				}
 				updateImageState(msgID, false)
 
+			case conns.PauseQueue:
+				state.SetPaused(true)
+
+			case conns.ResumeQueue:
+				state.SetPaused(false)
+
 			case conns.CleanOverlay:
 				eventWriter(cleanEvent())
 				eventWriter(&conns.DataEvent{
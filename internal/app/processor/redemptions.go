@@ -0,0 +1,101 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"app/db"
+	"app/pkg/textfilter"
+	"app/pkg/twitch"
+
+	"github.com/google/uuid"
+)
+
+// settleRedemption fulfills or cancels (refunds) the redemption behind a
+// message, once. A fulfilled request the filter censored down to nothing is
+// refunded instead: the viewer got nothing for their points. Runs detached
+// from the overlay connection, so a skip right before a disconnect still
+// refunds.
+func (p *Processor) settleRedemption(logger *slog.Logger, userID, msgID uuid.UUID, status string) {
+	if p.redemptions == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	logger = logger.With("msg_id", msgID, "status", status)
+
+	msg, err := p.db.GetMessageByID(ctx, msgID)
+	if err != nil {
+		logger.Warn("failed to get message for redemption", "err", err)
+		return
+	}
+	if len(msg.TwitchMessage.RewardID) == 0 {
+		return
+	}
+
+	data, err := db.ParseMessageData(msg.Data)
+	if err != nil {
+		logger.Warn("failed to parse message data for redemption", "err", err)
+		return
+	}
+	if data.ReplayOf != "" || data.RedemptionStatus != "" {
+		return
+	}
+
+	if _, _, err := p.db.GetRewardByTwitchReward(ctx, msg.TwitchMessage.RewardID); err != nil {
+		if db.ErrCode(err) != db.ErrCodeNoRows {
+			logger.Warn("failed to get reward for redemption", "err", err)
+		}
+		return
+	}
+
+	if status == twitch.RedemptionFulfilled && requestWiped(msg.TwitchMessage.Message, data.RequestFiltered) {
+		status = twitch.RedemptionCanceled
+	}
+
+	user, err := p.db.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Warn("failed to get user for redemption", "err", err)
+		return
+	}
+
+	// ingest captures the id in the background and may not have made it
+	redemptionID := data.RedemptionID
+	if redemptionID == "" {
+		claimed, err := p.db.GetClaimedRedemptionIDs(ctx, userID)
+		if err != nil {
+			logger.Warn("failed to get claimed redemptions", "err", err)
+			return
+		}
+		redemptionID, err = p.redemptions.FindRedemption(user, msg.TwitchMessage.RewardID, msg.TwitchMessage.TwitchUserID, msg.TwitchMessage.Message, claimed)
+		if err != nil {
+			logger.Warn("failed to find redemption", "err", err)
+			return
+		}
+		if redemptionID == "" {
+			logger.Info("redemption not found")
+			return
+		}
+	}
+
+	if err := p.redemptions.UpdateRedemptionStatus(user, msg.TwitchMessage.RewardID, redemptionID, status); err != nil {
+		logger.Warn("failed to update redemption status", "err", err)
+		return
+	}
+
+	if err := p.db.UpdateMessageData(ctx, msgID, &db.MessageData{RedemptionID: redemptionID, RedemptionStatus: status}); err != nil {
+		logger.Warn("failed to store redemption status", "err", err)
+	}
+}
+
+// requestWiped reports whether the filter left nothing of the request.
+func requestWiped(message string, spans []textfilter.Span) bool {
+	if len(spans) == 0 {
+		return false
+	}
+	return strings.TrimSpace(textfilter.Censor(message, spans, "")) == ""
+}
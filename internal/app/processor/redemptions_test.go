@@ -0,0 +1,28 @@
+package processor
+
+import (
+	"testing"
+
+	"app/pkg/textfilter"
+)
+
+func TestRequestWiped(t *testing.T) {
+	cases := []struct {
+		name    string
+		message string
+		spans   []textfilter.Span
+		want    bool
+	}{
+		{"no spans", "hello there", nil, false},
+		{"partly filtered", "hello SLUR", []textfilter.Span{{Start: 6, End: 10}}, false},
+		{"fully filtered", "SLUR", []textfilter.Span{{Start: 0, End: 4}}, true},
+		{"only whitespace left", " SLUR  SLUR ", []textfilter.Span{{Start: 1, End: 5}, {Start: 7, End: 11}}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := requestWiped(tc.message, tc.spans); got != tc.want {
+				t.Fatalf("requestWiped(%q) = %v, want %v", tc.message, got, tc.want)
+			}
+		})
+	}
+}
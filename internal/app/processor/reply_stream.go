@@ -0,0 +1,306 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"app/db"
+	"app/internal/app/conns"
+	"app/pkg/llm"
+	"app/pkg/llmfilter"
+	"app/pkg/textfilter"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// shorter pieces wait for the next sentence: both the filter and the
+	// voice do better with some context than with a lone "Yes."
+	minReplyPieceRunes = 24
+
+	// pieces queued between the model, the filter and the voice; a reply
+	// never gets near it, so nothing upstream ever waits on a slow stage
+	replyPieceQueue = 64
+)
+
+var errReplySkipped = errors.New("message skipped")
+
+// sentenceSplitter cuts streamed text into pieces of whole sentences. A piece
+// keeps the whitespace that ended it, so the pieces joined are the reply;
+// whitespace between pieces beyond that is dropped.
+type sentenceSplitter struct {
+	buf []rune
+}
+
+// Write adds a delta and returns the pieces it completed.
+func (sp *sentenceSplitter) Write(delta string) []string {
+	var pieces []string
+	for _, r := range delta {
+		if len(sp.buf) == 0 && unicode.IsSpace(r) {
+			continue
+		}
+		if unicode.IsSpace(r) && len(sp.buf) >= minReplyPieceRunes && (r == '\n' || endsWithSentence(sp.buf)) {
+			pieces = append(pieces, string(sp.buf)+string(r))
+			sp.buf = sp.buf[:0]
+			continue
+		}
+		sp.buf = append(sp.buf, r)
+	}
+	return pieces
+}
+
+// Flush returns what is left once the stream is over.
+func (sp *sentenceSplitter) Flush() string {
+	rest := string(sp.buf)
+	sp.buf = sp.buf[:0]
+	return rest
+}
+
+func endsWithSentence(buf []rune) bool {
+	i := len(buf) - 1
+	// closing quotes, brackets and stage-direction asterisks after the stop
+	for i > 0 && strings.ContainsRune(`"')]*”’`, buf[i]) {
+		i--
+	}
+	return i >= 0 && strings.ContainsRune(".!?…", buf[i])
+}
+
+// shiftSpans moves spans over a piece onto the whole reply.
+func shiftSpans(spans []textfilter.Span, by int) []textfilter.Span {
+	out := make([]textfilter.Span, len(spans))
+	for i, span := range spans {
+		out[i] = textfilter.Span{Start: span.Start + by, End: span.End + by}
+	}
+	return out
+}
+
+// clipSpans cuts spans off at n runes, for a reply that lost its trailing
+// whitespace.
+func clipSpans(spans []textfilter.Span, n int) []textfilter.Span {
+	var out []textfilter.Span
+	for _, span := range spans {
+		if span.Start >= n {
+			continue
+		}
+		out = append(out, textfilter.Span{Start: span.Start, End: min(span.End, n)})
+	}
+	return out
+}
+
+// runeOffsets maps each rune index of said, and its end, onto reply. said is
+// the pieces as spoken, which is reply less some whitespace the splitter
+// dropped between pieces; ok is false when it is not.
+func runeOffsets(said, reply string) ([]int, bool) {
+	from, to := []rune(said), []rune(reply)
+	offsets := make([]int, 0, len(from)+1)
+	j := 0
+	for _, r := range from {
+		for j < len(to) && to[j] != r && unicode.IsSpace(to[j]) {
+			j++
+		}
+		if j == len(to) || to[j] != r {
+			return nil, false
+		}
+		offsets = append(offsets, j)
+		j++
+	}
+	return append(offsets, j), true
+}
+
+// mapSpans moves spans over said onto reply with offsets from runeOffsets.
+func mapSpans(spans []textfilter.Span, offsets []int) []textfilter.Span {
+	out := make([]textfilter.Span, len(spans))
+	for i, span := range spans {
+		out[i] = textfilter.Span{Start: offsets[span.Start], End: offsets[span.End-1] + 1}
+	}
+	return out
+}
+
+// streamedReply is a reply that was spoken while it generated.
+type streamedReply struct {
+	text  string
+	spans []textfilter.Span
+
+	// done closes when the response track is over.
+	done <-chan struct{}
+}
+
+// streamReply speaks the reply while the model is still writing it: each
+// finished sentence is filtered, with the reply so far as context, and
+// handed to the response track, which plays once gate opens. It returns when
+// the reply is complete; the track may still be playing. A skip stops the
+// generation, and so does a track that ended early at the TTS limit.
+func (h *AIHandler) streamReply(ctx context.Context, logger *slog.Logger, model StreamingCharacterLLM, input InteractionInput, eventWriter conns.EventWriter, msgID uuid.UUID, voice ttsVoice, card *db.Card, message string, attachments []llm.Attachment, prompt string, skipLLMFilter bool, gate <-chan struct{}) (*streamedReply, error) {
+	genCtx, cancelGen := context.WithCancel(ctx)
+	defer cancelGen()
+
+	pieces := make(chan string, replyPieceQueue)
+	genErrCh := make(chan error, 1)
+	// the model's own cleaned reply, set before genErrCh is sent on
+	var cleaned string
+
+	go func() {
+		defer close(pieces)
+
+		send := func(piece string) error {
+			select {
+			case pieces <- piece:
+				return nil
+			case <-genCtx.Done():
+				return genCtx.Err()
+			}
+		}
+
+		var splitter sentenceSplitter
+		out, err := model.CharacterReplyStream(genCtx, card, input.Requester, message, attachments, func(delta string) error {
+			if input.State.IsSkipped(msgID) {
+				return errReplySkipped
+			}
+			for _, piece := range splitter.Write(delta) {
+				if err := send(piece); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			cleaned = out
+			if rest := splitter.Flush(); strings.TrimSpace(rest) != "" {
+				err = send(rest)
+			}
+		}
+		genErrCh <- err
+	}()
+
+	segments := make(chan string, replyPieceQueue)
+	trackDone, err := h.service.playTTSSegments(ctx, logger, eventWriter, input.AudioWriter, segments, msgID, voice, input.State, input.UserSettings, gate)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply strings.Builder
+	replyRunes := 0
+	var spans, regexSpans []textfilter.Span
+	var decision *llmfilter.Decision
+	if !skipLLMFilter {
+		decision = &llmfilter.Decision{}
+	}
+
+	skipTick := time.NewTicker(100 * time.Millisecond)
+	defer skipTick.Stop()
+
+	// nil once seen, so the loop doesn't spin on it
+	trackEnded := trackDone
+	stopped := false
+	var filterErr error
+
+receive:
+	for {
+		select {
+		case piece, ok := <-pieces:
+			if !ok {
+				break receive
+			}
+
+			pieceRegex, pieceDecision, err := h.service.replyPieceSpans(genCtx, input.UserSettings, prompt, reply.String(), piece, skipLLMFilter)
+			if err != nil {
+				// a filter cut short by a skip is not a failure
+				if genCtx.Err() == nil {
+					filterErr = fmt.Errorf("failed to filter response: %w", err)
+				}
+				break receive
+			}
+
+			pieceSpans := pieceRegex
+			regexSpans = append(regexSpans, shiftSpans(pieceRegex, replyRunes)...)
+			if pieceDecision != nil {
+				pieceSpans = textfilter.Merge(pieceRegex, pieceDecision.Spans())
+				decision.Builtin = append(decision.Builtin, shiftSpans(pieceDecision.Builtin, replyRunes)...)
+				decision.Streamer = append(decision.Streamer, shiftSpans(pieceDecision.Streamer, replyRunes)...)
+			}
+			spans = append(spans, shiftSpans(pieceSpans, replyRunes)...)
+
+			reply.WriteString(piece)
+			replyRunes += utf8.RuneCountInString(piece)
+
+			select {
+			case segments <- textfilter.Censor(piece, pieceSpans, "(filtered)"):
+			case <-genCtx.Done():
+			}
+		case <-skipTick.C:
+			if input.State.IsSkipped(msgID) {
+				stopped = true
+				cancelGen()
+			}
+		case <-trackEnded:
+			// the rest of the reply would never be heard
+			trackEnded = nil
+			stopped = true
+			cancelGen()
+		}
+	}
+
+	close(segments)
+	if filterErr != nil {
+		stopped = true
+		cancelGen()
+	}
+
+	genErr := <-genErrCh
+	if errors.Is(genErr, errReplySkipped) || ctx.Err() != nil {
+		stopped = true
+	}
+
+	if filterErr != nil || (genErr != nil && !stopped && reply.Len() == 0) {
+		// nothing unfiltered was queued; let what was play out before failing
+		select {
+		case <-trackDone:
+		case <-ctx.Done():
+		}
+		if filterErr != nil {
+			return nil, filterErr
+		}
+		return nil, genErr
+	}
+	if genErr != nil && !stopped {
+		logger.Warn("reply generation failed midway, keeping what was said", "err", genErr)
+	}
+
+	text := strings.TrimRightFunc(reply.String(), unicode.IsSpace)
+	spans = clipSpans(textfilter.Merge(spans), utf8.RuneCountInString(text))
+	regexSpans = clipSpans(textfilter.Merge(regexSpans), utf8.RuneCountInString(text))
+	if decision != nil {
+		decision.Builtin = clipSpans(decision.Builtin, utf8.RuneCountInString(text))
+		decision.Streamer = clipSpans(decision.Streamer, utf8.RuneCountInString(text))
+	}
+
+	// a complete reply is stored as the model returned it, with the spans
+	// moved over; a cut one is what was said
+	if genErr == nil && !stopped && cleaned != "" && cleaned != text {
+		if offsets, ok := runeOffsets(text, cleaned); ok {
+			text = cleaned
+			spans, regexSpans = mapSpans(spans, offsets), mapSpans(regexSpans, offsets)
+			if decision != nil {
+				decision.Builtin, decision.Streamer = mapSpans(decision.Builtin, offsets), mapSpans(decision.Streamer, offsets)
+			}
+		} else {
+			logger.Warn("streamed reply differs from the returned one, keeping what was said")
+		}
+	}
+
+	if text != "" {
+		run := &db.FilterRun{UserID: input.Broadcaster.ID, MsgID: msgID, Kind: db.FilterRunReply, Target: text, Context: prompt}
+		if decision != nil {
+			run.Custom = input.UserSettings.CustomFilterPrompt
+		}
+		h.service.recordFilterRun(ctx, run, regexSpans, decision)
+	}
+
+	return &streamedReply{text: text, spans: spans, done: trackDone}, nil
+}
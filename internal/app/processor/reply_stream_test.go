@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"app/db"
+	"app/internal/app/conns"
+	"app/pkg/textfilter"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSentenceSplitter(t *testing.T) {
+	t.Parallel()
+
+	reply := "Oh, you want to know about that? Fine. I will tell you everything!\n\nIt started on a \"dark night.\" Nobody came"
+
+	var sp sentenceSplitter
+	var pieces []string
+	// token-sized deltas, cut mid-word
+	for i := 0; i < len(reply); i += 3 {
+		pieces = append(pieces, sp.Write(reply[i:min(i+3, len(reply))])...)
+	}
+	pieces = append(pieces, sp.Flush())
+
+	require.Equal(t, []string{
+		"Oh, you want to know about that? ",
+		// too short to stand alone, so it rides with the next sentence
+		"Fine. I will tell you everything!\n",
+		"It started on a \"dark night.\" ",
+		"Nobody came",
+	}, pieces)
+}
+
+func TestClipSpans(t *testing.T) {
+	t.Parallel()
+
+	spans := []textfilter.Span{{Start: 0, End: 2}, {Start: 4, End: 8}, {Start: 9, End: 10}}
+	require.Equal(t, []textfilter.Span{{Start: 0, End: 2}, {Start: 4, End: 6}}, clipSpans(spans, 6))
+}
+
+func TestRuneOffsets(t *testing.T) {
+	t.Parallel()
+
+	// the pieces of a reasoning model's reply, as the splitter hands them
+	// over, against the reply the model returns
+	cleaned := "Oh, you want to know? Fine. I will tell you everything!\nIt started on a dark night."
+	var sp sentenceSplitter
+	var said strings.Builder
+	for _, delta := range []string{"Oh, you want", " to know? Fine. I will tell", " you everything!\n", "It started on a dark night."} {
+		for _, piece := range sp.Write(delta) {
+			said.WriteString(piece)
+		}
+	}
+	said.WriteString(sp.Flush())
+	text := strings.TrimRightFunc(said.String(), unicode.IsSpace)
+
+	offsets, ok := runeOffsets(text, cleaned)
+	require.True(t, ok)
+
+	start := strings.Index(text, "dark")
+	spans := mapSpans([]textfilter.Span{{Start: start, End: start + 4}}, offsets)
+	require.Equal(t, "dark", string([]rune(cleaned)[spans[0].Start:spans[0].End]))
+
+	_, ok = runeOffsets("Hello chat.", "<think>hmm</think>Hello chat.")
+	require.False(t, ok, "only whitespace may differ")
+}
+
+func TestPlayTTSSegmentsOneTrack(t *testing.T) {
+	engine := &fakeStreamEngine{chunks: streamChunks(2, 80*time.Millisecond)}
+	s := newGateTestService(t, engine)
+
+	log := &frameLog{}
+	var metas int
+	events := conns.EventWriter(func(e *conns.DataEvent) bool {
+		if e.EventType == conns.EventTypeTrackMeta {
+			metas++
+		}
+		return true
+	})
+
+	segments := make(chan string)
+	done, err := s.playTTSSegments(context.Background(), s.logger, events, log.writer(),
+		segments, uuid.New(), ttsVoice{}, NewProcessorState(), &db.UserSettings{}, nil)
+	require.NoError(t, err)
+
+	// the second segment arrives while the first is already playing
+	segments <- "hello world hello world"
+	time.Sleep(50 * time.Millisecond)
+	segments <- strings.Repeat("hello world ", 2)
+	close(segments)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("track did not finish")
+	}
+	// 2 segments of 2 chunk frames, then one track_done
+	require.Equal(t, 5, log.count())
+	require.Equal(t, 1, metas)
+}
@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"strings"
+
+	"app/db"
+	"app/internal/app/conns"
+	"app/pkg/ai"
+	"app/pkg/ffmpeg"
+
+	"github.com/google/uuid"
+)
+
+// ScriptNarrator is the voice name a scripted reply switches back to the
+// character's own voice with.
+const ScriptNarrator = "narrator"
+
+// sfxNames lists the embedded sound effects, sorted.
+func sfxNames() []string {
+	entries, err := fs.ReadDir(embeddedSFX, "sfx")
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+	}
+
+	return names
+}
+
+// scriptGuide tells the model how to write its reply as a skit in universal
+// TTS syntax. It only depends on the card, so the prompt cache holds.
+func scriptGuide(script *db.CardScript) string {
+	var b strings.Builder
+	b.WriteString("Your reply is performed as a short skit by several text-to-speech voices. Lines without a voice are read in your own voice.")
+	b.WriteString("\nTo hand the following lines to another voice, write its name and a colon, like \"forsen: I win again.\" Write \"" + ScriptNarrator + ":\" to take the lines back yourself.")
+	if len(script.Cast) != 0 {
+		fmt.Fprintf(&b, "\nVoices you can use: %s.", strings.Join(script.Cast, ", "))
+	}
+
+	b.WriteString("\nTo change how lines sound, open a filter with its name or number in curly braces and close the last one with {.}, like \"{sad}I lost.{.}\". Filters stack.")
+	fmt.Fprintf(&b, "\nEmotions: %s.", strings.Join(ai.EmotionNames, ", "))
+	filters := make([]string, 0, int(ffmpeg.FilterLast)-1)
+	for t := ffmpeg.FilterType(1); t < ffmpeg.FilterLast; t++ {
+		filters = append(filters, fmt.Sprintf("%d %s", t, t.Name()))
+	}
+	fmt.Fprintf(&b, "\nAudio filters: %s.", strings.Join(filters, ", "))
+
+	if sfx := sfxNames(); len(sfx) != 0 {
+		fmt.Fprintf(&b, "\nTo play a sound effect, write its name in square brackets, like \"[%s]\". Sound effects: %s.", sfx[0], strings.Join(sfx, ", "))
+	}
+
+	b.WriteString("\nWrite only the skit: no stage directions, no markdown and no other brackets.")
+
+	return b.String()
+}
+
+// withScriptGuide returns a copy of card carrying the script guide when the
+// character performs its replies as skits.
+func withScriptGuide(card *db.Card) *db.Card {
+	if card == nil || card.Data == nil || card.Data.Script == nil {
+		return card
+	}
+
+	scripted := *card
+	scripted.ScriptGuide = scriptGuide(card.Data.Script)
+
+	return &scripted
+}
+
+// playScript performs a scripted reply with narrator reading the lines that
+// name no voice. Synthesis runs right away; playback waits for gate, as in
+// playTTSBatchFromText.
+func (s *Service) playScript(ctx context.Context, logger *slog.Logger, eventWriter conns.EventWriter, audioWriter conns.AudioWriter, script string, msgID uuid.UUID, narrator ttsVoice, state *ProcessorState, userSettings *db.UserSettings, gate <-chan struct{}) (<-chan struct{}, error) {
+	actions, err := s.processUniversalTTSMessage(ctx, script, userSettings, ScriptNarrator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse script: %w", err)
+	}
+
+	audio, text, timings, err := s.craftUniversalTTSAudio(ctx, logger, actions, &narrator, userSettings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform script: %w", err)
+	}
+
+	if gate != nil {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			done := make(chan struct{})
+			close(done)
+			return done, nil
+		}
+	}
+
+	if state.IsSkipped(msgID) {
+		done := make(chan struct{})
+		close(done)
+		return done, nil
+	}
+
+	// each voice's gain is already in its part of the mix
+	return s.playTTS(ctx, logger, eventWriter, audioWriter, text, msgID, audio, timings, 0, state, userSettings)
+}
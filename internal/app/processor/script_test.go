@@ -0,0 +1,54 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"app/db"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptGuide(t *testing.T) {
+	guide := scriptGuide(&db.CardScript{Cast: []string{"forsen", "obiwan"}})
+
+	for _, want := range []string{
+		"Voices you can use: forsen, obiwan.",
+		"\"" + ScriptNarrator + ":\"",
+		"Emotions: happy,",
+		"[placeholder]",
+	} {
+		require.Contains(t, guide, want)
+	}
+
+	require.NotContains(t, scriptGuide(&db.CardScript{}), "Voices you can use")
+}
+
+func TestWithScriptGuide(t *testing.T) {
+	card := &db.Card{Data: &db.CardData{Name: "Forsen"}}
+	require.Same(t, card, withScriptGuide(card))
+
+	card.Data.Script = &db.CardScript{}
+	scripted := withScriptGuide(card)
+	require.NotEmpty(t, scripted.ScriptGuide)
+	require.Empty(t, card.ScriptGuide, "the card itself must stay untouched")
+}
+
+func TestProcessUniversalTTSMessageNarrator(t *testing.T) {
+	s := &Service{}
+	maxSfx := 1
+
+	actions, err := s.processUniversalTTSMessage(context.Background(), "Narrator: hi {sad}there{.} [placeholder] [placeholder]", &db.UserSettings{MaxSfxCount: &maxSfx}, ScriptNarrator)
+	require.NoError(t, err)
+
+	var sfx int
+	for _, action := range actions {
+		require.Empty(t, action.Voice, "narrator lines must fall back to the speaker's own voice")
+		if action.Sfx != "" {
+			sfx++
+		}
+	}
+	require.Equal(t, 1, sfx, "the sfx count limit must still apply")
+	require.Equal(t, " hi ", actions[0].Text)
+	require.Equal(t, []string{"sad"}, actions[1].Filters)
+}
@@ -20,14 +20,17 @@ type Service struct {
 	ffmpeg        *ffmpeg.Client
 	ttsEngine     ai.TTSEngine
 	chatTTSEngine ai.TTSEngine
+	ttsEngines    *ai.TTSRegistry
 	whisper       *whisperx.Client
 	llmModelRaw   *llm.Client
 	imageLlmRaw   *llm.Client
 	llmFilter     *llmfilter.Filter
 	connManager   *conns.Manager
+
+	filterSets filterSetCache
 }
 
-func NewService(logger *slog.Logger, db *db.DB, s3 *s3client.Client, ffmpeg *ffmpeg.Client, ttsEngine ai.TTSEngine, chatTTSEngine ai.TTSEngine, whisper *whisperx.Client, llmModel *llm.Client, imageLlm *llm.Client, llmFilter *llmfilter.Filter, connManager *conns.Manager) *Service {
+func NewService(logger *slog.Logger, db *db.DB, s3 *s3client.Client, ffmpeg *ffmpeg.Client, ttsEngine ai.TTSEngine, chatTTSEngine ai.TTSEngine, ttsEngines *ai.TTSRegistry, whisper *whisperx.Client, llmModel *llm.Client, imageLlm *llm.Client, llmFilter *llmfilter.Filter, connManager *conns.Manager) *Service {
 	return &Service{
 		logger:        logger,
 		db:            db,
@@ -35,6 +38,7 @@ func NewService(logger *slog.Logger, db *db.DB, s3 *s3client.Client, ffmpeg *ffm
 		ffmpeg:        ffmpeg,
 		ttsEngine:     ttsEngine,
 		chatTTSEngine: chatTTSEngine,
+		ttsEngines:    ttsEngines,
 		whisper:       whisper,
 		llmModelRaw:   llmModel,
 		imageLlmRaw:   imageLlm,
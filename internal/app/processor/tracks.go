@@ -223,10 +223,30 @@ func (s *Service) alignChunkWords(ctx context.Context, logger *slog.Logger, text
 // closed, while synthesis and chunk processing run immediately — the way to
 // queue a track behind one that is still playing without dead air between
 // them. Callers must close the gate, never send on it.
-func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eventWriter conns.EventWriter, audioWriter conns.AudioWriter, msg string, msgID uuid.UUID, voiceRef []byte, state *ProcessorState, userSettings *db.UserSettings, gate <-chan struct{}) (<-chan struct{}, error) {
-	streamer, ok := s.ttsEngine.(ai.StreamingTTSEngine)
+func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eventWriter conns.EventWriter, audioWriter conns.AudioWriter, msg string, msgID uuid.UUID, voice ttsVoice, state *ProcessorState, userSettings *db.UserSettings, gate <-chan struct{}) (<-chan struct{}, error) {
+	segments := make(chan string, 1)
+	segments <- msg
+	close(segments)
+
+	return s.playTTSSegments(ctx, logger, eventWriter, audioWriter, segments, msgID, voice, state, userSettings, gate)
+}
+
+// segmentChunk is a streamed chunk tagged with the segment it belongs to;
+// first marks where the segment's own stream timeline starts.
+type segmentChunk struct {
+	ai.StreamChunk
+	segment string
+	first   bool
+}
+
+// playTTSSegments is playTTSStreaming over text that is still arriving: each
+// segment is synthesized as soon as it is received, and all of them play as
+// one track. The caller closes segments when the text is complete.
+func (s *Service) playTTSSegments(ctx context.Context, logger *slog.Logger, eventWriter conns.EventWriter, audioWriter conns.AudioWriter, segments <-chan string, msgID uuid.UUID, voice ttsVoice, state *ProcessorState, userSettings *db.UserSettings, gate <-chan struct{}) (<-chan struct{}, error) {
+	streamer, ok := s.voiceEngine(voice).(ai.StreamingTTSEngine)
 	if !ok {
-		return s.playTTSBatchFromText(ctx, logger, eventWriter, audioWriter, msg, msgID, voiceRef, state, userSettings, gate)
+		msg := collectSegments(ctx, nil, segments)
+		return s.playTTSBatchFromText(ctx, logger, eventWriter, audioWriter, msg, msgID, voice, state, userSettings, gate)
 	}
 
 	ttsLimit := db.DefaultTtsLimitSeconds
@@ -235,23 +255,47 @@ func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eve
 	}
 	maxDur := time.Duration(ttsLimit) * time.Second
 
-	ttsText := stripForTTS(msg)
-
 	streamCtx, cancelStream := context.WithCancel(ctx)
 
-	chunkCh := make(chan ai.StreamChunk, 8)
+	chunkCh := make(chan segmentChunk, 8)
 	errCh := make(chan error, 1)
 
+	// every segment taken for synthesis; read only after errCh delivers
+	var consumed []string
+
 	go func() {
 		defer close(chunkCh)
-		errCh <- streamer.TTSStream(streamCtx, ttsText, voiceRef, func(c ai.StreamChunk) error {
+
+		var err error
+		for err == nil {
+			var segment string
+			var ok bool
 			select {
-			case chunkCh <- c:
-				return nil
+			case segment, ok = <-segments:
 			case <-streamCtx.Done():
-				return streamCtx.Err()
+				err = streamCtx.Err()
+				continue
+			}
+			if !ok {
+				break
 			}
-		})
+			if strings.TrimSpace(segment) == "" {
+				continue
+			}
+			consumed = append(consumed, segment)
+
+			first := true
+			err = streamer.TTSStream(streamCtx, stripForTTS(segment), voice.ref, func(c ai.StreamChunk) error {
+				select {
+				case chunkCh <- segmentChunk{StreamChunk: c, segment: segment, first: first}:
+					first = false
+					return nil
+				case <-streamCtx.Done():
+					return streamCtx.Err()
+				}
+			})
+		}
+		errCh <- err
 	}()
 
 	done := make(chan struct{})
@@ -267,18 +311,26 @@ func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eve
 		seq := 0
 		emitted := false
 
+		// track offset where the current segment's stream started
+		var segmentStart time.Duration
+
 		type readyChunk struct {
-			header *chunkHeader
-			mp3    []byte
+			header  *chunkHeader
+			mp3     []byte
+			segment string
 		}
 		var pending []readyChunk
 
+		// everything emitted, kept for highlight export once the track ends
+		var playedMp3 [][]byte
+		var playedWords []trackWord
+
 		// nil channel is never selected: nil gate means emit immediately
 		gateCh := gate
 
 		emit := func(c readyChunk) {
 			if !emitted {
-				eventWriter(trackMetaEvent(msgID, trackID, msg))
+				eventWriter(trackMetaEvent(msgID, trackID, c.segment))
 				playStart = time.Now()
 				emitted = true
 			}
@@ -290,6 +342,9 @@ func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eve
 				playStart = playStart.Add(now.Sub(scheduled))
 			}
 			audioWriter(chunkFrame(c.header, c.mp3))
+
+			playedMp3 = append(playedMp3, c.mp3)
+			playedWords = append(playedWords, c.header.Words...)
 		}
 
 		// loudness is measured once on the first chunk and reused for the whole
@@ -299,7 +354,23 @@ func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eve
 		loudnessMeasured := false
 
 		// returns false when the track must stop consuming (error or TTS limit)
-		process := func(chunk ai.StreamChunk) bool {
+		process := func(chunk segmentChunk) bool {
+			if chunk.first {
+				segmentStart = offset
+			}
+
+			if !voice.tuning.IsZero() {
+				tuned, err := s.ffmpeg.TuneVoice(ctx, chunk.Audio, voice.tuning)
+				if err != nil {
+					logger.Error("failed to tune chunk, ending track early", "err", err)
+					return false
+				}
+				speed := voice.tuning.SpeedFactor()
+				chunk.Audio = tuned
+				chunk.SpeechStart = time.Duration(float64(chunk.SpeechStart) / speed)
+				chunk.SpeechEnd = time.Duration(float64(chunk.SpeechEnd) / speed)
+			}
+
 			chunkDur, okDur := wavDuration(chunk.Audio)
 			if !okDur {
 				var err error
@@ -323,7 +394,7 @@ func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eve
 			var mp3 []byte
 			var err error
 			if loudness != nil {
-				mp3, err = s.ffmpeg.Ffmpeg2Mp3Normalized(ctx, chunk.Audio, loudness)
+				mp3, err = s.ffmpeg.Ffmpeg2Mp3Normalized(ctx, chunk.Audio, loudness, voice.tuning.Gain)
 			} else {
 				mp3, err = s.ffmpeg.Ffmpeg2Mp3(ctx, chunk.Audio, userSettings.DisableAudioNormalization)
 			}
@@ -332,9 +403,11 @@ func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eve
 				return false
 			}
 
-			// speech bounds arrive stream-absolute from the engine; make them
-			// chunk-local for the interpolation fallback
-			words := s.alignChunkWords(ctx, logger, chunk.Text, chunk.Audio, chunkDur, chunk.SpeechStart-offset, chunk.SpeechEnd-offset)
+			// speech bounds arrive stream-absolute from the engine, and each
+			// segment is its own stream; make them chunk-local for the
+			// interpolation fallback
+			local := offset - segmentStart
+			words := s.alignChunkWords(ctx, logger, chunk.Text, chunk.Audio, chunkDur, chunk.SpeechStart-local, chunk.SpeechEnd-local)
 			for i := range words {
 				words[i].S += offset.Milliseconds()
 				words[i].E += offset.Milliseconds()
@@ -350,7 +423,8 @@ func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eve
 					Text:     chunk.Text,
 					Words:    words,
 				},
-				mp3: mp3,
+				mp3:     mp3,
+				segment: chunk.segment,
 			}
 
 			if gateCh == nil {
@@ -421,8 +495,12 @@ func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eve
 			if ctx.Err() != nil || state.IsSkipped(msgID) {
 				return
 			}
+			msg := collectSegments(ctx, consumed, segments)
+			if strings.TrimSpace(msg) == "" {
+				return
+			}
 			logger.Warn("stream produced no chunks, falling back to batch TTS", "err", streamErr)
-			fallbackDone, err := s.playTTSBatchFromText(ctx, logger, eventWriter, audioWriter, msg, msgID, voiceRef, state, userSettings, gate)
+			fallbackDone, err := s.playTTSBatchFromText(ctx, logger, eventWriter, audioWriter, msg, msgID, voice, state, userSettings, gate)
 			if err != nil {
 				logger.Error("batch fallback failed", "err", err)
 				return
@@ -441,6 +519,8 @@ func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eve
 
 		audioWriter(trackDoneFrame(msgID, trackID, offset))
 
+		go s.saveTrack(logger, msgID, trackID, strings.Join(consumed, " "), playedWords, offset, playedMp3...)
+
 		ticker := time.NewTicker(100 * time.Millisecond)
 		defer ticker.Stop()
 
@@ -463,10 +543,28 @@ func (s *Service) playTTSStreaming(ctx context.Context, logger *slog.Logger, eve
 	return done, nil
 }
 
+// collectSegments joins the segments already taken with the rest of the
+// channel, waiting for the caller to close it.
+func collectSegments(ctx context.Context, taken []string, segments <-chan string) string {
+	for {
+		select {
+		case segment, ok := <-segments:
+			if !ok {
+				return strings.Join(taken, " ")
+			}
+			if strings.TrimSpace(segment) != "" {
+				taken = append(taken, segment)
+			}
+		case <-ctx.Done():
+			return strings.Join(taken, " ")
+		}
+	}
+}
+
 // playTTSBatchFromText is the streaming path's fallback: synthesize whole,
 // then play through the regular batch pipeline once the gate (if any) opens.
-func (s *Service) playTTSBatchFromText(ctx context.Context, logger *slog.Logger, eventWriter conns.EventWriter, audioWriter conns.AudioWriter, msg string, msgID uuid.UUID, voiceRef []byte, state *ProcessorState, userSettings *db.UserSettings, gate <-chan struct{}) (<-chan struct{}, error) {
-	audio, timings, err := s.TTSWithTimings(ctx, msg, voiceRef)
+func (s *Service) playTTSBatchFromText(ctx context.Context, logger *slog.Logger, eventWriter conns.EventWriter, audioWriter conns.AudioWriter, msg string, msgID uuid.UUID, voice ttsVoice, state *ProcessorState, userSettings *db.UserSettings, gate <-chan struct{}) (<-chan struct{}, error) {
+	audio, timings, err := s.ttsWithTimings(ctx, msg, voice)
 	if err != nil {
 		return nil, fmt.Errorf("batch synthesis failed: %w", err)
 	}
@@ -487,5 +585,5 @@ func (s *Service) playTTSBatchFromText(ctx context.Context, logger *slog.Logger,
 		return done, nil
 	}
 
-	return s.playTTS(ctx, logger, eventWriter, audioWriter, msg, msgID, audio, timings, state, userSettings)
+	return s.playTTS(ctx, logger, eventWriter, audioWriter, msg, msgID, audio, timings, voice.tuning.Gain, state, userSettings)
 }
@@ -61,10 +61,89 @@ func stripForTTS(msg string) string {
 	return strings.ReplaceAll(msg, "*", "")
 }
 
-func (s *Service) TTSWithTimings(ctx context.Context, msg string, refAudio []byte) ([]byte, []whisperx.Timiing, error) {
+// ttsVoice is what a character speaks with: its reference audio, the engine
+// tuned for it and how its speech is reshaped afterwards. A nil engine is the
+// default one.
+type ttsVoice struct {
+	ref    []byte
+	engine ai.TTSEngine
+	tuning ffmpeg.VoiceTuning
+}
+
+// cardVoice resolves the voice of a card. A card whose engine is no longer
+// configured speaks with the default engine rather than not at all.
+func (s *Service) cardVoice(logger *slog.Logger, data *db.CardData) ttsVoice {
+	voice := ttsVoice{ref: data.VoiceReference}
+	if data.TTS != nil {
+		voice.tuning = ffmpeg.VoiceTuning{Speed: data.TTS.Speed, Pitch: data.TTS.Pitch, Gain: data.TTS.Gain}
+	}
+	if s.ttsEngines == nil {
+		return voice
+	}
+
+	var name string
+	var params ai.TTSParams
+	if data.TTS != nil {
+		name = data.TTS.Engine
+		params = cardTTSParams(data.TTS)
+	}
+
+	engine, err := s.ttsEngines.Engine(name, params)
+	if err != nil {
+		logger.Warn("card tts engine unavailable, using the default", "err", err, "engine", name)
+		return voice
+	}
+	voice.engine = engine
+
+	return voice
+}
+
+func cardTTSParams(t *db.CardTTS) ai.TTSParams {
+	return ai.TTSParams{
+		EmoWeight:                t.EmoWeight,
+		MaxTextTokensPerSentence: t.MaxTextTokensPerSentence,
+		EmoVector:                t.EmoVector,
+		Alpha:                    t.Alpha,
+		Beta:                     t.Beta,
+		Voice:                    t.Voice,
+	}
+}
+
+func (s *Service) voiceEngine(voice ttsVoice) ai.TTSEngine {
+	if voice.engine != nil {
+		return voice.engine
+	}
+	return s.ttsEngine
+}
+
+// TTSEngineNames lists the engines a card can pick.
+func (s *Service) TTSEngineNames() []string {
+	if s.ttsEngines == nil {
+		return nil
+	}
+	return s.ttsEngines.Names()
+}
+
+// HasTTSEngine reports whether a card can pick the named engine.
+func (s *Service) HasTTSEngine(name string) bool {
+	return s.ttsEngines != nil && s.ttsEngines.Has(name)
+}
+
+// TTSWithTimings speaks msg as the card does: its reference, its engine and
+// its voice tuning.
+func (s *Service) TTSWithTimings(ctx context.Context, msg string, card *db.CardData) ([]byte, []whisperx.Timiing, error) {
+	return s.ttsWithTimings(ctx, msg, s.cardVoice(s.logger, card))
+}
+
+func (s *Service) ttsWithTimings(ctx context.Context, msg string, voice ttsVoice) ([]byte, []whisperx.Timiing, error) {
 	text := stripForTTS(msg)
 
-	ttsResult, ttsSegments, err := s.ttsEngine.TTS(ctx, text, refAudio)
+	ttsResult, ttsSegments, err := s.voiceEngine(voice).TTS(ctx, text, voice.ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ttsResult, ttsSegments, err = s.tuneSpeech(ctx, voice.tuning, ttsResult, ttsSegments)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -82,6 +161,36 @@ func (s *Service) TTSWithTimings(ctx context.Context, msg string, refAudio []byt
 	return ttsResult, ttsSegments, nil
 }
 
+// tuneSpeech reshapes synthesized speech with the voice tuning, moving the
+// engine timings along with a changed tempo.
+func (s *Service) tuneSpeech(ctx context.Context, tuning ffmpeg.VoiceTuning, audio []byte, timings []whisperx.Timiing) ([]byte, []whisperx.Timiing, error) {
+	if tuning.IsZero() {
+		return audio, timings, nil
+	}
+
+	tuned, err := s.ffmpeg.TuneVoice(ctx, audio, tuning)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tuned, scaleTimings(timings, tuning.SpeedFactor()), nil
+}
+
+func scaleTimings(timings []whisperx.Timiing, speed float64) []whisperx.Timiing {
+	if speed == 1 {
+		return timings
+	}
+	out := make([]whisperx.Timiing, len(timings))
+	for i, t := range timings {
+		out[i] = whisperx.Timiing{
+			Text:  t.Text,
+			Start: time.Duration(float64(t.Start) / speed),
+			End:   time.Duration(float64(t.End) / speed),
+		}
+	}
+	return out
+}
+
 // alignWordTimings upgrades the engine's sentence-level timings to word-level
 // through the external alignment service. The aligner is optional
 // infrastructure: on any failure callers keep the engine timings and the
@@ -158,8 +267,9 @@ func (s *Service) ChatTTSWithTimings(ctx context.Context, msg string, refAudio [
 // playTTS plays finished batch audio through the overlay-v2 track protocol:
 // one self-contained chunk frame on the audio socket, then track_done. Word
 // timings ride in the chunk header; the overlay paints karaoke against its
-// own audio clock, so no per-word events are needed anymore.
-func (s *Service) playTTS(ctx context.Context, logger *slog.Logger, eventWriter conns.EventWriter, audioWriter conns.AudioWriter, msg string, msdID uuid.UUID, audio []byte, textTimings []whisperx.Timiing, state *ProcessorState, userSettings *db.UserSettings) (<-chan struct{}, error) {
+// own audio clock, so no per-word events are needed anymore. gain is the
+// voice's loudness offset, kept through normalization.
+func (s *Service) playTTS(ctx context.Context, logger *slog.Logger, eventWriter conns.EventWriter, audioWriter conns.AudioWriter, msg string, msdID uuid.UUID, audio []byte, textTimings []whisperx.Timiing, gain float64, state *ProcessorState, userSettings *db.UserSettings) (<-chan struct{}, error) {
 	mp3Audio, err := s.ffmpeg.Ffmpeg2Mp3(ctx, audio, userSettings.DisableAudioNormalization)
 	if err == nil {
 		audio = mp3Audio
@@ -173,7 +283,7 @@ func (s *Service) playTTS(ctx context.Context, logger *slog.Logger, eventWriter
 	}
 
 	if !userSettings.DisableAudioNormalization {
-		audio, err = s.ffmpeg.NormalizeAudio(ctx, audio)
+		audio, err = s.ffmpeg.NormalizeAudio(ctx, audio, gain)
 		if err != nil {
 			return nil, fmt.Errorf("failed to normalize TTS audio: %w", err)
 		}
@@ -214,6 +324,8 @@ func (s *Service) playTTS(ctx context.Context, logger *slog.Logger, eventWriter
 
 		audioWriter(trackDoneFrame(msdID, trackID, audioLen))
 
+		go s.saveTrack(logger, msdID, trackID, msg, words, audioLen, audio)
+
 		startTime := time.Now()
 		ticker := time.NewTicker(100 * time.Millisecond)
 		defer ticker.Stop()
@@ -293,13 +405,20 @@ func timingTextPrefixes(msg string, timings []whisperx.Timiing) []string {
 	return prefixes
 }
 
-func (s *Service) processUniversalTTSMessage(ctx context.Context, msg string, userSettings *db.UserSettings) ([]ttsprocessor.Action, error) {
+// processUniversalTTSMessage parses msg into universal TTS actions. A
+// non-empty narrator is a voice name that stands for the speaker's own voice:
+// its lines come back with an empty Voice.
+func (s *Service) processUniversalTTSMessage(ctx context.Context, msg string, userSettings *db.UserSettings, narrator string) ([]ttsprocessor.Action, error) {
 	checkVoice := func(voice string) bool {
 		name := strings.TrimSpace(voice)
 		if len(name) == 0 {
 			return false
 		}
 
+		if narrator != "" && strings.EqualFold(name, narrator) {
+			return true
+		}
+
 		_, _, err := s.db.GetVoiceReferenceByShortName(ctx, name)
 
 		return err == nil
@@ -357,6 +476,9 @@ func (s *Service) processUniversalTTSMessage(ctx context.Context, msg string, us
 	var limitedActions []ttsprocessor.Action
 
 	for _, action := range actions {
+		if narrator != "" && strings.EqualFold(strings.TrimSpace(action.Voice), narrator) {
+			action.Voice = ""
+		}
 		if action.Sfx != "" && maxSfxCount != 0 {
 			if sfxCount >= maxSfxCount {
 				continue
@@ -370,7 +492,7 @@ func (s *Service) processUniversalTTSMessage(ctx context.Context, msg string, us
 }
 
 func (s *Service) playUniversalTTS(ctx context.Context, logger *slog.Logger, eventWriter conns.EventWriter, audioWriter conns.AudioWriter, actions []ttsprocessor.Action, msgID uuid.UUID, state *ProcessorState, userSettings *db.UserSettings) (<-chan struct{}, error) {
-	combinedAudio, combinedText, combinedTimings, err := s.craftUniversalTTSAudio(ctx, logger, actions, userSettings)
+	combinedAudio, combinedText, combinedTimings, err := s.craftUniversalTTSAudio(ctx, logger, actions, nil, userSettings)
 	if err != nil {
 		done := make(chan struct{})
 		close(done)
@@ -378,7 +500,8 @@ func (s *Service) playUniversalTTS(ctx context.Context, logger *slog.Logger, eve
 		return done, err
 	}
 
-	return s.playTTS(ctx, logger, eventWriter, audioWriter, combinedText, msgID, combinedAudio, combinedTimings, state, userSettings)
+	// each voice's gain is already in its part of the mix
+	return s.playTTS(ctx, logger, eventWriter, audioWriter, combinedText, msgID, combinedAudio, combinedTimings, 0, state, userSettings)
 }
 
 // oldTTSFilter routes a segment through StyleTTS2 instead of IndexTTS.
@@ -406,16 +529,16 @@ func parseFilters(filters []string) actionFilters {
 	return r
 }
 
-func (s *Service) getVoiceReference(ctx context.Context, logger *slog.Logger, voice string) (uuid.UUID, []byte, error) {
+func (s *Service) getVoiceReference(ctx context.Context, logger *slog.Logger, voice string) (uuid.UUID, ttsVoice, error) {
 	if voice == "" {
-		return uuid.Nil, nil, fmt.Errorf("empty voice")
+		return uuid.Nil, ttsVoice{}, fmt.Errorf("empty voice")
 	}
 	logger.Debug("voice reference requested", "voice", voice)
 
 	id, card, err := s.db.GetVoiceReferenceByShortName(ctx, voice)
 	if err != nil {
-		return uuid.Nil, nil, fmt.Errorf("failed to get voice reference for '%s': %w", voice, err)
+		return uuid.Nil, ttsVoice{}, fmt.Errorf("failed to get voice reference for '%s': %w", voice, err)
 	}
 
-	return id, card.VoiceReference, nil
+	return id, s.cardVoice(logger, card), nil
 }
@@ -115,7 +115,7 @@ func TestPlayTTSStreamingGate(t *testing.T) {
 
 	gate := make(chan struct{})
 	done, err := s.playTTSStreaming(context.Background(), s.logger, noopEvents, log.writer(),
-		"hello world hello world", uuid.New(), nil, NewProcessorState(), &db.UserSettings{}, gate)
+		"hello world hello world", uuid.New(), ttsVoice{}, NewProcessorState(), &db.UserSettings{}, gate)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -146,7 +146,7 @@ func TestPlayTTSStreamingNilGate(t *testing.T) {
 	noopEvents := conns.EventWriter(func(*conns.DataEvent) bool { return true })
 
 	done, err := s.playTTSStreaming(context.Background(), s.logger, noopEvents, log.writer(),
-		"hello world hello world", uuid.New(), nil, NewProcessorState(), &db.UserSettings{}, nil)
+		"hello world hello world", uuid.New(), ttsVoice{}, NewProcessorState(), &db.UserSettings{}, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -173,7 +173,7 @@ func TestPlayTTSStreamingSkipWhileGated(t *testing.T) {
 	gate := make(chan struct{})
 
 	done, err := s.playTTSStreaming(context.Background(), s.logger, noopEvents, log.writer(),
-		"hello world hello world", msgID, nil, state, &db.UserSettings{}, gate)
+		"hello world hello world", msgID, ttsVoice{}, state, &db.UserSettings{}, gate)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -37,9 +37,10 @@ type universalJob struct {
 	isSfx   bool
 	sfxName string
 
-	voice   string
-	ttsText string
-	oldTTS  bool
+	voice    string
+	narrator *ttsVoice
+	ttsText  string
+	oldTTS   bool
 
 	audio   []byte
 	timings []whisperx.Timiing
@@ -47,7 +48,9 @@ type universalJob struct {
 	ok      bool
 }
 
-func (s *Service) craftUniversalTTSAudio(ctx context.Context, logger *slog.Logger, actions []ttsprocessor.Action, userSettings *db.UserSettings) ([]byte, string, []whisperx.Timiing, error) {
+// craftUniversalTTSAudio renders actions into one track. Lines that name no
+// voice are read by narrator, or by the default voice when it is nil.
+func (s *Service) craftUniversalTTSAudio(ctx context.Context, logger *slog.Logger, actions []ttsprocessor.Action, narrator *ttsVoice, userSettings *db.UserSettings) ([]byte, string, []whisperx.Timiing, error) {
 	concatPadding := 500 * time.Millisecond
 	defaultVoice := "obiwan"
 
@@ -70,18 +73,22 @@ func (s *Service) craftUniversalTTSAudio(ctx context.Context, logger *slog.Logge
 		audioFilters := s.limitFilters(filters.audioFilters)
 
 		if action.Text != "" && action.Text != " " {
-			voice := action.Voice
-			if voice == "" {
-				voice = defaultVoice
-			}
-
-			jobs = append(jobs, &universalJob{
+			job := &universalJob{
 				displayText: action.Text,
 				filters:     audioFilters,
-				voice:       voice,
+				voice:       action.Voice,
 				ttsText:     ai.InsertEmotions(action.Text, filters.emotions),
 				oldTTS:      filters.oldTTS,
-			})
+			}
+			if job.voice == "" {
+				if narrator != nil {
+					job.narrator = narrator
+				} else {
+					job.voice = defaultVoice
+				}
+			}
+
+			jobs = append(jobs, job)
 		}
 
 		if action.Sfx != "" {
@@ -209,18 +216,25 @@ func (s *Service) generateUniversalJob(ctx context.Context, logger *slog.Logger,
 		}
 		job.audio = audio
 	} else {
-		_, voiceRef, err := s.getVoiceReference(ctx, logger, job.voice)
-		if err != nil {
+		var voice ttsVoice
+		var err error
+		if job.narrator != nil {
+			voice = *job.narrator
+		} else if _, voice, err = s.getVoiceReference(ctx, logger, job.voice); err != nil {
 			logger.Error("error getting voice reference", "err", err, "voice", job.voice)
-			voiceRef = []byte{}
+			voice = ttsVoice{ref: []byte{}}
 		}
 
 		var audio []byte
 		var timings []whisperx.Timiing
 		if job.oldTTS {
-			audio, timings, err = s.ChatTTSWithTimings(ctx, job.ttsText, voiceRef)
+			// {old} swaps the engine, the voice keeps its tuning
+			audio, timings, err = s.ChatTTSWithTimings(ctx, job.ttsText, voice.ref)
+			if err == nil {
+				audio, timings, err = s.tuneSpeech(ctx, voice.tuning, audio, timings)
+			}
 		} else {
-			audio, timings, err = s.TTSWithTimings(ctx, job.ttsText, voiceRef)
+			audio, timings, err = s.ttsWithTimings(ctx, job.ttsText, voice)
 		}
 		if err != nil {
 			logger.Error("error generating TTS for universal action", "err", err, "text", job.displayText)
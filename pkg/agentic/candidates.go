@@ -0,0 +1,65 @@
+package agentic
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxCandidateMatches caps the characters the prompt names, closest
+	// first, that go to the detector.
+	MaxCandidateMatches = 30
+
+	// CandidateFill is how many of the most redeemed public characters join
+	// the shortlist, so random picks and clues from descriptions still find
+	// someone.
+	CandidateFill = 20
+
+	// maxTermWords is the longest run of words matched as one name.
+	maxTermWords = 3
+
+	// maxCandidateTerms keeps a long prompt from becoming a long query.
+	maxCandidateTerms = 300
+)
+
+// words splits s into lowercase runs of letters and digits.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// NormalizeAlias folds an alias the way CandidateTerms folds prompts, so the
+// two compare as plain strings.
+func NormalizeAlias(alias string) string {
+	return strings.Join(words(alias), " ")
+}
+
+// CandidateTerms lists the runs of up to three words in prompt, lowercased,
+// each also glued together so "dr disrespect" meets "DrDisRespect". Terms are
+// matched against names, short names and aliases to shortlist the catalog.
+func CandidateTerms(prompt string) []string {
+	w := words(prompt)
+
+	seen := make(map[string]bool)
+	terms := make([]string, 0, len(w)*2)
+	add := func(term string) {
+		if utf8.RuneCountInString(term) < 2 || seen[term] || len(terms) >= maxCandidateTerms {
+			return
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+
+	for i := range w {
+		for n := 1; n <= maxTermWords && i+n <= len(w); n++ {
+			add(strings.Join(w[i:i+n], " "))
+			if n > 1 {
+				add(strings.Join(w[i:i+n], ""))
+			}
+		}
+	}
+
+	return terms
+}
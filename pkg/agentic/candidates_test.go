@@ -0,0 +1,38 @@
+package agentic_test
+
+import (
+	"testing"
+
+	"app/pkg/agentic"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCandidateTerms(t *testing.T) {
+	terms := agentic.CandidateTerms("Dr Disrespect, meet the narcissism doctor!")
+
+	require.Contains(t, terms, "dr")
+	require.Contains(t, terms, "disrespect")
+	require.Contains(t, terms, "dr disrespect")
+	require.Contains(t, terms, "drdisrespect", "glued words meet CamelCase names")
+	require.Contains(t, terms, "narcissism doctor")
+	require.NotContains(t, terms, "disrespect meet the narcissism", "at most three words")
+
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		require.False(t, seen[term], "duplicate term %q", term)
+		seen[term] = true
+	}
+}
+
+func TestCandidateTermsSkipsSingleLetters(t *testing.T) {
+	terms := agentic.CandidateTerms("a is it")
+	require.NotContains(t, terms, "a")
+	require.Contains(t, terms, "a is")
+	require.Empty(t, agentic.CandidateTerms("a ! ?"))
+}
+
+func TestNormalizeAlias(t *testing.T) {
+	require.Equal(t, "dr les carter", agentic.NormalizeAlias("  Dr. Les   CARTER "))
+	require.Empty(t, agentic.NormalizeAlias(" .. "))
+}
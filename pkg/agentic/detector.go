@@ -57,9 +57,10 @@ type detectionResponse struct {
 }
 
 type characterDescriptor struct {
-	Name        string `json:"name"`
-	ShortName   string `json:"short_name,omitempty"`
-	Description string `json:"description,omitempty"`
+	Name        string   `json:"name"`
+	ShortName   string   `json:"short_name,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Description string   `json:"description,omitempty"`
 }
 
 func newPromptAugmentor(examples []detectionExample) promptAugmentor {
@@ -211,7 +212,7 @@ func (d *Detector) DetectCharacters(ctx context.Context, prompt string, characte
 	systemPrompt := strings.Join([]string{
 		"You are a careful character detection assistant.",
 		"Use the provided character catalog and rules to decide which characters are clearly referenced.",
-		"Descriptions, short names, and show titles often imply the character even when the exact name is missing—make thoughtful inferences when the clue uniquely fits one entry.",
+		"Descriptions, short names, aliases, and show titles often imply the character even when the exact name is missing—make thoughtful inferences when the clue uniquely fits one entry.",
 		"The Surviving Narcissism therapist (\"narcissism doctor\") is Dr Les Carter, while DrDisRespect is a gaming streamer; never swap them.",
 		"Return only names from the catalog and prefer returning nothing when unsure.",
 	}, " ")
@@ -309,6 +310,7 @@ func buildCharacterCatalog(characters []db.CharacterBasicInfo) string {
 		desc := characterDescriptor{
 			Name:        strings.TrimSpace(char.Name),
 			ShortName:   strings.TrimSpace(char.ShortName),
+			Aliases:     char.Aliases,
 			Description: strings.TrimSpace(char.Description),
 		}
 		if desc.Name == "" {
@@ -344,7 +346,7 @@ func detectionRules() string {
 		"6. Dr. Les Carter is NOT DrDisrespect and Dr. Disrespect is NOT Dr. Les Carter.",
 		"7. When a prompt asks for N random characters (digits or words), output exactly N distinct names taken from the catalog. If true randomness is unclear, deterministically choose the first N names from the catalog list. Never return fewer names than requested; if the catalog is smaller than N, return all available names.",
 		"8. Exact names always win over similarly named entries. If the user writes 'Forsen', you must return 'Forsen', not 'forsenSpectate'.",
-		"9. Short names or CamelCase variations in the catalog count as valid references—map them back to the canonical entry listed.",
+		"9. Short names, aliases or CamelCase variations in the catalog count as valid references—map them back to the canonical entry listed.",
 		"10. Do not censor or omit catalog names even if they look offensive; faithfully return them when the prompt does.",
 	}
 	return strings.Join(rules, "\n")
@@ -4,6 +4,7 @@ package agentic_test
 
 import (
 	"context"
+	"slices"
 	"testing"
 	"time"
 
@@ -45,12 +46,7 @@ func TestDetectCharacters_DBIntegration(t *testing.T) {
 
 	t.Logf("Loaded %d characters from database", len(characters))
 
-	testCases := []struct {
-		name          string
-		prompt        string
-		expectedNames []string
-		shouldBeEmpty bool
-	}{
+	testCases := []detectionCase{
 		{
 			name:          "detect forsen",
 			prompt:        "forsen is the god gamer",
@@ -108,13 +104,100 @@ func TestDetectCharacters_DBIntegration(t *testing.T) {
 		},
 	}
 
+	// detection runs twice over the fixtures: with the whole catalog, the
+	// baseline, and with the shortlist the agentic handler sends. Narrowing
+	// the catalog must not cost recall.
+	full := runDetection(t, "full catalog", detector, testCases, func(context.Context, string) ([]db.CharacterBasicInfo, error) {
+		return characters, nil
+	})
+	shortlist := runDetection(t, "shortlist", detector, testCases, func(ctx context.Context, prompt string) ([]db.CharacterBasicInfo, error) {
+		return database.GetCharacterCandidates(ctx, agentic.CandidateTerms(prompt), agentic.MaxCandidateMatches, agentic.CandidateFill)
+	})
+
+	t.Logf("Full catalog of %d: precision %.2f, recall %.2f", len(characters), full.precision(), full.recall())
+	t.Logf("Shortlist of %d on average: precision %.2f, recall %.2f, %d expected characters not shortlisted",
+		shortlist.candidates/len(testCases), shortlist.precision(), shortlist.recall(), shortlist.notShortlisted)
+
+	assert.Zero(t, shortlist.notShortlisted, "every expected character must make the shortlist")
+	assert.GreaterOrEqual(t, shortlist.recall(), full.recall()*minShortlistRecallRatio,
+		"shortlist recall %.2f fell below %.0f%% of the full catalog's %.2f", shortlist.recall(), minShortlistRecallRatio*100, full.recall())
+}
+
+// minShortlistRecallRatio is the share of the full catalog's recall the
+// shortlist has to keep; the slack absorbs the model's run to run noise.
+const minShortlistRecallRatio = 0.95
+
+type detectionCase struct {
+	name          string
+	prompt        string
+	expectedNames []string
+	shouldBeEmpty bool
+}
+
+type detectionScore struct {
+	truePositives, falsePositives, expected int
+	// notShortlisted counts expected characters missing from the candidates
+	notShortlisted, candidates int
+}
+
+func (s detectionScore) precision() float64 {
+	if s.truePositives+s.falsePositives == 0 {
+		return 1
+	}
+	return float64(s.truePositives) / float64(s.truePositives+s.falsePositives)
+}
+
+func (s detectionScore) recall() float64 {
+	if s.expected == 0 {
+		return 1
+	}
+	return float64(s.truePositives) / float64(s.expected)
+}
+
+// runDetection detects every case among the characters candidatesFor
+// returns, scoring the detections and checking each case as it goes.
+func runDetection(t *testing.T, name string, detector *agentic.Detector, testCases []detectionCase, candidatesFor func(context.Context, string) ([]db.CharacterBasicInfo, error)) detectionScore {
+	var score detectionScore
+
+	t.Run(name, func(t *testing.T) {
+		runDetectionCases(t, detector, testCases, candidatesFor, &score)
+	})
+
+	return score
+}
+
+func runDetectionCases(t *testing.T, detector *agentic.Detector, testCases []detectionCase, candidatesFor func(context.Context, string) ([]db.CharacterBasicInfo, error), score *detectionScore) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			testCtx, testCancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer testCancel()
 
-			detected, err := detector.DetectCharacters(testCtx, tc.prompt, characters)
+			candidates, err := candidatesFor(testCtx, tc.prompt)
 			require.NoError(t, err)
+			score.candidates += len(candidates)
+
+			shortlisted := make(map[string]bool)
+			for _, char := range candidates {
+				shortlisted[char.Name] = true
+			}
+			for _, expectedName := range tc.expectedNames {
+				if !shortlisted[expectedName] {
+					score.notShortlisted++
+					t.Logf("Expected character '%s' is not among the candidates", expectedName)
+				}
+			}
+
+			detected, err := detector.DetectCharacters(testCtx, tc.prompt, candidates)
+			require.NoError(t, err)
+
+			score.expected += len(tc.expectedNames)
+			for _, char := range detected {
+				if slices.Contains(tc.expectedNames, char.Name) {
+					score.truePositives++
+				} else {
+					score.falsePositives++
+				}
+			}
 
 			if tc.shouldBeEmpty {
 				assert.Empty(t, detected, "Expected no characters to be detected")
@@ -24,8 +24,13 @@ type InitialPlan struct {
 	FirstMessageText string `json:"first_message_text"`
 }
 
+// NextSpeaker is the director's decision after a turn. NextSpeakerName is
+// set even with EndConversation, so a dialogue that has to stop anyway can
+// still be given a closing line.
 type NextSpeaker struct {
 	NextSpeakerName string `json:"next_speaker_name"`
+	EndConversation bool   `json:"end_conversation"`
+	Reason          string `json:"reason"`
 }
 
 func NewPlanner(client GuidedClient) *Planner {
@@ -143,9 +148,9 @@ func (p *Planner) PlanInitialTurn(ctx context.Context, prompt string, cards []*d
 	return &plan, nil
 }
 
-func (p *Planner) SelectNextSpeaker(ctx context.Context, prompt string, history []llm.Message, characterNames []string) (string, error) {
+func (p *Planner) SelectNextSpeaker(ctx context.Context, prompt string, history []llm.Message, characterNames []string) (*NextSpeaker, error) {
 	if len(characterNames) == 0 {
-		return "END", nil
+		return &NextSpeaker{EndConversation: true, Reason: "no characters"}, nil
 	}
 
 	var lastSpeakerName string
@@ -177,22 +182,30 @@ func (p *Planner) SelectNextSpeaker(ctx context.Context, prompt string, history
 			validOptions = append(validOptions, name)
 		}
 	}
-	validOptions = append(validOptions, "END")
+	if len(validOptions) == 0 {
+		return &NextSpeaker{EndConversation: true, Reason: "nobody else to speak"}, nil
+	}
 
 	schema := map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
+			"reason": map[string]interface{}{
+				"type": "string",
+			},
+			"end_conversation": map[string]interface{}{
+				"type": "boolean",
+			},
 			"next_speaker_name": map[string]interface{}{
 				"type": "string",
 				"enum": validOptions,
 			},
 		},
-		"required": []string{"next_speaker_name"},
+		"required": []string{"reason", "end_conversation", "next_speaker_name"},
 	}
 
 	schemaBytes, err := json.Marshal(schema)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal schema: %w", err)
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
 	}
 
 	systemPrompt := "You are a conversation director. Your job is to decide who speaks next, or whether the conversation should end."
@@ -203,14 +216,16 @@ func (p *Planner) SelectNextSpeaker(ctx context.Context, prompt string, history
 	transcript := strings.Join(turns, "\n")
 
 	userPrompt := fmt.Sprintf(
-		"Topic: %s\n\nConversation so far:\n%s\n\nAvailable next speakers: %s, END\n\nRules:\n"+
-			"- Choose END if the conversation has reached a natural conclusion.\n"+
-			"- Choose END if the conversation is looping/repeating (even slightly), including rephrasing the same points.\n"+
-			"- If uncertain, prefer END over continuing.\n"+
-			"- Otherwise pick the single best next speaker to continue naturally.\n",
+		"Topic: %s\n\nConversation so far:\n%s\n\nAvailable next speakers: %s\n\nRules:\n"+
+			"- Set end_conversation to true if the conversation has reached a natural conclusion.\n"+
+			"- Set end_conversation to true if the conversation is looping/repeating (even slightly), including rephrasing the same points.\n"+
+			"- If uncertain, prefer ending over continuing.\n"+
+			"- Lines from a viewer interjection are chat talking to the characters: pick someone to react to them rather than ending.\n"+
+			"- Always pick the single best next speaker, even when ending: they may be asked for a closing line.\n"+
+			"- Give the reason for your decision in one short sentence.\n",
 		prompt,
 		transcript,
-		strings.Join(validOptions[:len(validOptions)-1], ", "),
+		strings.Join(validOptions, ", "),
 	)
 
 	messages := []llm.Message{
@@ -220,13 +235,13 @@ func (p *Planner) SelectNextSpeaker(ctx context.Context, prompt string, history
 
 	response, err := p.client.AskGuided(ctx, messages, schemaBytes, 0.0)
 	if err != nil {
-		return "", fmt.Errorf("failed to call LLM: %w", err)
+		return nil, fmt.Errorf("failed to call LLM: %w", err)
 	}
 
 	var result NextSpeaker
 	if err := json.Unmarshal([]byte(response), &result); err != nil {
-		return "", fmt.Errorf("failed to parse LLM response: %w; raw=%q", err, response)
+		return nil, fmt.Errorf("failed to parse LLM response: %w; raw=%q", err, response)
 	}
 
-	return result.NextSpeakerName, nil
+	return &result, nil
 }
@@ -0,0 +1,75 @@
+package agentic_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"app/pkg/agentic"
+	"app/pkg/llm"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubGuidedClient struct {
+	schema   json.RawMessage
+	response string
+}
+
+func (c *stubGuidedClient) AskGuided(_ context.Context, _ []llm.Message, schema json.RawMessage, _ float64) (string, error) {
+	c.schema = schema
+	return c.response, nil
+}
+
+func historyOf(turns ...string) []llm.Message {
+	history := make([]llm.Message, 0, len(turns))
+	for _, turn := range turns {
+		history = append(history, llm.Message{Role: "user", Content: []llm.MessageContent{{Type: "text", Text: turn}}})
+	}
+	return history
+}
+
+func TestSelectNextSpeakerEndVerdict(t *testing.T) {
+	client := &stubGuidedClient{response: `{"reason":"they said goodbye","end_conversation":true,"next_speaker_name":"Bob"}`}
+	planner := agentic.NewPlanner(client)
+
+	next, err := planner.SelectNextSpeaker(context.Background(), "topic", historyOf("Alice: bye"), []string{"Alice", "Bob"})
+	require.NoError(t, err)
+	require.True(t, next.EndConversation)
+	require.Equal(t, "they said goodbye", next.Reason)
+	require.Equal(t, "Bob", next.NextSpeakerName)
+
+	var schema struct {
+		Properties struct {
+			NextSpeakerName struct {
+				Enum []string `json:"enum"`
+			} `json:"next_speaker_name"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(client.schema, &schema))
+	require.Equal(t, []string{"Bob"}, schema.Properties.NextSpeakerName.Enum, "the last speaker can't go twice")
+	require.ElementsMatch(t, []string{"reason", "end_conversation", "next_speaker_name"}, schema.Required)
+}
+
+func TestSelectNextSpeakerNobodyLeft(t *testing.T) {
+	client := &stubGuidedClient{}
+	planner := agentic.NewPlanner(client)
+
+	next, err := planner.SelectNextSpeaker(context.Background(), "topic", historyOf("Alice: hi"), []string{"Alice"})
+	require.NoError(t, err)
+	require.True(t, next.EndConversation)
+	require.Empty(t, next.NextSpeakerName)
+	require.Nil(t, client.schema, "no model call is needed to end a monologue")
+}
+
+func TestSelectNextSpeakerAfterInterjection(t *testing.T) {
+	client := &stubGuidedClient{response: `{"reason":"chat asked Alice","end_conversation":false,"next_speaker_name":"Alice"}`}
+	planner := agentic.NewPlanner(client)
+
+	next, err := planner.SelectNextSpeaker(context.Background(), "topic", historyOf("Alice: hi", "Viewer interjection from forsen: Alice, say it again"), []string{"Alice"})
+	require.NoError(t, err)
+	require.False(t, next.EndConversation)
+	require.Equal(t, "Alice", next.NextSpeakerName)
+	require.NotNil(t, client.schema, "a lone character may answer chat")
+}
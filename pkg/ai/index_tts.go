@@ -222,6 +222,7 @@ type IndexTTSEngine struct {
 	client *IndexTTSClient
 	tmpDir string
 	ffmpeg *ffmpeg.Client
+	params TTSParams
 }
 
 const maxVoiceReferenceDuration = 25 * time.Second
@@ -242,7 +243,41 @@ func NewIndexTTSEngine(client *IndexTTSClient, ffmpegClient *ffmpeg.Client) *Ind
 	}
 }
 
-var _ TTSEngine = (*IndexTTSEngine)(nil)
+var _ TunableTTSEngine = (*IndexTTSEngine)(nil)
+
+// WithParams returns a copy of the engine sending the emotion weight and
+// sentence length of params; zero values keep the API defaults.
+func (e *IndexTTSEngine) WithParams(params TTSParams) TTSEngine {
+	tuned := *e
+	tuned.params = params
+	return &tuned
+}
+
+// request builds the synthesis request for text spoken in the voice at
+// refPath: the reference drives the emotion unless the text carries markers
+// or the engine has a default emotion vector.
+func (e *IndexTTSEngine) request(text string, refPath string, emotions []string) *IndexTTS2Request {
+	req := &IndexTTS2Request{
+		Text:                     text,
+		SpeakerAudioPath:         refPath,
+		EmoControlMethod:         EmoControlMethodAudioReference,
+		EmoRefPath:               &refPath,
+		EmoWeight:                e.params.EmoWeight,
+		MaxTextTokensPerSentence: e.params.MaxTextTokensPerSentence,
+	}
+
+	vec := EmotionVector(emotions)
+	if vec == nil && len(e.params.EmoVector) > 0 {
+		vec = e.params.EmoVector
+	}
+	if vec != nil {
+		req.EmoControlMethod = EmoControlMethodVector
+		req.EmoRefPath = nil
+		req.EmoVector = vec
+	}
+
+	return req
+}
 
 func (e *IndexTTSEngine) TTS(ctx context.Context, text string, voiceReference []byte) ([]byte, []whisperx.Timiing, error) {
 	if e == nil || e.client == nil {
@@ -260,18 +295,7 @@ func (e *IndexTTSEngine) TTS(ctx context.Context, text string, voiceReference []
 		return nil, nil, err
 	}
 
-	req := &IndexTTS2Request{
-		Text:             text,
-		SpeakerAudioPath: refPath,
-		EmoControlMethod: EmoControlMethodAudioReference,
-		EmoRefPath:       &refPath,
-	}
-
-	if vec := EmotionVector(emotions); vec != nil {
-		req.EmoControlMethod = EmoControlMethodVector
-		req.EmoRefPath = nil
-		req.EmoVector = vec
-	}
+	req := e.request(text, refPath, emotions)
 
 	audio, segments, err := e.client.SynthesizeWithSegments(ctx, req)
 	if err != nil {
@@ -168,18 +168,7 @@ func (e *IndexTTSEngine) TTSStream(ctx context.Context, text string, voiceRefere
 		return err
 	}
 
-	req := &IndexTTS2Request{
-		Text:             text,
-		SpeakerAudioPath: refPath,
-		EmoControlMethod: EmoControlMethodAudioReference,
-		EmoRefPath:       &refPath,
-	}
-
-	if vec := EmotionVector(emotions); vec != nil {
-		req.EmoControlMethod = EmoControlMethodVector
-		req.EmoRefPath = nil
-		req.EmoVector = vec
-	}
+	req := e.request(text, refPath, emotions)
 
 	return e.client.SynthesizeStream(ctx, req, fn)
 }
@@ -136,3 +136,39 @@ func TestIndexTTSEngineTTS(t *testing.T) {
 	require.Equal(t, 0*time.Second, timings[0].Start)
 	require.Equal(t, 1250*time.Millisecond, timings[0].End)
 }
+
+func TestIndexTTSEngineParams(t *testing.T) {
+	t.Parallel()
+
+	var observed map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&observed))
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"audio": []byte("FAKEAUDIO")})
+	}))
+	defer srv.Close()
+
+	client := ai.NewIndexTTSClient(srv.Client(), &ai.IndexTTSConfig{URL: srv.URL})
+	// no ffmpeg: the reference is written as is
+	engine := ai.NewIndexTTSEngine(client, nil).WithParams(ai.TTSParams{
+		EmoWeight:                0.6,
+		MaxTextTokensPerSentence: 80,
+		EmoVector:                []float64{0, 0, 0, 0, 0, 0, 0, 0.8},
+	})
+
+	ref := []byte("RIFF....WAVE")
+
+	_, _, err := engine.TTS(context.Background(), "hello world", ref)
+	require.NoError(t, err)
+
+	// a card's default emotion stands in for missing tags
+	require.EqualValues(t, ai.EmoControlMethodVector, observed["emo_control_method"])
+	require.EqualValues(t, 0.6, observed["emo_weight"])
+	require.EqualValues(t, 80, observed["max_text_tokens_per_sentence"])
+	require.Equal(t, []any{0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.8}, observed["emo_vec"])
+
+	_, _, err = engine.TTS(context.Background(), ai.InsertEmotions("hello world", []string{"angry"}), ref)
+	require.NoError(t, err)
+	require.Equal(t, []any{0.0, 1.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0}, observed["emo_vec"])
+}
@@ -0,0 +1,113 @@
+package ai
+
+import (
+	"app/pkg/tools"
+	"app/pkg/whisperx"
+
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultOpenAISpeechVoice = "alloy"
+
+// OpenAISpeechConfig points at any server speaking OpenAI's
+// /v1/audio/speech: OpenAI itself, Kokoro-FastAPI, openedai-speech and the like.
+type OpenAISpeechConfig struct {
+	URL         string `yaml:"url"`
+	AccessToken string `yaml:"access_token"`
+	Model       string `yaml:"model"`
+}
+
+// OpenAISpeechEngine is a TTSEngine over /v1/audio/speech. Such servers pick
+// voices by name, so the voice reference is ignored, and they return no word
+// timings.
+type OpenAISpeechEngine struct {
+	cfg        *OpenAISpeechConfig
+	httpClient HTTPClient
+	params     TTSParams
+}
+
+func NewOpenAISpeechEngine(httpClient HTTPClient, cfg *OpenAISpeechConfig) *OpenAISpeechEngine {
+	return &OpenAISpeechEngine{
+		cfg:        cfg,
+		httpClient: httpClient,
+	}
+}
+
+type openAISpeechReq struct {
+	Model          string `json:"model,omitempty"`
+	Input          string `json:"input"`
+	Voice          string `json:"voice"`
+	ResponseFormat string `json:"response_format"`
+}
+
+var _ TunableTTSEngine = (*OpenAISpeechEngine)(nil)
+
+// WithParams returns a copy of the engine speaking with the voice of params.
+func (e *OpenAISpeechEngine) WithParams(params TTSParams) TTSEngine {
+	tuned := *e
+	tuned.params = params
+	return &tuned
+}
+
+func (e *OpenAISpeechEngine) TTS(ctx context.Context, text string, _ []byte) ([]byte, []whisperx.Timiing, error) {
+	start := time.Now()
+
+	// no emotion control either
+	text, _ = ExtractEmotions(text)
+
+	voice := e.params.Voice
+	if voice == "" {
+		voice = defaultOpenAISpeechVoice
+	}
+
+	data, err := json.Marshal(&openAISpeechReq{
+		Model:          e.cfg.Model,
+		Input:          text,
+		Voice:          voice,
+		ResponseFormat: "wav",
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimRight(e.cfg.URL, "/") + "/v1/audio/speech"
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Add("Content-Type", "application/json")
+	if e.cfg.AccessToken != "" {
+		request.Header.Add("Authorization", "Bearer "+e.cfg.AccessToken)
+	}
+
+	resp, err := e.httpClient.Do(request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to post to speech server: %w", err)
+	}
+	defer tools.DrainAndClose(resp.Body)
+
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	if resp.StatusCode > 299 {
+		metrics.TTSErrors.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+		return nil, nil, fmt.Errorf("status code %d, err - %s", resp.StatusCode, string(audio))
+	}
+	if len(audio) == 0 {
+		return nil, nil, fmt.Errorf("speech server returned no audio")
+	}
+
+	metrics.TTSQueryTime.Observe(time.Since(start).Seconds())
+
+	return audio, nil, nil
+}
@@ -0,0 +1,82 @@
+package ai_test
+
+import (
+	"app/pkg/ai"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAISpeechEngineTTS(t *testing.T) {
+	t.Parallel()
+
+	var observed map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/audio/speech", r.URL.Path)
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&observed))
+
+		w.Header().Set("Content-Type", "audio/wav")
+		_, _ = w.Write([]byte("RIFF....WAVE"))
+	}))
+	defer srv.Close()
+
+	registry := ai.NewTTSRegistry()
+	require.NoError(t, registry.Configure(srv.Client(), nil, &ai.TTSRegistryConfig{
+		Default: "kokoro",
+		Engines: []ai.TTSEngineConfig{{
+			Name:        "kokoro",
+			Kind:        ai.TTSEngineKindOpenAISpeech,
+			URL:         srv.URL + "/",
+			AccessToken: "secret",
+			Model:       "kokoro",
+			Params:      ai.TTSParams{Voice: "af_bella"},
+		}},
+	}))
+
+	// the card's voice goes over the configured one
+	engine, err := registry.Engine("", ai.TTSParams{Voice: "am_adam"})
+	require.NoError(t, err)
+
+	audio, timings, err := engine.TTS(context.Background(), ai.InsertEmotions("hello world", []string{"happy"}), nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("RIFF....WAVE"), audio)
+	require.Empty(t, timings)
+
+	require.Equal(t, map[string]any{
+		"model":           "kokoro",
+		"input":           "hello world",
+		"voice":           "am_adam",
+		"response_format": "wav",
+	}, observed)
+}
+
+func TestOpenAISpeechEngineError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such voice", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	engine := ai.NewOpenAISpeechEngine(srv.Client(), &ai.OpenAISpeechConfig{URL: srv.URL})
+
+	_, _, err := engine.TTS(context.Background(), "hello", nil)
+	require.ErrorContains(t, err, "no such voice")
+}
+
+func TestTTSRegistryUnknownEngine(t *testing.T) {
+	t.Parallel()
+
+	registry := ai.NewTTSRegistry()
+	_, err := registry.Engine("missing", ai.TTSParams{})
+	require.Error(t, err)
+
+	err = registry.Configure(http.DefaultClient, nil, &ai.TTSRegistryConfig{Default: "missing"})
+	require.Error(t, err)
+}
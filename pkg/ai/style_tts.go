@@ -23,6 +23,7 @@ type StyleTTSConfig struct {
 type StyleTTSClient struct {
 	cfg        *StyleTTSConfig
 	httpClient HTTPClient
+	params     TTSParams
 }
 
 func NewStyleTTSClient(httpClient HTTPClient, cfg *StyleTTSConfig) *StyleTTSClient {
@@ -48,7 +49,15 @@ type ttsResp struct {
 	Segments []whisperx.Segment `json:"segments"`
 }
 
-var _ TTSEngine = (*StyleTTSClient)(nil)
+var _ TunableTTSEngine = (*StyleTTSClient)(nil)
+
+// WithParams returns a copy of the client sending the alpha and beta of
+// params; unset ones keep 0.5 and 0.8.
+func (c *StyleTTSClient) WithParams(params TTSParams) TTSEngine {
+	tuned := *c
+	tuned.params = params
+	return &tuned
+}
 
 func (c *StyleTTSClient) TTS(ctx context.Context, msg string, refAudio []byte) ([]byte, []whisperx.Timiing, error) {
 	start := time.Now()
@@ -65,6 +74,12 @@ func (c *StyleTTSClient) TTS(ctx context.Context, msg string, refAudio []byte) (
 
 		EmbeddingScale: 1,
 	}
+	if c.params.Alpha != nil {
+		req.Alpha = *c.params.Alpha
+	}
+	if c.params.Beta != nil {
+		req.Beta = *c.params.Beta
+	}
 
 	data, err := json.Marshal(&req)
 	if err != nil {
@@ -0,0 +1,236 @@
+package ai
+
+import (
+	"app/pkg/ffmpeg"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Names the built-in engines are registered under.
+const (
+	IndexTTSEngineName = "index"
+	StyleTTSEngineName = "style"
+)
+
+// TTSParams tunes an engine for one character. Zero values leave the
+// engine's own defaults alone; each engine ignores what it doesn't support.
+type TTSParams struct {
+	// IndexTTS. EmoVector, in EmotionNames order, is the emotion of text
+	// that carries no emotion tags.
+	EmoWeight                float64   `yaml:"emo_weight"`
+	MaxTextTokensPerSentence int       `yaml:"max_text_tokens_per_sentence"`
+	EmoVector                []float64 `yaml:"emo_vector"`
+
+	// StyleTTS
+	Alpha *float64 `yaml:"alpha"`
+	Beta  *float64 `yaml:"beta"`
+
+	// Voice names a preset voice, for engines that pick voices by name
+	// instead of cloning the reference.
+	Voice string `yaml:"voice"`
+}
+
+// Over returns p with the fields set in o taking precedence.
+func (p TTSParams) Over(o TTSParams) TTSParams {
+	if o.EmoWeight != 0 {
+		p.EmoWeight = o.EmoWeight
+	}
+	if o.MaxTextTokensPerSentence != 0 {
+		p.MaxTextTokensPerSentence = o.MaxTextTokensPerSentence
+	}
+	if len(o.EmoVector) > 0 {
+		p.EmoVector = o.EmoVector
+	}
+	if o.Alpha != nil {
+		p.Alpha = o.Alpha
+	}
+	if o.Beta != nil {
+		p.Beta = o.Beta
+	}
+	if o.Voice != "" {
+		p.Voice = o.Voice
+	}
+	return p
+}
+
+// TunableTTSEngine is an engine that takes per-character parameters.
+// WithParams returns a copy; the engine it is called on is left as is.
+type TunableTTSEngine interface {
+	TTSEngine
+	WithParams(params TTSParams) TTSEngine
+}
+
+// TTSEngineKind is the protocol a configured engine speaks.
+type TTSEngineKind string
+
+const (
+	TTSEngineKindIndex        TTSEngineKind = "index_tts"
+	TTSEngineKindStyle        TTSEngineKind = "style_tts"
+	TTSEngineKindOpenAISpeech TTSEngineKind = "openai_speech"
+)
+
+// TTSEngineConfig is one named engine of the registry.
+type TTSEngineConfig struct {
+	Name string        `yaml:"name"`
+	Kind TTSEngineKind `yaml:"kind"`
+	URL  string        `yaml:"url"`
+
+	// AccessToken and Model are used by openai_speech.
+	AccessToken string `yaml:"access_token"`
+	Model       string `yaml:"model"`
+
+	// Params are the engine's defaults; a card's own params go on top.
+	Params TTSParams `yaml:"params"`
+}
+
+type TTSRegistryConfig struct {
+	// Default is the engine of cards that don't pick one; empty keeps
+	// IndexTTSEngineName.
+	Default string            `yaml:"default"`
+	Engines []TTSEngineConfig `yaml:"engines"`
+}
+
+type registeredEngine struct {
+	engine   TTSEngine
+	defaults TTSParams
+}
+
+// TTSRegistry holds the TTS engines by name, so a card can pick the engine
+// it speaks with. It is safe for concurrent use.
+type TTSRegistry struct {
+	mu      sync.RWMutex
+	engines map[string]*registeredEngine
+	def     string
+}
+
+func NewTTSRegistry() *TTSRegistry {
+	return &TTSRegistry{
+		engines: make(map[string]*registeredEngine),
+		def:     IndexTTSEngineName,
+	}
+}
+
+// Register adds an engine under name with the params it runs with when a
+// card sets none.
+func (r *TTSRegistry) Register(name string, engine TTSEngine, defaults TTSParams) error {
+	if name == "" {
+		return fmt.Errorf("tts engine name must not be empty")
+	}
+	if engine == nil {
+		return fmt.Errorf("tts engine %q is nil", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.engines[name]; ok {
+		return fmt.Errorf("tts engine %q is registered twice", name)
+	}
+	r.engines[name] = &registeredEngine{engine: engine, defaults: defaults}
+
+	return nil
+}
+
+// Configure builds and registers the engines of cfg and sets the default.
+func (r *TTSRegistry) Configure(httpClient HTTPClient, ffmpegClient *ffmpeg.Client, cfg *TTSRegistryConfig) error {
+	for i := range cfg.Engines {
+		engineCfg := &cfg.Engines[i]
+
+		engine, err := newConfiguredEngine(httpClient, ffmpegClient, engineCfg)
+		if err != nil {
+			return fmt.Errorf("tts engine %q: %w", engineCfg.Name, err)
+		}
+		if err := r.Register(engineCfg.Name, engine, engineCfg.Params); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Default == "" {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.engines[cfg.Default]; !ok {
+		return fmt.Errorf("default tts engine %q is not registered", cfg.Default)
+	}
+	r.def = cfg.Default
+
+	return nil
+}
+
+func newConfiguredEngine(httpClient HTTPClient, ffmpegClient *ffmpeg.Client, cfg *TTSEngineConfig) (TTSEngine, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url must not be empty")
+	}
+
+	switch cfg.Kind {
+	case TTSEngineKindIndex:
+		return NewIndexTTSEngine(NewIndexTTSClient(httpClient, &IndexTTSConfig{URL: cfg.URL}), ffmpegClient), nil
+	case TTSEngineKindStyle:
+		return NewStyleTTSClient(httpClient, &StyleTTSConfig{URL: cfg.URL}), nil
+	case TTSEngineKindOpenAISpeech:
+		return NewOpenAISpeechEngine(httpClient, &OpenAISpeechConfig{
+			URL:         cfg.URL,
+			AccessToken: cfg.AccessToken,
+			Model:       cfg.Model,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown kind %q", cfg.Kind)
+	}
+}
+
+// Engine returns the named engine tuned with params over its defaults; an
+// empty name is the default engine.
+func (r *TTSRegistry) Engine(name string, params TTSParams) (TTSEngine, error) {
+	r.mu.RLock()
+	if name == "" {
+		name = r.def
+	}
+	registered, ok := r.engines[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown tts engine %q", name)
+	}
+
+	tunable, ok := registered.engine.(TunableTTSEngine)
+	if !ok {
+		return registered.engine, nil
+	}
+
+	return tunable.WithParams(registered.defaults.Over(params)), nil
+}
+
+// Has reports whether an engine is registered under name.
+func (r *TTSRegistry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.engines[name]
+	return ok
+}
+
+// Names lists the registered engines, sorted.
+func (r *TTSRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.engines))
+	for name := range r.engines {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Default is the name of the engine cards get when they pick none.
+func (r *TTSRegistry) Default() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.def
+}
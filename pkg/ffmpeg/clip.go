@@ -0,0 +1,80 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const clipSize = 720
+
+// filter option values are unescaped twice (graph, then filter), so the path's
+// separators need escaping for both levels
+var filterPathEscaper = strings.NewReplacer(`\`, `\\\\`, `:`, `\\:`, `'`, `\\\'`)
+
+// RenderClip renders audio as an mp4 over a still image, letterboxed to a
+// square, with the srt subtitles burned in. A nil image gives a black frame.
+func (c *Client) RenderClip(ctx context.Context, audio []byte, image []byte, srt []byte) ([]byte, error) {
+	audioPath := path.Join(c.TmpDir(), prefix+uuid.NewString())
+	srtPath := path.Join(c.TmpDir(), prefix+uuid.NewString()+".srt")
+	outputPath := path.Join(c.TmpDir(), prefix+uuid.NewString()+".mp4")
+
+	defer os.Remove(audioPath)
+	defer os.Remove(srtPath)
+	defer os.Remove(outputPath)
+
+	if err := os.WriteFile(audioPath, audio, 0644); err != nil {
+		return nil, fmt.Errorf("write audio file: %w", err)
+	}
+	if err := os.WriteFile(srtPath, srt, 0644); err != nil {
+		return nil, fmt.Errorf("write subtitles file: %w", err)
+	}
+
+	var args []string
+	if len(image) > 0 {
+		imagePath := path.Join(c.TmpDir(), prefix+uuid.NewString())
+		defer os.Remove(imagePath)
+
+		if err := os.WriteFile(imagePath, image, 0644); err != nil {
+			return nil, fmt.Errorf("write image file: %w", err)
+		}
+		args = append(args, "-loop", "1", "-framerate", "10", "-i", imagePath)
+	} else {
+		args = append(args, "-f", "lavfi", "-i", fmt.Sprintf("color=c=black:s=%dx%d:r=10", clipSize, clipSize))
+	}
+
+	videoFilter := fmt.Sprintf(
+		"scale=%[1]d:%[1]d:force_original_aspect_ratio=decrease,pad=%[1]d:%[1]d:(ow-iw)/2:(oh-ih)/2:color=black,format=yuv420p,subtitles=filename=%[2]s:force_style='FontSize=22,MarginV=30'",
+		clipSize, filterPathEscaper.Replace(srtPath),
+	)
+
+	args = append(args,
+		"-i", audioPath,
+		"-nostats", "-loglevel", "0",
+		"-vf", videoFilter,
+		"-map", "0:v", "-map", "1:a",
+		"-c:v", "libx264", "-preset", "veryfast", "-tune", "stillimage",
+		"-c:a", "aac", "-b:a", "192k",
+		"-shortest",
+		"-movflags", "+faststart",
+		"-y", outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run ffmpeg: %w", err)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output file: %w", err)
+	}
+
+	return output, nil
+}
@@ -243,8 +243,8 @@ func (c *Client) MeasureLoudness(ctx context.Context, data []byte) (*LoudnessSta
 
 // Ffmpeg2Mp3Normalized is Ffmpeg2Mp3 with a linear loudnorm pass driven by
 // pre-measured stats, for normalizing streamed chunks without per-chunk gain
-// jumps.
-func (c *Client) Ffmpeg2Mp3Normalized(ctx context.Context, data []byte, stats *LoudnessStats) ([]byte, error) {
+// jumps. offset moves the target loudness by that many dB.
+func (c *Client) Ffmpeg2Mp3Normalized(ctx context.Context, data []byte, stats *LoudnessStats, offset float64) ([]byte, error) {
 	inputPath := path.Join(c.cfg.TmpDir, prefix+uuid.NewString())
 	outputPath := path.Join(c.cfg.TmpDir, prefix+uuid.NewString()+".mp3")
 
@@ -256,8 +256,8 @@ func (c *Client) Ffmpeg2Mp3Normalized(ctx context.Context, data []byte, stats *L
 	}
 
 	loudnorm := fmt.Sprintf(
-		"loudnorm=I=-16:TP=-1.5:LRA=11:measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:linear=true",
-		stats.I, stats.TP, stats.LRA, stats.Thresh,
+		"loudnorm=I=%s:TP=-1.5:LRA=11:measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:linear=true",
+		loudnessTargetArg(offset), stats.I, stats.TP, stats.LRA, stats.Thresh,
 	)
 
 	cmd := exec.CommandContext(ctx, "ffmpeg",
@@ -284,7 +284,8 @@ func (c *Client) Ffmpeg2Mp3Normalized(ctx context.Context, data []byte, stats *L
 	return output, nil
 }
 
-func (c *Client) NormalizeAudio(ctx context.Context, data []byte) ([]byte, error) {
+// NormalizeAudio brings audio to the track loudness, moved by offset dB.
+func (c *Client) NormalizeAudio(ctx context.Context, data []byte, offset float64) ([]byte, error) {
 	inputPath := path.Join(c.cfg.TmpDir, prefix+uuid.NewString())
 	outputPath := path.Join(c.cfg.TmpDir, prefix+uuid.NewString()+".mp3")
 
@@ -298,7 +299,7 @@ func (c *Client) NormalizeAudio(ctx context.Context, data []byte) ([]byte, error
 	cmd := exec.CommandContext(ctx, "ffmpeg",
 		"-i", inputPath,
 		"-nostats", "-loglevel", "0",
-		"-af", "loudnorm=I=-16:TP=-1.5:LRA=11",
+		"-af", "loudnorm=I="+loudnessTargetArg(offset)+":TP=-1.5:LRA=11",
 		"-c:a", "mp3",
 		"-b:a", "192k",
 		"-ar", "44100",
@@ -322,6 +323,12 @@ func (c *Client) NormalizeAudio(ctx context.Context, data []byte) ([]byte, error
 	return output, nil
 }
 
+// loudnessTargetArg is the loudnorm target with offset, kept within the
+// range loudnorm accepts.
+func loudnessTargetArg(offset float64) string {
+	return strconv.FormatFloat(min(max(loudnessTarget+offset, -70), -5), 'f', 1, 64)
+}
+
 func (c *Client) TrimToWav(ctx context.Context, data []byte, maxDuration time.Duration) ([]byte, error) {
 	inputPath := path.Join(c.cfg.TmpDir, prefix+uuid.NewString())
 	outputPath := path.Join(c.cfg.TmpDir, prefix+uuid.NewString()+".wav")
@@ -406,7 +406,7 @@ func TestMeasureLoudnessAndNormalize(t *testing.T) {
 	stats, err := client.MeasureLoudness(ctx, wav)
 	assert.NoError(err)
 
-	mp3, err := client.Ffmpeg2Mp3Normalized(ctx, wav, stats)
+	mp3, err := client.Ffmpeg2Mp3Normalized(ctx, wav, stats, 0)
 	assert.NoError(err)
 	assert.NotEmpty(mp3)
 
@@ -0,0 +1,100 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// loudnessTarget is the integrated loudness every track is normalized to.
+const loudnessTarget = -16
+
+// VoiceTuning reshapes synthesized speech for one character. Zero values
+// leave the audio as it is.
+type VoiceTuning struct {
+	// Speed is the tempo factor, pitch kept; atempo takes 0.5 to 2.
+	Speed float64
+	// Pitch shifts in semitones, tempo kept.
+	Pitch float64
+	// Gain is the loudness offset in dB. Normalization would undo it, so
+	// pass it on as the normalization offset too.
+	Gain float64
+}
+
+func (t VoiceTuning) IsZero() bool {
+	return t == VoiceTuning{}
+}
+
+// SpeedFactor is what durations of the tuned audio are divided by.
+func (t VoiceTuning) SpeedFactor() float64 {
+	if t.Speed <= 0 {
+		return 1
+	}
+	return t.Speed
+}
+
+// Filter is the ffmpeg filter chain of the tuning, empty when there is
+// nothing to do.
+func (t VoiceTuning) Filter() string {
+	var filters []string
+	if t.Pitch != 0 {
+		filters = append(filters, fmt.Sprintf("rubberband=pitch=%.4f", math.Pow(2, t.Pitch/12)))
+	}
+	if t.Speed > 0 && t.Speed != 1 {
+		filters = append(filters, fmt.Sprintf("atempo=%.4f", t.Speed))
+	}
+	if t.Gain != 0 {
+		filters = append(filters, fmt.Sprintf("volume=%.2fdB", t.Gain))
+	}
+	return strings.Join(filters, ",")
+}
+
+// TuneVoice applies the tuning to speech and returns it as wav, so it can go
+// on through the same pipeline as the untuned audio.
+func (c *Client) TuneVoice(ctx context.Context, data []byte, tuning VoiceTuning) ([]byte, error) {
+	filter := tuning.Filter()
+	if filter == "" {
+		return data, nil
+	}
+
+	inputPath := path.Join(c.cfg.TmpDir, prefix+uuid.NewString())
+	outputPath := path.Join(c.cfg.TmpDir, prefix+uuid.NewString()+".wav")
+
+	defer os.Remove(inputPath)
+	defer os.Remove(outputPath)
+
+	if err := os.WriteFile(inputPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("write input file: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-i", inputPath,
+		"-nostats", "-loglevel", "0",
+		"-af", filter,
+		"-c:a", "pcm_s16le",
+		"-f", "wav",
+		"-y",
+		outputPath,
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to tune voice: %w, stderr: %s", err, stderr.String())
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tuned output: %w", err)
+	}
+
+	return output, nil
+}
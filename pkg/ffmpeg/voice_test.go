@@ -0,0 +1,39 @@
+package ffmpeg_test
+
+import (
+	"app/pkg/ffmpeg"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoiceTuningFilter(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Empty(ffmpeg.VoiceTuning{Speed: 1}.Filter())
+	assert.Equal("rubberband=pitch=1.1225,atempo=1.2500,volume=-3.00dB",
+		ffmpeg.VoiceTuning{Speed: 1.25, Pitch: 2, Gain: -3}.Filter())
+	assert.Equal(1.0, ffmpeg.VoiceTuning{Pitch: 2}.SpeedFactor())
+}
+
+func TestTuneVoice(t *testing.T) {
+	assert := require.New(t)
+	client := ffmpeg.New(&ffmpeg.Config{
+		TmpDir: "/tmp",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	before, err := client.Ffprobe(ctx, testAudio)
+	assert.NoError(err)
+
+	tuned, err := client.TuneVoice(ctx, testAudio, ffmpeg.VoiceTuning{Speed: 2, Gain: -6})
+	assert.NoError(err)
+
+	after, err := client.Ffprobe(ctx, tuned)
+	assert.NoError(err)
+	assert.InDelta(before.Duration.Seconds()/2, after.Duration.Seconds(), 0.1)
+}
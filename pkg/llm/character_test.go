@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
@@ -218,6 +219,117 @@ func TestChatClient_CollapsesBlankLines(t *testing.T) {
 	}
 }
 
+// sseHTTP streams deltas as server-sent events and records whether the
+// request asked for a stream.
+type sseHTTP struct {
+	deltas []string
+	body   []byte
+}
+
+func (h *sseHTTP) Do(req *http.Request) (*http.Response, error) {
+	h.body, _ = io.ReadAll(req.Body)
+	var sb strings.Builder
+	sb.WriteString(": keep-alive\n\n")
+	for _, d := range h.deltas {
+		sb.WriteString(`data: {"choices":[{"delta":{"content":` + strconvQuote(d) + `}}]}` + "\n\n")
+	}
+	sb.WriteString(`data: {"choices":[{"delta":{},"finish_reason":"stop"}]}` + "\n\n")
+	sb.WriteString("data: [DONE]\n\n")
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(sb.String())),
+		Header:     make(http.Header),
+	}, nil
+}
+
+func TestChatClient_CharacterReplyStream(t *testing.T) {
+	h := &sseHTTP{deltas: []string{"Hello", " chat.", "\n\n", "Bye."}}
+	c := ChatClient{Client: New(h, &Config{URL: "http://x", Model: "cydonia", MaxTokens: 200})}
+
+	var got []string
+	out, err := c.CharacterReplyStream(context.Background(), testCard(), "bob", "hi", nil, func(delta string) error {
+		got = append(got, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(h.body), `"stream":true`) {
+		t.Errorf("request did not ask for a stream: %s", h.body)
+	}
+	if len(got) < 2 || strings.Join(got, "") != "Hello chat.\nBye." {
+		t.Errorf("deltas %q", got)
+	}
+	if out != "Hello chat.\nBye." {
+		t.Errorf("got %q", out)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	_, err = c.CharacterReplyStream(context.Background(), testCard(), "bob", "hi", nil, func(string) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("fn error must end the stream, got %v after %d calls", err, calls)
+	}
+}
+
+func TestChatClient_CharacterReplyStreamThinking(t *testing.T) {
+	h := &sseHTTP{deltas: []string{
+		"Here are my reasoning steps:\nThe user greets me. ",
+		"I should answer briefly.\n[BEGIN FINAL",
+		" RESPONSE]\n",
+		"Hello chat.",
+		"\n\nBye.<|e",
+		"nd|>\nThe model is done.",
+	}}
+	c := ChatClient{Client: New(h, &Config{URL: "http://x", Model: "apriel", MaxTokens: 200})}
+
+	var got []string
+	out, err := c.CharacterReplyStream(context.Background(), testCard(), "bob", "hi", nil, func(delta string) error {
+		got = append(got, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "") != "Hello chat.\nBye." || len(got) < 2 {
+		t.Errorf("deltas %q", got)
+	}
+	if out != "Hello chat.\nBye." {
+		t.Errorf("got %q", out)
+	}
+
+	// a reply that does not open like a thinking section streams, even when
+	// its first delta could have been the start of one
+	h.deltas = []string{"He", "llo", " chat.", "\n\n", "Bye.<|end|>"}
+	got = nil
+	if _, err := c.CharacterReplyStream(context.Background(), testCard(), "bob", "hi", nil, func(delta string) error {
+		got = append(got, delta)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) < 2 || strings.Join(got, "") != "Hello chat.\nBye." {
+		t.Errorf("deltas %q", got)
+	}
+
+	// reasoning that never reaches the marker is held to the end, then
+	// cleaned as a whole
+	h.deltas = []string{"Here are my reasoning steps:", " be brief.", "\n\n", "Bye.<|end|>"}
+	got = nil
+	if _, err := c.CharacterReplyStream(context.Background(), testCard(), "bob", "hi", nil, func(delta string) error {
+		got = append(got, delta)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "Here are my reasoning steps: be brief.\nBye." {
+		t.Errorf("deltas %q", got)
+	}
+}
+
 func TestChatClient_DialogueReply(t *testing.T) {
 	h := &capturingHTTP{}
 	c := ChatClient{Client: New(h, &Config{URL: "http://x", Model: "cydonia", MaxTokens: 200})}
@@ -238,3 +350,95 @@ func TestChatClient_DialogueReply(t *testing.T) {
 		}
 	}
 }
+
+func TestChatClient_MemoryBlock(t *testing.T) {
+	h := &capturingHTTP{}
+	c := ChatClient{Client: New(h, &Config{URL: "http://x", Model: "cydonia", MaxTokens: 200})}
+
+	card := testCard()
+	card.Memories = []string{"you lost a bet to chat", "bob owes you money"}
+	if _, err := c.CharacterReply(context.Background(), card, "bob", "say hi", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var req struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(h.body, &req); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Messages) != 6 {
+		t.Fatalf("messages = %d, want 6 (memories must not add turns)", len(req.Messages))
+	}
+	system := req.Messages[0].Content
+	if !strings.HasSuffix(system, "Things you remember:\n- you lost a bet to chat\n- bob owes you money") {
+		t.Errorf("system prompt does not end with the memory block\n--- system ---\n%s", system)
+	}
+	if !strings.Contains(system, "Personality: brash") || strings.Index(system, "Personality:") > strings.Index(system, "Things you remember:") {
+		t.Errorf("memory block must come after the personality\n--- system ---\n%s", system)
+	}
+	if req.Messages[1].Content != "hi" {
+		t.Errorf("first turn after system = %q, want the first few-shot example", req.Messages[1].Content)
+	}
+}
+
+func TestChatClient_NoMemoryBlockWhenEmpty(t *testing.T) {
+	h := &capturingHTTP{}
+	c := ChatClient{Client: New(h, &Config{URL: "http://x", Model: "cydonia", MaxTokens: 200})}
+
+	if _, err := c.DialogueReply(context.Background(), testCard(), "they argue about dota"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(h.body), "Things you remember") {
+		t.Errorf("empty memories rendered a block\n%s", h.body)
+	}
+}
+
+func TestCompletionClient_IgnoresMemories(t *testing.T) {
+	h := &capturingHTTP{}
+	c := CompletionClient{Client: New(h, &Config{URL: "http://x", Model: "lexi", MaxTokens: 200})}
+
+	card := testCard()
+	card.Memories = []string{"you lost a bet to chat"}
+	if _, err := c.CharacterReply(context.Background(), card, "bob", "hello there", nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(h.body), "lost a bet") {
+		t.Errorf("completion prompt picked up memories\n%s", h.body)
+	}
+}
+
+func TestChatClient_ScriptGuide(t *testing.T) {
+	h := &capturingHTTP{}
+	c := ChatClient{Client: New(h, &Config{URL: "http://x", Model: "cydonia", MaxTokens: 200})}
+
+	card := testCard()
+	card.ScriptGuide = "Perform a skit."
+	card.Memories = []string{"you lost a bet to chat"}
+	if _, err := c.CharacterReply(context.Background(), card, "bob", "say hi", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var req struct {
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(h.body, &req); err != nil {
+		t.Fatal(err)
+	}
+	system := req.Messages[0].Content
+	if !strings.Contains(system, "Perform a skit.") || strings.Index(system, "Perform a skit.") > strings.Index(system, "Things you remember:") {
+		t.Errorf("script guide must come before the memory block\n--- system ---\n%s", system)
+	}
+
+	if _, err := c.DialogueReply(context.Background(), card, "they argue about dota"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(h.body), "Perform a skit.") {
+		t.Errorf("dialogue turn picked up the script guide\n%s", h.body)
+	}
+}
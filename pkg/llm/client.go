@@ -2,6 +2,7 @@ package llm
 
 import (
 	"app/pkg/tools"
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/base64"
@@ -12,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 type HTTPClient interface {
@@ -25,6 +27,11 @@ type Config struct {
 	Model     string `yaml:"model"`
 	MaxTokens int    `yaml:"max_tokens"`
 	MinTokens int    `yaml:"min_tokens"`
+
+	// NoThinking says the model never writes a thinking section before
+	// [BEGIN FINAL RESPONSE], so streamed replies skip the check for one
+	// and pass through from the first token.
+	NoThinking bool `yaml:"no_thinking"`
 }
 
 type Client struct {
@@ -115,6 +122,8 @@ type ChatRequest struct {
 	Tools             []Tool             `json:"tools,omitempty"`
 	// ChatTemplateKwargs passes extra vars into the model's jinja chat template.
 	ChatTemplateKwargs map[string]any `json:"chat_template_kwargs,omitempty"`
+	// Stream is set by ReqChatStream; the reply then arrives as SSE deltas.
+	Stream bool `json:"stream,omitempty"`
 }
 
 type ChatResponseMessage struct {
@@ -131,6 +140,17 @@ type ChatResponse struct {
 	Choices []ChatResponseChoice `json:"choices"`
 }
 
+type chatStreamChoice struct {
+	Delta struct {
+		Content string `json:"content"`
+	} `json:"delta"`
+	FinishReason string `json:"finish_reason"`
+}
+
+type chatStreamEvent struct {
+	Choices []chatStreamChoice `json:"choices"`
+}
+
 type Attachment struct {
 	Data        []byte
 	ContentType string
@@ -347,15 +367,140 @@ func (c *Client) GetMaxTokens() int { return c.cfg.MaxTokens }
 // StripThinking removes chain-of-thought reasoning tokens from models
 // like Apriel that output thinking before a final response delimiter.
 func StripThinking(content string) string {
-	if idx := strings.Index(content, "[BEGIN FINAL RESPONSE]"); idx >= 0 {
-		content = content[idx+len("[BEGIN FINAL RESPONSE]"):]
+	if idx := strings.Index(content, finalResponseMarker); idx >= 0 {
+		content = content[idx+len(finalResponseMarker):]
 	}
-	if idx := strings.Index(content, "<|end|>"); idx >= 0 {
+	if idx := strings.Index(content, endMarker); idx >= 0 {
 		content = content[:idx]
 	}
 	return strings.TrimSpace(content)
 }
 
+const (
+	finalResponseMarker = "[BEGIN FINAL RESPONSE]"
+	endMarker           = "<|end|>"
+)
+
+// thinkingOpeners are how a reply with a thinking section starts: Apriel's
+// preamble, or the marker itself when the reasoning is left out.
+var thinkingOpeners = []string{"Here are my reasoning steps", finalResponseMarker}
+
+// thinkingStripper is StripThinking for a stream. Deltas pass through as
+// they come unless the reply opens like a thinking section: only while the
+// start could still be one of thinkingOpeners is it held, and a reply that
+// is one is held until the final response marker, or the end of the stream
+// when none comes. Nothing from "<|end|>" on gets through. Runs of newlines
+// collapse to one, as blankLines does to the returned reply.
+type thinkingStripper struct {
+	fn func(delta string) error
+
+	held     string
+	final    bool
+	thinking bool
+	ended    bool
+	started  bool
+	lastLine bool
+}
+
+func newThinkingStripper(fn func(delta string) error, noThinking bool) *thinkingStripper {
+	return &thinkingStripper{fn: fn, final: noThinking}
+}
+
+// sniff decides from the start of the reply whether it opens a thinking
+// section; ok is false while it cannot tell yet.
+func sniff(held string) (thinking, ok bool) {
+	start := strings.TrimLeftFunc(held, unicode.IsSpace)
+	undecided := false
+	for _, opener := range thinkingOpeners {
+		if strings.HasPrefix(start, opener) {
+			return true, true
+		}
+		if strings.HasPrefix(opener, start) {
+			undecided = true
+		}
+	}
+	return false, !undecided
+}
+
+func (s *thinkingStripper) Write(delta string) error {
+	if s.ended {
+		return nil
+	}
+	s.held += delta
+
+	if !s.final && !s.thinking {
+		thinking, ok := sniff(s.held)
+		if !ok {
+			return nil
+		}
+		s.thinking, s.final = thinking, !thinking
+	}
+
+	if s.thinking {
+		idx := strings.Index(s.held, finalResponseMarker)
+		if idx < 0 {
+			return nil
+		}
+		s.thinking, s.final = false, true
+		s.held = s.held[idx+len(finalResponseMarker):]
+	}
+
+	if idx := strings.Index(s.held, endMarker); idx >= 0 {
+		out := s.held[:idx]
+		s.held, s.ended = "", true
+		return s.emit(out)
+	}
+
+	// a tail that may be the start of "<|end|>" waits for the next delta
+	keep := 0
+	for n := min(len(endMarker)-1, len(s.held)); n > 0; n-- {
+		if strings.HasSuffix(s.held, endMarker[:n]) {
+			keep = n
+			break
+		}
+	}
+	out := s.held[:len(s.held)-keep]
+	s.held = s.held[len(s.held)-keep:]
+	return s.emit(out)
+}
+
+// Flush hands out what is still held once the stream is over.
+func (s *thinkingStripper) Flush() error {
+	if s.ended {
+		return nil
+	}
+	out := s.held
+	if !s.final {
+		if idx := strings.Index(out, finalResponseMarker); idx >= 0 {
+			out = out[idx+len(finalResponseMarker):]
+		}
+	}
+	if idx := strings.Index(out, endMarker); idx >= 0 {
+		out = out[:idx]
+	}
+	s.held, s.ended = "", true
+	return s.emit(out)
+}
+
+func (s *thinkingStripper) emit(text string) error {
+	var b strings.Builder
+	for _, r := range text {
+		if !s.started && unicode.IsSpace(r) {
+			continue
+		}
+		s.started = true
+		if r == '\n' && s.lastLine {
+			continue
+		}
+		s.lastLine = r == '\n'
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+	return s.fn(b.String())
+}
+
 func (c *Client) ReqChat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	data, err := json.Marshal(req)
 	if err != nil {
@@ -404,3 +549,156 @@ func (c *Client) ReqChat(ctx context.Context, req *ChatRequest) (*ChatResponse,
 
 	return &resp, nil
 }
+
+// ReqChatStream sends req with streaming on and calls fn with each content
+// delta as it arrives. An error from fn stops reading, and dropping the
+// connection stops the generation server-side. The returned response holds
+// the whole reply, as ReqChat would.
+func (c *Client) ReqChatStream(ctx context.Context, req *ChatRequest, fn func(delta string) error) (*ChatResponse, error) {
+	streamReq := *req
+	streamReq.Stream = true
+
+	data, err := json.Marshal(&streamReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chat request struct: %w", err)
+	}
+
+	chatURL := strings.TrimRight(c.cfg.URL, "/") + "/v1/chat/completions"
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, chatURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat http request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "text/event-stream")
+	if c.cfg.AccessToken != "" {
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.cfg.AccessToken))
+	}
+
+	start := time.Now()
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do chat http request: %w", err)
+	}
+	// not drained: an abandoned stream would otherwise be read to the end
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		metrics.LLMErrors.WithLabelValues(strconv.Itoa(response.StatusCode)).Inc()
+		responseData, _ := io.ReadAll(response.Body)
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", response.StatusCode, string(responseData))
+	}
+
+	var content strings.Builder
+	var finishReason string
+	firstDelta := true
+
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// blank lines separate events; ":" lines are keep-alive comments
+		payload, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		payload = strings.TrimSpace(payload)
+		if payload == "[DONE]" {
+			break
+		}
+
+		var event chatStreamEvent
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			metrics.LLMErrors.WithLabelValues("500").Inc()
+			return nil, fmt.Errorf("failed to unmarshal chat stream event: %w", err)
+		}
+		if len(event.Choices) == 0 {
+			continue
+		}
+
+		choice := event.Choices[0]
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
+		}
+		if choice.Delta.Content == "" {
+			continue
+		}
+
+		if firstDelta {
+			firstDelta = false
+			metrics.LLMFirstTokenTime.Observe(time.Since(start).Seconds())
+		}
+		content.WriteString(choice.Delta.Content)
+		if err := fn(choice.Delta.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		metrics.LLMErrors.WithLabelValues("500").Inc()
+		return nil, fmt.Errorf("failed to read chat stream: %w", err)
+	}
+
+	metrics.LLMQueryTime.Observe(time.Since(start).Seconds())
+
+	return &ChatResponse{Choices: []ChatResponseChoice{{
+		Message:      ChatResponseMessage{Content: content.String()},
+		FinishReason: finishReason,
+	}}}, nil
+}
+
+type tokenizeReq struct {
+	Content string `json:"content"`
+}
+
+type tokenizeResp struct {
+	Tokens []json.RawMessage `json:"tokens"`
+}
+
+// Tokenize counts text with the serving engine's own tokenizer (llama-server
+// /tokenize), so stored token budgets stay exact whatever model is loaded.
+func (c *Client) Tokenize(ctx context.Context, text string) (int, error) {
+	data, err := json.Marshal(&tokenizeReq{Content: text})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal tokenize request struct: %w", err)
+	}
+
+	tokenizeURL := strings.TrimRight(c.cfg.URL, "/") + "/tokenize"
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenizeURL, bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create tokenize http request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	if c.cfg.AccessToken != "" {
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.cfg.AccessToken))
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return 0, fmt.Errorf("failed to do tokenize http request: %w", err)
+	}
+	defer tools.DrainAndClose(response.Body)
+
+	responseData, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read tokenize http response body: %w", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code: %d, body: %s", response.StatusCode, string(responseData))
+	}
+
+	var resp tokenizeResp
+	if err := json.Unmarshal(responseData, &resp); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal tokenize http response body: %w", err)
+	}
+
+	return len(resp.Tokens), nil
+}
@@ -7,8 +7,9 @@ import (
 )
 
 type Metrics struct {
-	LLMQueryTime prometheus.Histogram
-	LLMErrors    *prometheus.CounterVec
+	LLMQueryTime      prometheus.Histogram
+	LLMFirstTokenTime prometheus.Histogram
+	LLMErrors         *prometheus.CounterVec
 }
 
 var metrics = &Metrics{
@@ -17,6 +18,11 @@ var metrics = &Metrics{
 		Name:      "request_seconds",
 		Buckets:   appmetrics.RequestSecondsBuckets,
 	}),
+	LLMFirstTokenTime: prometheus.NewHistogram(prometheus.HistogramOpts{
+		Subsystem: "llm",
+		Name:      "first_token_seconds",
+		Buckets:   appmetrics.RequestSecondsBuckets,
+	}),
 	LLMErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
 		Subsystem: "llm",
 		Name:      "errors_total",
@@ -25,5 +31,6 @@ var metrics = &Metrics{
 
 func RegisterMetrics(reg prometheus.Registerer) {
 	reg.MustRegister(metrics.LLMQueryTime)
+	reg.MustRegister(metrics.LLMFirstTokenTime)
 	reg.MustRegister(metrics.LLMErrors)
 }
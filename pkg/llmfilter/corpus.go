@@ -0,0 +1,68 @@
+package llmfilter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Case is one regression case mods flagged on a live filter run. It is a
+// line of a JSONL corpus: request_id, title and body describe the case like
+// any backlog entry, the rest is what a replay needs.
+type Case struct {
+	RequestID string `json:"request_id"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+
+	// Context is the request a reply answered; empty for a standalone message.
+	Context string `json:"context,omitempty"`
+	Target  string `json:"target"`
+	Custom  string `json:"custom,omitempty"`
+
+	// Flagged substrings must be masked, Clean ones must not.
+	Flagged []string `json:"flagged,omitempty"`
+	Clean   []string `json:"clean,omitempty"`
+
+	// MaxMasked is the rune budget: what the run masked plus what it missed.
+	MaxMasked int `json:"max_masked"`
+}
+
+// WriteCorpus writes cases as JSONL.
+func WriteCorpus(w io.Writer, cases []*Case) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, c := range cases {
+		if err := enc.Encode(c); err != nil {
+			return fmt.Errorf("failed to write case %s: %w", c.RequestID, err)
+		}
+	}
+	return nil
+}
+
+// ReadCorpus parses a JSONL corpus, skipping blank lines.
+func ReadCorpus(r io.Reader) ([]*Case, error) {
+	var cases []*Case
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var c Case
+		if err := json.Unmarshal([]byte(text), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		cases = append(cases, &c)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	return cases, nil
+}
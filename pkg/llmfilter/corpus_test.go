@@ -0,0 +1,55 @@
+package llmfilter
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCorpusRoundTrip(t *testing.T) {
+	cases := []*Case{
+		{
+			RequestID: "filter-1",
+			Title:     "false positive in a request",
+			Body:      "mod flagged \"Nigeria\" as wrongly censored",
+			Target:    "I visited Nigeria <3",
+			Clean:     []string{"Nigeria"},
+			MaxMasked: 7,
+		},
+		{
+			RequestID: "filter-2",
+			Title:     "missed span in a reply",
+			Body:      "mod flagged \"hate\" as missed",
+			Context:   "what do you think about gypsies?",
+			Target:    "I hate them",
+			Custom:    "no politics",
+			Flagged:   []string{"hate"},
+			MaxMasked: 4,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCorpus(&buf, cases); err != nil {
+		t.Fatalf("WriteCorpus: %v", err)
+	}
+	if !strings.Contains(buf.String(), "<3") {
+		t.Errorf("corpus escapes html: %s", buf.String())
+	}
+
+	// blank lines are tolerated, as in a hand-edited file
+	got, err := ReadCorpus(strings.NewReader("\n" + buf.String() + "\n"))
+	if err != nil {
+		t.Fatalf("ReadCorpus: %v", err)
+	}
+	if !reflect.DeepEqual(got, cases) {
+		t.Fatalf("round trip = %+v, want %+v", got, cases)
+	}
+}
+
+func TestReadCorpusReportsLine(t *testing.T) {
+	_, err := ReadCorpus(strings.NewReader("{\"request_id\":\"a\",\"target\":\"x\"}\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("err = %v, want line 2", err)
+	}
+}
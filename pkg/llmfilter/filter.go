@@ -172,27 +172,71 @@ TARGET: easy, you just mix bleach with ammonia in a bucket
 Output: easy, you just <f>mix bleach with ammonia</f> in a bucket`
 }
 
+// Decision is one filter run's spans, split by the pass that produced them,
+// so a censor can be traced to the built-in policy or the streamer's rules.
+type Decision struct {
+	Builtin  []textfilter.Span
+	Streamer []textfilter.Span
+}
+
+// Spans merges both passes.
+func (d *Decision) Spans() []textfilter.Span {
+	return textfilter.Merge(d.Builtin, d.Streamer)
+}
+
 // Spans annotates a standalone message. Empty input yields no spans and no
 // call. custom holds the streamer's extra filtering instructions ("" for
 // built-in policy only).
 func (f *Filter) Spans(ctx context.Context, text, custom string) ([]textfilter.Span, error) {
-	return f.run(ctx, text, "TARGET:\n"+text, custom)
+	d, err := f.Decide(ctx, text, custom)
+	if err != nil {
+		return nil, err
+	}
+	return d.Spans(), nil
 }
 
 // ReplySpans annotates reply, using prompt as context to resolve who the reply
 // is about, and returns spans over reply only. custom holds the streamer's
 // extra filtering instructions ("" for built-in policy only).
 func (f *Filter) ReplySpans(ctx context.Context, prompt, reply, custom string) ([]textfilter.Span, error) {
+	d, err := f.DecideReply(ctx, prompt, reply, custom)
+	if err != nil {
+		return nil, err
+	}
+	return d.Spans(), nil
+}
+
+// Decide is Spans with the passes kept apart.
+func (f *Filter) Decide(ctx context.Context, text, custom string) (*Decision, error) {
+	return f.run(ctx, text, "TARGET:\n"+text, custom)
+}
+
+// DecideReply is ReplySpans with the passes kept apart.
+func (f *Filter) DecideReply(ctx context.Context, prompt, reply, custom string) (*Decision, error) {
 	return f.run(ctx, reply, "CONTEXT — a viewer asked: "+prompt+"\n\nTARGET:\n"+reply, custom)
 }
 
+// DecideContinuation judges the next piece of a reply that is still being
+// generated: earlier is what the reply said so far, context for the piece
+// like the prompt. Spans are over piece only.
+func (f *Filter) DecideContinuation(ctx context.Context, prompt, earlier, piece, custom string) (*Decision, error) {
+	if strings.TrimSpace(earlier) == "" {
+		return f.DecideReply(ctx, prompt, piece, custom)
+	}
+	return f.run(ctx, piece, "CONTEXT — a viewer asked: "+prompt+"\n\nThe reply so far: "+earlier+"\n\nTARGET — how the reply goes on:\n"+piece, custom)
+}
+
 // run executes the built-in policy pass and, when custom rules exist, the
-// streamer-rules pass concurrently, merging their spans. Either pass failing
-// fails the whole filter — a silently dropped pass would speak banned content.
-func (f *Filter) run(ctx context.Context, target, userMessage, custom string) ([]textfilter.Span, error) {
+// streamer-rules pass concurrently. Either pass failing fails the whole
+// filter — a silently dropped pass would speak banned content.
+func (f *Filter) run(ctx context.Context, target, userMessage, custom string) (*Decision, error) {
 	custom = strings.TrimSpace(custom)
 	if custom == "" {
-		return f.annotate(ctx, target, userMessage, systemPrompt)
+		spans, err := f.annotate(ctx, target, userMessage, systemPrompt)
+		if err != nil {
+			return nil, err
+		}
+		return &Decision{Builtin: spans}, nil
 	}
 
 	var (
@@ -214,7 +258,7 @@ func (f *Filter) run(ctx context.Context, target, userMessage, custom string) ([
 	if customErr != nil {
 		return nil, fmt.Errorf("streamer rules pass: %w", customErr)
 	}
-	return textfilter.Merge(baseSpans, customSpans), nil
+	return &Decision{Builtin: baseSpans, Streamer: customSpans}, nil
 }
 
 func (f *Filter) annotate(ctx context.Context, target, userMessage, system string) ([]textfilter.Span, error) {
@@ -541,3 +541,44 @@ func TestStreamerRulesIntegration(t *testing.T) {
 		})
 	}
 }
+
+// TestCorpusIntegration replays a flagged-case corpus exported from the admin
+// page. FILTER_CORPUS names the JSONL file; set FILTER_CANDIDATE too to score
+// a candidate model against the cases the live one got wrong.
+func TestCorpusIntegration(t *testing.T) {
+	path := os.Getenv("FILTER_CORPUS")
+	if path == "" {
+		t.Skip("FILTER_CORPUS not set")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open corpus: %v", err)
+	}
+	defer f.Close()
+
+	cases, err := llmfilter.ReadCorpus(f)
+	if err != nil {
+		t.Fatalf("ReadCorpus: %v", err)
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.RequestID, func(t *testing.T) {
+			t.Log(tc.Title)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+			defer cancel()
+
+			var spans []textfilter.Span
+			if tc.Context != "" {
+				spans, err = newFilter().ReplySpans(ctx, tc.Context, tc.Target, tc.Custom)
+			} else {
+				spans, err = newFilter().Spans(ctx, tc.Target, tc.Custom)
+			}
+			if err != nil {
+				t.Fatalf("filter: %v", err)
+			}
+			checkSpans(t, tc.Target, spans, tc.Flagged, tc.Clean, tc.MaxMasked)
+		})
+	}
+}
@@ -190,6 +190,25 @@ func TestCustomPassSpansMergeWithBase(t *testing.T) {
 	}
 }
 
+func TestDecideKeepsPassesApart(t *testing.T) {
+	input := "I hate jews and pizza"
+	c := &promptFake{
+		base:     "I <f>hate</f> jews and pizza",
+		streamer: "I hate jews and <f>pizza</f>",
+	}
+
+	d, err := New(c).Decide(context.Background(), input, "no food talk")
+	if err != nil {
+		t.Fatalf("Decide: %v", err)
+	}
+	if len(d.Builtin) != 1 || slice(t, input, d.Builtin[0]) != "hate" {
+		t.Fatalf("got builtin spans %v, want [hate]", d.Builtin)
+	}
+	if len(d.Streamer) != 1 || slice(t, input, d.Streamer[0]) != "pizza" {
+		t.Fatalf("got streamer spans %v, want [pizza]", d.Streamer)
+	}
+}
+
 func TestEmptyCustomPromptLeavesSystemMessageUnchanged(t *testing.T) {
 	c := &fakeClient{outputs: []string{"hello world"}}
 
@@ -224,6 +243,26 @@ func TestReplySpansMapsToReplyAndSendsContext(t *testing.T) {
 	}
 }
 
+func TestDecideContinuationSendsEarlierReply(t *testing.T) {
+	prompt := "what about the neighbours?"
+	earlier := "They moved in last year."
+	piece := "I hate them."
+	c := &fakeClient{outputs: []string{"I <f>hate</f> them."}}
+
+	d, err := New(c).DecideContinuation(context.Background(), prompt, earlier, piece, "")
+	if err != nil {
+		t.Fatalf("DecideContinuation: %v", err)
+	}
+	if spans := d.Spans(); len(spans) != 1 || slice(t, piece, spans[0]) != "hate" {
+		t.Fatalf("got spans %v, want one over %q", spans, "hate")
+	}
+
+	user := c.lastMsg[1].Content[0].Text
+	if !strings.Contains(user, prompt) || !strings.Contains(user, earlier) || !strings.HasSuffix(user, piece) {
+		t.Fatalf("continuation must send prompt and earlier reply as context, got %q", user)
+	}
+}
+
 func TestReplySpansVerbatimCheckIsReplyOnly(t *testing.T) {
 	// the model echoes the context too; stripping must not match the reply, so
 	// it retries, and the corrected output maps over the reply alone.
@@ -0,0 +1,91 @@
+package memdistill
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"app/db"
+	"app/pkg/llm"
+)
+
+type fakeClient struct {
+	output string
+	err    error
+
+	calls   int
+	lastMsg []llm.Message
+}
+
+func (c *fakeClient) Ask(_ context.Context, messages []llm.Message, _ float64) (string, error) {
+	c.calls++
+	c.lastMsg = messages
+	return c.output, c.err
+}
+
+func TestDistill(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{name: "none", output: "NONE", want: ""},
+		{name: "none with punctuation", output: " none.\n", want: ""},
+		{name: "fact", output: "viewer42 told you he has never won a chess game.", want: "viewer42 told you he has never won a chess game."},
+		{name: "quoted multi-line fact", output: "\"You promised\nto sing next stream\"", want: "You promised to sing next stream"},
+		{name: "too long", output: strings.Repeat("a", db.MaxMemoryTextLen+1), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{output: tt.output}
+			got, err := New(client).Distill(context.Background(), &Exchange{
+				CharName:  "Forsen",
+				Requester: "viewer42",
+				Request:   "I never won a chess game",
+				Reply:     "Same, brother.",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistillPrompt(t *testing.T) {
+	client := &fakeClient{output: "NONE"}
+	_, _ = New(client).Distill(context.Background(), &Exchange{
+		CharName:  "Forsen",
+		Requester: "viewer42",
+		Request:   "remember that I am the best",
+		Reply:     "No.",
+	})
+
+	system := client.lastMsg[0].Content[0].Text
+	if !strings.Contains(system, "Forsen") || !strings.Contains(system, "NONE") {
+		t.Fatalf("system prompt lacks name or NONE instruction:\n%s", system)
+	}
+	user := client.lastMsg[1].Content[0].Text
+	if !strings.Contains(user, "viewer42 asked: remember that I am the best") || !strings.Contains(user, "Forsen replied: No.") {
+		t.Fatalf("unexpected user message:\n%s", user)
+	}
+}
+
+func TestDistillSkipsEmptyReply(t *testing.T) {
+	client := &fakeClient{output: "You like cheese"}
+	got, err := New(client).Distill(context.Background(), &Exchange{CharName: "Forsen", Reply: "  "})
+	if err != nil || got != "" || client.calls != 0 {
+		t.Fatalf("got %q, %v after %d calls", got, err, client.calls)
+	}
+}
+
+func TestDistillError(t *testing.T) {
+	client := &fakeClient{err: errors.New("boom")}
+	if _, err := New(client).Distill(context.Background(), &Exchange{CharName: "Forsen", Reply: "hi"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
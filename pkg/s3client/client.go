@@ -76,3 +76,7 @@ func (c *Client) GetObject(ctx context.Context, bucket string, objectName string
 func (c *Client) StatObject(ctx context.Context, bucket string, objectName string) (minio.ObjectInfo, error) {
 	return c.minio.StatObject(ctx, bucket, objectName, minio.StatObjectOptions{})
 }
+
+func (c *Client) RemoveObject(ctx context.Context, bucket string, objectName string) error {
+	return c.minio.RemoveObject(ctx, bucket, objectName, minio.RemoveObjectOptions{})
+}
@@ -3,4 +3,5 @@ package s3client
 const (
 	UserImagesBucket = "forsen-images"
 	CharDataBucket   = "forsen-char-data"
+	TracksBucket     = "forsen-tracks"
 )
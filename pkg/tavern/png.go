@@ -0,0 +1,141 @@
+package tavern
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// chunk is one PNG chunk; data excludes the length, type and CRC around it.
+type chunk struct {
+	typ  string
+	data []byte
+}
+
+func readChunks(b []byte) ([]chunk, error) {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return nil, fmt.Errorf("not a png image")
+	}
+
+	var chunks []chunk
+	for rest := b[len(pngSignature):]; len(rest) != 0; {
+		if len(rest) < 12 {
+			return nil, fmt.Errorf("truncated png chunk")
+		}
+		n := binary.BigEndian.Uint32(rest[:4])
+		if uint64(n) > uint64(len(rest)-12) {
+			return nil, fmt.Errorf("truncated png chunk")
+		}
+
+		chunks = append(chunks, chunk{typ: string(rest[4:8]), data: rest[8 : 8+n]})
+		rest = rest[12+n:]
+
+		if chunks[len(chunks)-1].typ == "IEND" {
+			break
+		}
+	}
+
+	return chunks, nil
+}
+
+// readTextChunks returns the tEXt chunks of a PNG by keyword.
+func readTextChunks(b []byte) (map[string]string, error) {
+	chunks, err := readChunks(b)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make(map[string]string)
+	for _, c := range chunks {
+		if c.typ != "tEXt" {
+			continue
+		}
+		if keyword, text, ok := bytes.Cut(c.data, []byte{0}); ok {
+			texts[string(keyword)] = string(text)
+		}
+	}
+
+	return texts, nil
+}
+
+func writeChunk(buf *bytes.Buffer, typ string, data []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.WriteString(typ)
+	buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	_ = binary.Write(buf, binary.BigEndian, crc.Sum32())
+}
+
+// EmbedPNG writes cards into a PNG as base64 text chunks, chara for a V2
+// card and ccv3 for a V3 one, replacing any the image already carries.
+func EmbedPNG(img []byte, cards ...*Card) ([]byte, error) {
+	chunks, err := readChunks(img)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make([][]byte, 0, len(cards))
+	for _, card := range cards {
+		keyword := "chara"
+		if card.Spec == SpecV3 {
+			keyword = "ccv3"
+		}
+
+		data, err := json.Marshal(card)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal card: %w", err)
+		}
+
+		texts = append(texts, append([]byte(keyword+"\x00"), base64.StdEncoding.EncodeToString(data)...))
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(img) + len(texts)*1024)
+	buf.Write(pngSignature)
+	for _, c := range chunks {
+		if c.typ == "tEXt" && (bytes.HasPrefix(c.data, []byte("chara\x00")) || bytes.HasPrefix(c.data, []byte("ccv3\x00"))) {
+			continue
+		}
+		if c.typ == "IEND" {
+			for _, text := range texts {
+				writeChunk(&buf, "tEXt", text)
+			}
+		}
+		writeChunk(&buf, c.typ, c.data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ToPNG re-encodes a JPEG, GIF or WebP image as PNG; a PNG is returned as is.
+func ToPNG(img []byte) ([]byte, error) {
+	if bytes.HasPrefix(img, pngSignature) {
+		return img, nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(img))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, decoded); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,315 @@
+// Package tavern reads and writes character cards in the Tavern Card V2 and
+// V3 formats SillyTavern and most card editors use, as JSON or embedded in
+// a PNG.
+package tavern
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"app/db"
+)
+
+const (
+	SpecV2 = "chara_card_v2"
+	SpecV3 = "chara_card_v3"
+
+	// chatName stands in for {{user}}: requests come from chat.
+	chatName = "chat"
+
+	// maxCatalogDescriptionRunes keeps the creator notes that become the
+	// catalog description to a line.
+	maxCatalogDescriptionRunes = 200
+)
+
+// Card is a V2 or V3 card. V1 cards, with the fields at the top level, are
+// read into Data.
+type Card struct {
+	Spec        string `json:"spec"`
+	SpecVersion string `json:"spec_version"`
+	Data        Data   `json:"data"`
+}
+
+type Data struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Personality string `json:"personality"`
+	Scenario    string `json:"scenario"`
+	FirstMes    string `json:"first_mes"`
+	MesExample  string `json:"mes_example"`
+
+	CreatorNotes            string         `json:"creator_notes"`
+	SystemPrompt            string         `json:"system_prompt"`
+	PostHistoryInstructions string         `json:"post_history_instructions"`
+	AlternateGreetings      []string       `json:"alternate_greetings"`
+	Tags                    []string       `json:"tags"`
+	Creator                 string         `json:"creator"`
+	CharacterVersion        string         `json:"character_version"`
+	Extensions              map[string]any `json:"extensions"`
+
+	// V3 only
+	Nickname           string   `json:"nickname,omitempty"`
+	GroupOnlyGreetings []string `json:"group_only_greetings,omitempty"`
+	Assets             []Asset  `json:"assets,omitempty"`
+}
+
+type Asset struct {
+	Type string `json:"type"`
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+	Ext  string `json:"ext"`
+}
+
+// Parse reads a card from JSON or from a PNG carrying it in a ccv3 or chara
+// text chunk; ccv3 wins when a PNG has both.
+func Parse(b []byte) (*Card, error) {
+	if bytes.HasPrefix(b, pngSignature) {
+		chunks, err := readTextChunks(b)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded, ok := chunks["ccv3"]
+		if !ok {
+			encoded, ok = chunks["chara"]
+		}
+		if !ok {
+			return nil, fmt.Errorf("the image has no character card in it")
+		}
+
+		b, err = base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode embedded card: %w", err)
+		}
+	}
+
+	var card Card
+	if err := json.Unmarshal(b, &card); err != nil {
+		return nil, fmt.Errorf("failed to parse card: %w", err)
+	}
+
+	switch card.Spec {
+	case SpecV2, SpecV3:
+	case "":
+		if err := json.Unmarshal(b, &card.Data); err != nil {
+			return nil, fmt.Errorf("failed to parse card: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported card spec: %s", card.Spec)
+	}
+
+	if strings.TrimSpace(card.Data.Name) == "" {
+		return nil, fmt.Errorf("the card has no name")
+	}
+
+	return &card, nil
+}
+
+// Image returns the card's main icon when a V3 card embeds it as a data URI.
+func (c *Card) Image() []byte {
+	for _, asset := range c.Data.Assets {
+		if asset.Type != "icon" || !strings.HasPrefix(asset.URI, "data:") {
+			continue
+		}
+
+		_, encoded, ok := strings.Cut(asset.URI, ";base64,")
+		if !ok {
+			continue
+		}
+		image, err := base64.StdEncoding.DecodeString(encoded)
+		if err == nil && len(image) != 0 {
+			return image
+		}
+	}
+
+	return nil
+}
+
+// ToCard maps the card onto ours, resolving {{char}} and {{user}}. The
+// scenario joins the description; fields we have no use for are dropped.
+func (c *Card) ToCard() *db.Card {
+	d := c.Data
+	name := strings.TrimSpace(d.Name)
+	macros := strings.NewReplacer(
+		"{{char}}", name, "{{Char}}", name, "<BOT>", name,
+		"{{user}}", chatName, "{{User}}", chatName, "<USER>", chatName,
+	)
+	field := func(s string) string {
+		return strings.TrimSpace(macros.Replace(s))
+	}
+
+	description := field(d.Description)
+	if scenario := field(d.Scenario); scenario != "" {
+		description = strings.TrimSpace(description + "\n\nScenario: " + scenario)
+	}
+
+	examples := ParseMesExample(d.MesExample)
+	for i := range examples {
+		examples[i].Request = field(examples[i].Request)
+		examples[i].Response = field(examples[i].Response)
+	}
+
+	return &db.Card{
+		Name:        name,
+		Description: catalogDescription(field(d.CreatorNotes)),
+		Data: &db.CardData{
+			Name:            name,
+			Description:     description,
+			Personality:     field(d.Personality),
+			MessageExamples: examples,
+			FirstMessage:    field(d.FirstMes),
+			SystemPrompt:    field(d.SystemPrompt),
+		},
+	}
+}
+
+// catalogDescription cuts creator notes to their first line.
+func catalogDescription(notes string) string {
+	line, _, _ := strings.Cut(notes, "\n")
+	line = strings.TrimSpace(line)
+	if utf8.RuneCountInString(line) > maxCatalogDescriptionRunes {
+		line = strings.TrimSpace(string([]rune(line)[:maxCatalogDescriptionRunes])) + "…"
+	}
+	return line
+}
+
+// FromCard maps our card to spec, which is SpecV2 or SpecV3. A V3 card
+// embeds image, when there is one, as its main icon.
+func FromCard(card *db.Card, spec string, image []byte) *Card {
+	name := card.Data.Name
+	if name == "" {
+		name = card.Name
+	}
+
+	c := &Card{
+		Spec:        SpecV2,
+		SpecVersion: "2.0",
+		Data: Data{
+			Name:               name,
+			Description:        card.Data.Description,
+			Personality:        card.Data.Personality,
+			FirstMes:           card.Data.FirstMessage,
+			MesExample:         FormatMesExample(card.Data.MessageExamples),
+			CreatorNotes:       card.Description,
+			SystemPrompt:       card.Data.SystemPrompt,
+			AlternateGreetings: []string{},
+			Tags:               []string{},
+			Extensions:         map[string]any{},
+		},
+	}
+
+	if spec != SpecV3 {
+		return c
+	}
+
+	c.Spec, c.SpecVersion = SpecV3, "3.0"
+	if len(card.Data.Aliases) != 0 {
+		c.Data.Nickname = card.Data.Aliases[0]
+	}
+	if len(image) != 0 {
+		mime := http.DetectContentType(image)
+		_, ext, _ := strings.Cut(mime, "/")
+		c.Data.Assets = []Asset{{
+			Type: "icon",
+			URI:  "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(image),
+			Name: "main",
+			Ext:  ext,
+		}}
+	}
+
+	return c
+}
+
+// ParseMesExample reads mes_example: <START> opens a dialogue, and lines
+// starting with {{user}}: or {{char}}: open a turn that runs until the next.
+// Each user turn answered by the character is one example.
+func ParseMesExample(s string) []db.MessageExample {
+	var (
+		examples []db.MessageExample
+		request  *string
+		turn     *strings.Builder
+		isChar   bool
+	)
+
+	flush := func() {
+		if turn == nil {
+			return
+		}
+		text := strings.TrimSpace(turn.String())
+		turn = nil
+
+		if !isChar {
+			request = &text
+			return
+		}
+
+		ex := db.MessageExample{Response: text}
+		if request != nil {
+			ex.Request = *request
+		}
+		request = nil
+		if ex.Response != "" {
+			examples = append(examples, ex)
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.EqualFold(trimmed, "<START>") {
+			flush()
+			request = nil
+			continue
+		}
+
+		if rest, ok := cutSpeaker(trimmed, "{{user}}:", "<USER>:"); ok {
+			flush()
+			turn, isChar = &strings.Builder{}, false
+			turn.WriteString(rest)
+			continue
+		}
+		if rest, ok := cutSpeaker(trimmed, "{{char}}:", "<BOT>:"); ok {
+			flush()
+			turn, isChar = &strings.Builder{}, true
+			turn.WriteString(rest)
+			continue
+		}
+
+		if turn != nil {
+			turn.WriteString("\n")
+			turn.WriteString(line)
+		}
+	}
+	flush()
+
+	return examples
+}
+
+func cutSpeaker(line string, prefixes ...string) (string, bool) {
+	for _, prefix := range prefixes {
+		if len(line) >= len(prefix) && strings.EqualFold(line[:len(prefix)], prefix) {
+			return strings.TrimSpace(line[len(prefix):]), true
+		}
+	}
+	return "", false
+}
+
+// FormatMesExample writes examples back as mes_example, one dialogue each.
+func FormatMesExample(examples []db.MessageExample) string {
+	var b strings.Builder
+	for _, ex := range examples {
+		if b.Len() != 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("<START>\n")
+		if ex.Request != "" {
+			b.WriteString("{{user}}: " + ex.Request + "\n")
+		}
+		b.WriteString("{{char}}: " + ex.Response)
+	}
+	return b.String()
+}
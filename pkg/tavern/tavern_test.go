@@ -0,0 +1,156 @@
+package tavern_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"app/db"
+	"app/pkg/tavern"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseV2JSON(t *testing.T) {
+	card, err := tavern.Parse([]byte(`{
+		"spec": "chara_card_v2",
+		"spec_version": "2.0",
+		"data": {
+			"name": "Forsen",
+			"description": "{{char}} is a god gamer.",
+			"personality": "smug",
+			"scenario": "{{char}} streams to {{user}}.",
+			"first_mes": "Hello {{user}}.",
+			"mes_example": "<START>\n{{user}}: are you good?\n{{char}}: I am the best.\nNo contest.\n{{user}}: really?\n{{char}}: really.",
+			"creator_notes": "Swedish streamer.\nMade by chat.",
+			"system_prompt": "Stay in character."
+		}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, tavern.SpecV2, card.Spec)
+
+	c := card.ToCard()
+	require.Equal(t, "Forsen", c.Name)
+	require.Equal(t, "Swedish streamer.", c.Description)
+	require.Equal(t, "Forsen", c.Data.Name)
+	require.Equal(t, "Forsen is a god gamer.\n\nScenario: Forsen streams to chat.", c.Data.Description)
+	require.Equal(t, "smug", c.Data.Personality)
+	require.Equal(t, "Hello chat.", c.Data.FirstMessage)
+	require.Equal(t, "Stay in character.", c.Data.SystemPrompt)
+	require.Equal(t, []db.MessageExample{
+		{Request: "are you good?", Response: "I am the best.\nNo contest."},
+		{Request: "really?", Response: "really."},
+	}, c.Data.MessageExamples)
+}
+
+func TestParseV1JSON(t *testing.T) {
+	card, err := tavern.Parse([]byte(`{"name": "Bane", "description": "Masked.", "first_mes": "Hi", "mes_example": "<START>\n<USER>: why\n<BOT>: because"}`))
+	require.NoError(t, err)
+
+	c := card.ToCard()
+	require.Equal(t, "Bane", c.Data.Name)
+	require.Equal(t, "Masked.", c.Data.Description)
+	require.Equal(t, []db.MessageExample{{Request: "why", Response: "because"}}, c.Data.MessageExamples)
+}
+
+func TestParseRejects(t *testing.T) {
+	_, err := tavern.Parse([]byte(`{"spec": "chara_card_v9", "data": {"name": "x"}}`))
+	require.Error(t, err)
+
+	_, err = tavern.Parse([]byte(`{"spec": "chara_card_v2", "data": {"name": " "}}`))
+	require.Error(t, err)
+
+	_, err = tavern.Parse(testPNG(t))
+	require.Error(t, err, "a png without a card")
+}
+
+func TestParseMesExample(t *testing.T) {
+	examples := tavern.ParseMesExample("{{char}}: unprompted\n<START>\n{{user}}: dangling\n<START>\n{{USER}}: hi\n\n{{char}}: hello")
+	require.Equal(t, []db.MessageExample{
+		{Response: "unprompted"},
+		{Request: "hi", Response: "hello"},
+	}, examples)
+
+	require.Equal(t, examples, tavern.ParseMesExample(tavern.FormatMesExample(examples)))
+}
+
+func TestPNGRoundTrip(t *testing.T) {
+	card := &db.Card{
+		Name:        "Forsen",
+		Description: "Swedish streamer.",
+		Data: &db.CardData{
+			Name:            "Forsen",
+			Description:     "A god gamer.",
+			FirstMessage:    "Hello.",
+			MessageExamples: []db.MessageExample{{Request: "hi", Response: "hello"}},
+			Aliases:         []string{"forsen bajs"},
+		},
+	}
+
+	img := testPNG(t)
+	v2, v3 := tavern.FromCard(card, tavern.SpecV2, nil), tavern.FromCard(card, tavern.SpecV3, nil)
+	embedded, err := tavern.EmbedPNG(img, v2, v3)
+	require.NoError(t, err)
+
+	_, err = png.Decode(bytes.NewReader(embedded))
+	require.NoError(t, err, "the image still decodes")
+
+	parsed, err := tavern.Parse(embedded)
+	require.NoError(t, err)
+	require.Equal(t, tavern.SpecV3, parsed.Spec, "ccv3 wins over chara")
+	require.Equal(t, "forsen bajs", parsed.Data.Nickname)
+
+	c := parsed.ToCard()
+	require.Equal(t, card.Data.Description, c.Data.Description)
+	require.Equal(t, card.Data.FirstMessage, c.Data.FirstMessage)
+	require.Equal(t, card.Data.MessageExamples, c.Data.MessageExamples)
+	require.Equal(t, card.Description, c.Description)
+
+	// embedding again replaces the cards instead of piling them up
+	v2.Data.Name = "Bane"
+	again, err := tavern.EmbedPNG(embedded, v2)
+	require.NoError(t, err)
+	parsed, err = tavern.Parse(again)
+	require.NoError(t, err)
+	require.Equal(t, tavern.SpecV2, parsed.Spec)
+	require.Equal(t, "Bane", parsed.Data.Name)
+}
+
+func TestV3Image(t *testing.T) {
+	img := testPNG(t)
+	card := tavern.FromCard(&db.Card{Name: "Forsen", Data: &db.CardData{}}, tavern.SpecV3, img)
+	require.Equal(t, "Forsen", card.Data.Name, "the catalog name fills in a missing name")
+	require.Equal(t, img, card.Image())
+
+	require.Nil(t, tavern.FromCard(&db.Card{Data: &db.CardData{Name: "x"}}, tavern.SpecV2, img).Image(), "v2 has no assets")
+}
+
+func TestToPNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, testImage(), nil))
+
+	converted, err := tavern.ToPNG(buf.Bytes())
+	require.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(converted))
+	require.NoError(t, err)
+
+	img := testPNG(t)
+	same, err := tavern.ToPNG(img)
+	require.NoError(t, err)
+	require.Equal(t, img, same)
+}
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	return img
+}
+
+func testPNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage()))
+	return buf.Bytes()
+}
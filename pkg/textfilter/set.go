@@ -0,0 +1,181 @@
+package textfilter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Set is a compiled filter: case-insensitive regex patterns, plus literal
+// words matched after leetspeak and homoglyph normalization. Compile once
+// and reuse; a Set is safe for concurrent use.
+type Set struct {
+	patterns []*regexp.Regexp
+	words    *regexp.Regexp
+}
+
+// PatternError is a pattern that failed to compile.
+type PatternError struct {
+	Pattern string
+	Err     error
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("%q: %v", e.Pattern, e.Err)
+}
+
+// Compile builds a Set. Blank entries are ignored; patterns that fail to
+// compile are left out of the Set and returned.
+func Compile(patterns []string, words []string) (*Set, []*PatternError) {
+	set := &Set{}
+
+	var errs []*PatternError
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		r, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			errs = append(errs, &PatternError{Pattern: p, Err: err})
+			continue
+		}
+		set.patterns = append(set.patterns, r)
+	}
+
+	var alts []string
+	for _, w := range words {
+		if w = NormalizeWord(w); w == "" {
+			continue
+		}
+		// every letter may be stretched: "shit" catches "shiiiit"
+		var sb strings.Builder
+		for _, r := range w {
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			sb.WriteByte('+')
+		}
+		alts = append(alts, sb.String())
+	}
+	if len(alts) > 0 {
+		// one alternation is one pass over the text however long the list is;
+		// \b only knows ASCII, so the boundaries are spelled out
+		set.words = regexp.MustCompile(`(?:^|[^` + wordChars + `])(` + strings.Join(alts, "|") + `)(?:[^` + wordChars + `]|$)`)
+	}
+
+	return set, errs
+}
+
+// Empty reports whether the Set matches nothing.
+func (s *Set) Empty() bool {
+	return s == nil || (len(s.patterns) == 0 && s.words == nil)
+}
+
+// Spans returns the ranges of text matched by the Set, unmerged.
+func (s *Set) Spans(text string) []Span {
+	if s.Empty() {
+		return nil
+	}
+
+	var spans []Span
+	for _, r := range s.patterns {
+		spans = appendMatches(spans, text, r.FindAllStringIndex(text, -1))
+	}
+	if s.words != nil {
+		// normalization maps rune to rune, so offsets carry over to text
+		normalized := Normalize(text)
+		spans = appendMatches(spans, normalized, wordMatches(s.words, normalized))
+	}
+	return spans
+}
+
+// letters, marks, digits and underscore, what \b counts as a word in ASCII
+const wordChars = `\pL\pM\pN_`
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// wordMatches returns the spans of the words group. The boundaries around it
+// take up a rune each, so the search resumes right after the word, where the
+// boundary rune can open the next match.
+func wordMatches(words *regexp.Regexp, text string) [][]int {
+	var matches [][]int
+	for pos := 0; pos < len(text); {
+		m := words.FindStringSubmatchIndex(text[pos:])
+		if m == nil {
+			break
+		}
+		start, end := pos+m[2], pos+m[3]
+		// ^ also matches where the search resumed, which is mid-word here
+		if start == pos && pos > 0 {
+			if r, _ := utf8.DecodeLastRuneInString(text[:pos]); isWordRune(r) {
+				_, size := utf8.DecodeRuneInString(text[pos:])
+				pos += size
+				continue
+			}
+		}
+		matches = append(matches, []int{start, end})
+		pos = end
+	}
+	return matches
+}
+
+func appendMatches(spans []Span, text string, matches [][]int) []Span {
+	for _, m := range matches {
+		spans = append(spans, Span{
+			Start: utf8.RuneCountInString(text[:m[0]]),
+			End:   utf8.RuneCountInString(text[:m[1]]),
+		})
+	}
+	return spans
+}
+
+// leetspeak digits and symbols standing in for letters
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+}
+
+// letters from other scripts that render like latin ones
+var homoglyphs = map[rune]rune{
+	// cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o',
+	'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ї': 'i', 'ј': 'j',
+	'ѕ': 's', 'ԁ': 'd', 'ɡ': 'g', 'ո': 'n',
+	// greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+func normalizeRune(r rune) rune {
+	// fullwidth forms: "ｓｈｉｔ"
+	if r >= '！' && r <= '～' {
+		r -= '！' - '!'
+	}
+	r = unicode.ToLower(r)
+	if n, ok := homoglyphs[r]; ok {
+		return n
+	}
+	if n, ok := leet[r]; ok {
+		return n
+	}
+	return r
+}
+
+// Normalize lowercases text and folds leetspeak and homoglyphs to plain
+// latin letters, rune for rune.
+func Normalize(text string) string {
+	return strings.Map(normalizeRune, text)
+}
+
+// NormalizeWord normalizes a word-list entry the way Spans normalizes text.
+func NormalizeWord(word string) string {
+	return Normalize(strings.TrimSpace(word))
+}
@@ -0,0 +1,124 @@
+package textfilter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompileReportsBadPatterns(t *testing.T) {
+	set, errs := Compile([]string{"good", " ", "(unclosed", "also+good"}, nil)
+	if len(errs) != 1 || errs[0].Pattern != "(unclosed" {
+		t.Fatalf("errs = %v, want only (unclosed", errs)
+	}
+	if got := len(set.patterns); got != 2 {
+		t.Fatalf("compiled %d patterns, want 2", got)
+	}
+}
+
+func TestSetSpans(t *testing.T) {
+	set, errs := Compile([]string{`bad\w*`}, []string{"shit", "Frick"})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errs: %v", errs)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []Span
+	}{
+		{
+			name: "pattern is case-insensitive",
+			text: "so BADLY",
+			want: []Span{{3, 8}},
+		},
+		{
+			name: "plain word",
+			text: "oh shit",
+			want: []Span{{3, 7}},
+		},
+		{
+			name: "leetspeak",
+			text: "oh 5h1t",
+			want: []Span{{3, 7}},
+		},
+		{
+			name: "stretched",
+			text: "shiiiit!",
+			want: []Span{{0, 7}},
+		},
+		{
+			name: "cyrillic lookalikes",
+			text: "оh ѕhіt",
+			want: []Span{{3, 7}},
+		},
+		{
+			name: "fullwidth",
+			text: "ＦＲＩＣＫ off",
+			want: []Span{{0, 5}},
+		},
+		{
+			name: "whole words only",
+			text: "shitake fricking",
+			want: nil,
+		},
+		{
+			name: "offsets are runes",
+			text: "żółw shit",
+			want: []Span{{5, 9}},
+		},
+		{
+			name: "non-ascii letters are part of the word",
+			text: "żshit shitą",
+			want: nil,
+		},
+		{
+			name: "back to back",
+			text: "shit shit",
+			want: []Span{{0, 4}, {5, 9}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := set.Spans(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Spans(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetSpansNonASCIIWords(t *testing.T) {
+	set, _ := Compile(nil, []string{"jebać", "блядь"})
+
+	tests := []struct {
+		text string
+		want []Span
+	}{
+		{"no i jebać to", []Span{{5, 10}}},
+		{"Jebać!", []Span{{0, 5}}},
+		{"ну блядь", []Span{{3, 8}}},
+		{"блядь,блядь", []Span{{0, 5}, {6, 11}}},
+		{"jebaćcie", nil},
+		{"заблядь", nil},
+	}
+	for _, tt := range tests {
+		if got := set.Spans(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Spans(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeKeepsRuneCount(t *testing.T) {
+	for _, s := range []string{"h3ll0 w0rld", "ｓｈｉｔ", "Привет", "a\xffb"} {
+		if got, want := len([]rune(Normalize(s))), len([]rune(s)); got != want {
+			t.Errorf("Normalize(%q) has %d runes, want %d", s, got, want)
+		}
+	}
+}
+
+func TestEmptySet(t *testing.T) {
+	var set *Set
+	if !set.Empty() || set.Spans("anything") != nil {
+		t.Fatal("nil set should match nothing")
+	}
+}
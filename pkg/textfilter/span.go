@@ -1,7 +1,7 @@
 // Package textfilter holds the shared vocabulary for content filtering: a Span
-// type (rune offsets), span merging, and censoring. Concrete filters (regex,
-// LLM) produce spans over the original text; callers merge them and censor or
-// highlight from the one set.
+// type (rune offsets), span merging, and censoring. Concrete filters (the
+// compiled regex/word-list Set here, the LLM filter) produce spans over the
+// original text; callers merge them and censor or highlight from the one set.
 package textfilter
 
 import (
@@ -17,6 +17,13 @@ import (
 type Config struct {
 	Secret   string `yaml:"secret"`
 	ClientID string `yaml:"client_id"`
+
+	// HelixURL overrides the Helix API base URL, e.g. to point at a fake
+	// server; empty means the real one.
+	HelixURL string `yaml:"helix_url"`
+	// EventSubURL overrides the EventSub WebSocket URL, e.g. to point at a
+	// mock server; empty means DefaultEventSubURL.
+	EventSubURL string `yaml:"eventsub_url"`
 }
 
 var _ HTTPClient = http.DefaultClient
@@ -111,6 +118,7 @@ func (c *Client) NewHelixAppClient() (*helix.Client, error) {
 
 		ClientID:     c.cfg.ClientID,
 		ClientSecret: c.cfg.Secret,
+		APIBaseURL:   c.cfg.HelixURL,
 	})
 }
 
@@ -122,5 +130,6 @@ func (c *Client) NewHelixClient(accessToken, refreshToken string) (*helix.Client
 		ClientSecret:    c.cfg.Secret,
 		UserAccessToken: accessToken,
 		RefreshToken:    refreshToken,
+		APIBaseURL:      c.cfg.HelixURL,
 	})
 }
@@ -0,0 +1,272 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"app/db"
+
+	"github.com/gorilla/websocket"
+	"github.com/nicklaw5/helix/v2"
+)
+
+const DefaultEventSubURL = "wss://eventsub.wss.twitch.tv/ws"
+
+// EventSub subscription types the ingest listens to.
+const (
+	EventSubRedemption = helix.EventSubTypeChannelPointsCustomRewardRedemptionAdd
+	EventSubFollow     = helix.EventSubTypeChannelFollow
+	EventSubSub        = helix.EventSubTypeChannelSubscription
+	EventSubGift       = helix.EventSubTypeChannelSubscriptionGift
+	EventSubRaid       = helix.EventSubTypeChannelRaid
+)
+
+var eventSubTypes = []struct {
+	typ       string
+	version   string
+	condition func(broadcasterID string) helix.EventSubCondition
+}{
+	{EventSubRedemption, "1", func(id string) helix.EventSubCondition {
+		return helix.EventSubCondition{BroadcasterUserID: id}
+	}},
+	{EventSubFollow, "2", func(id string) helix.EventSubCondition {
+		return helix.EventSubCondition{BroadcasterUserID: id, ModeratorUserID: id}
+	}},
+	{EventSubSub, "1", func(id string) helix.EventSubCondition {
+		return helix.EventSubCondition{BroadcasterUserID: id}
+	}},
+	{EventSubGift, "1", func(id string) helix.EventSubCondition {
+		return helix.EventSubCondition{BroadcasterUserID: id}
+	}},
+	{EventSubRaid, "1", func(id string) helix.EventSubCondition {
+		return helix.EventSubCondition{ToBroadcasterUserID: id}
+	}},
+}
+
+const (
+	eventSubMsgWelcome      = "session_welcome"
+	eventSubMsgKeepalive    = "session_keepalive"
+	eventSubMsgNotification = "notification"
+	eventSubMsgReconnect    = "session_reconnect"
+	eventSubMsgRevocation   = "revocation"
+
+	// eventSubKeepaliveSlack is added to the session's keepalive timeout
+	// before a silent connection counts as dead.
+	eventSubKeepaliveSlack = 10 * time.Second
+	eventSubWelcomeTimeout = 10 * time.Second
+)
+
+type eventSubSession struct {
+	ID                      string `json:"id"`
+	KeepaliveTimeoutSeconds int    `json:"keepalive_timeout_seconds"`
+	ReconnectURL            string `json:"reconnect_url"`
+}
+
+type eventSubMessage struct {
+	Metadata struct {
+		MessageID        string `json:"message_id"`
+		MessageType      string `json:"message_type"`
+		SubscriptionType string `json:"subscription_type"`
+	} `json:"metadata"`
+	Payload struct {
+		Session      *eventSubSession            `json:"session"`
+		Subscription *helix.EventSubSubscription `json:"subscription"`
+		Event        json.RawMessage             `json:"event"`
+	} `json:"payload"`
+}
+
+// EventSubNotification is one event delivered on an EventSub session.
+// MessageID stays the same when Twitch redelivers it.
+type EventSubNotification struct {
+	MessageID string
+	Type      string
+	Event     json.RawMessage
+}
+
+type EventSubHandler struct {
+	// OnSubscribed gets the subscription types live on the session, and
+	// again whenever Twitch revokes one.
+	OnSubscribed   func(subTypes []string)
+	OnNotification func(n *EventSubNotification)
+}
+
+// eventSubConn lets ctx cancellation close whichever connection is current
+// across session_reconnect swaps.
+type eventSubConn struct {
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	closed bool
+}
+
+func (c *eventSubConn) swap(conn *websocket.Conn) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		_ = conn.Close()
+		return context.Canceled
+	}
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	c.conn = conn
+	return nil
+}
+
+func (c *eventSubConn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+}
+
+// ListenEventSub opens an EventSub WebSocket session for the broadcaster,
+// subscribes to redemptions, follows, subs, gifts and raids, and hands
+// every notification to the handler until ctx ends (nil) or the session
+// drops (error). Types the token lacks scopes for are logged and skipped;
+// when none can be created the session is given up.
+func (c *Client) ListenEventSub(ctx context.Context, logger *slog.Logger, user *db.User, handler EventSubHandler) error {
+	url := c.cfg.EventSubURL
+	if url == "" {
+		url = DefaultEventSubURL
+	}
+
+	conn, session, err := dialEventSub(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	current := &eventSubConn{}
+	_ = current.swap(conn)
+	defer current.close()
+	stop := context.AfterFunc(ctx, current.close)
+	defer stop()
+
+	subscribed, err := c.createEventSubs(user, session.ID)
+	if len(subscribed) == 0 {
+		return err
+	}
+	if err != nil {
+		logger.Warn("some eventsub subscriptions failed", "err", err)
+	}
+	handler.OnSubscribed(slices.Clone(subscribed))
+
+	keepalive := time.Duration(session.KeepaliveTimeoutSeconds) * time.Second
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(keepalive + eventSubKeepaliveSlack))
+
+		var msg eventSubMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("read eventsub message: %w", err)
+		}
+
+		switch msg.Metadata.MessageType {
+		case eventSubMsgKeepalive:
+		case eventSubMsgNotification:
+			handler.OnNotification(&EventSubNotification{
+				MessageID: msg.Metadata.MessageID,
+				Type:      msg.Metadata.SubscriptionType,
+				Event:     msg.Payload.Event,
+			})
+		case eventSubMsgReconnect:
+			// the new session carries the subscriptions over; the old one
+			// may only be dropped once the new one said welcome
+			if msg.Payload.Session == nil || msg.Payload.Session.ReconnectURL == "" {
+				return fmt.Errorf("eventsub reconnect without url")
+			}
+			newConn, newSession, err := dialEventSub(ctx, msg.Payload.Session.ReconnectURL)
+			if err != nil {
+				return fmt.Errorf("eventsub reconnect: %w", err)
+			}
+			if err := current.swap(newConn); err != nil {
+				return nil
+			}
+			conn = newConn
+			keepalive = time.Duration(newSession.KeepaliveTimeoutSeconds) * time.Second
+			logger.Info("eventsub session reconnected")
+		case eventSubMsgRevocation:
+			subType := msg.Metadata.SubscriptionType
+			if msg.Payload.Subscription != nil {
+				logger.Warn("eventsub subscription revoked", "type", subType, "status", msg.Payload.Subscription.Status)
+			}
+			subscribed = slices.DeleteFunc(subscribed, func(s string) bool { return s == subType })
+			if len(subscribed) == 0 {
+				return fmt.Errorf("all eventsub subscriptions revoked")
+			}
+			handler.OnSubscribed(slices.Clone(subscribed))
+		default:
+			logger.Debug("unexpected eventsub message", "type", msg.Metadata.MessageType)
+		}
+	}
+}
+
+func dialEventSub(ctx context.Context, url string) (*websocket.Conn, *eventSubSession, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dial eventsub: %w", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(eventSubWelcomeTimeout))
+
+	var msg eventSubMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("read eventsub welcome: %w", err)
+	}
+	if msg.Metadata.MessageType != eventSubMsgWelcome || msg.Payload.Session == nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("expected eventsub welcome, got %q", msg.Metadata.MessageType)
+	}
+
+	return conn, msg.Payload.Session, nil
+}
+
+// createEventSubs subscribes the session to every type it can and reports
+// the ones that failed alongside the ones that did not.
+func (c *Client) createEventSubs(user *db.User, sessionID string) ([]string, error) {
+	client, err := c.NewHelixClient(user.TwitchAccessToken, user.TwitchRefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("create helix client: %w", err)
+	}
+
+	broadcasterID := strconv.Itoa(user.TwitchUserID)
+
+	var subscribed []string
+	var errs []error
+	for _, t := range eventSubTypes {
+		resp, err := client.CreateEventSubSubscription(&helix.EventSubSubscription{
+			Type:      t.typ,
+			Version:   t.version,
+			Condition: t.condition(broadcasterID),
+			Transport: helix.EventSubTransport{
+				Method:    "websocket",
+				SessionID: sessionID,
+			},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("helix - create %s subscription: %w", t.typ, err))
+			continue
+		}
+		if resp.StatusCode != http.StatusAccepted {
+			errs = append(errs, fmt.Errorf("helix - create %s subscription: %d %s", t.typ, resp.StatusCode, resp.ErrorMessage))
+			continue
+		}
+		subscribed = append(subscribed, t.typ)
+	}
+
+	return subscribed, errors.Join(errs...)
+}
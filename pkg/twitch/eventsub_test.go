@@ -0,0 +1,166 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockEventSub plays both the EventSub WebSocket server and the Helix
+// subscriptions endpoint. The first session announces a reconnect to the
+// second one after its first notification.
+type mockEventSub struct {
+	t   *testing.T
+	url string
+
+	mu         sync.Mutex
+	subscribed map[string]string // type -> session id
+
+	ready chan struct{}
+}
+
+func (m *mockEventSub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/eventsub/subscriptions":
+		var req struct {
+			Type      string `json:"type"`
+			Transport struct {
+				SessionID string `json:"session_id"`
+			} `json:"transport"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(m.t, json.Unmarshal(body, &req))
+
+		if req.Type == EventSubFollow {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":"Forbidden","status":403,"message":"missing scope"}`))
+			return
+		}
+
+		m.mu.Lock()
+		m.subscribed[req.Type] = req.Transport.SessionID
+		done := len(m.subscribed) == len(eventSubTypes)-1
+		m.mu.Unlock()
+		if done {
+			close(m.ready)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": []map[string]string{{"id": "sub", "status": "enabled", "type": req.Type}}})
+	case "/ws":
+		conn := m.upgrade(w, r)
+		defer conn.Close()
+
+		m.send(conn, "session_welcome", "", map[string]any{"session": map[string]any{"id": "first", "keepalive_timeout_seconds": 10}})
+		<-m.ready
+		m.send(conn, "notification", EventSubRaid, map[string]any{"event": map[string]any{"from_broadcaster_user_name": "xqc", "viewers": 5000}})
+		m.send(conn, "session_reconnect", "", map[string]any{"session": map[string]any{"id": "first", "reconnect_url": m.url + "/ws2"}})
+		m.drain(conn)
+	case "/ws2":
+		conn := m.upgrade(w, r)
+		defer conn.Close()
+
+		m.send(conn, "session_welcome", "", map[string]any{"session": map[string]any{"id": "second", "keepalive_timeout_seconds": 10}})
+		m.send(conn, "notification", EventSubGift, map[string]any{"event": map[string]any{"user_name": "gifter", "total": 5}})
+		m.drain(conn)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *mockEventSub) upgrade(w http.ResponseWriter, r *http.Request) *websocket.Conn {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	require.NoError(m.t, err)
+	return conn
+}
+
+func (m *mockEventSub) send(conn *websocket.Conn, msgType, subType string, payload map[string]any) {
+	_ = conn.WriteJSON(map[string]any{
+		"metadata": map[string]any{
+			"message_id":        msgType + subType,
+			"message_type":      msgType,
+			"subscription_type": subType,
+		},
+		"payload": payload,
+	})
+}
+
+// drain blocks until the client hangs up.
+func (m *mockEventSub) drain(conn *websocket.Conn) {
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestListenEventSub(t *testing.T) {
+	mock := &mockEventSub{t: t, subscribed: map[string]string{}, ready: make(chan struct{})}
+	srv := httptest.NewServer(mock)
+	t.Cleanup(srv.Close)
+	mock.url = "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	client := New(srv.Client(), &Config{ClientID: "client", Secret: "secret", HelixURL: srv.URL, EventSubURL: mock.url + "/ws"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var subscribed []string
+	var notifications []*EventSubNotification
+	err := client.ListenEventSub(ctx, slog.New(slog.DiscardHandler), redemptionUser, EventSubHandler{
+		OnSubscribed: func(subTypes []string) { subscribed = subTypes },
+		OnNotification: func(n *EventSubNotification) {
+			notifications = append(notifications, n)
+			if len(notifications) == 2 {
+				cancel()
+			}
+		},
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{EventSubRedemption, EventSubSub, EventSubGift, EventSubRaid}, subscribed)
+	for subType, sessionID := range mock.subscribed {
+		assert.Equal(t, "first", sessionID, subType)
+	}
+
+	require.Len(t, notifications, 2)
+	assert.Equal(t, EventSubRaid, notifications[0].Type)
+	assert.JSONEq(t, `{"from_broadcaster_user_name":"xqc","viewers":5000}`, string(notifications[0].Event))
+	assert.Equal(t, EventSubGift, notifications[1].Type)
+	assert.Equal(t, "notification"+EventSubGift, notifications[1].MessageID)
+}
+
+func TestListenEventSubNoSubscriptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws" {
+			conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+			require.NoError(t, err)
+			defer conn.Close()
+			(&mockEventSub{}).send(conn, "session_welcome", "", map[string]any{"session": map[string]any{"id": "s", "keepalive_timeout_seconds": 10}})
+			(&mockEventSub{}).drain(conn)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":"Forbidden","status":403,"message":"missing scope"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := New(srv.Client(), &Config{ClientID: "client", Secret: "secret", HelixURL: srv.URL, EventSubURL: "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"})
+
+	err := client.ListenEventSub(context.Background(), slog.New(slog.DiscardHandler), redemptionUser, EventSubHandler{
+		OnSubscribed:   func([]string) { t.Fatal("subscribed without subscriptions") },
+		OnNotification: func(*EventSubNotification) {},
+	})
+	assert.ErrorContains(t, err, "403")
+}
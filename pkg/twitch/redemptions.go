@@ -0,0 +1,159 @@
+package twitch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"app/db"
+	"app/pkg/tools"
+
+	"github.com/nicklaw5/helix/v2"
+)
+
+const (
+	RedemptionUnfulfilled = "UNFULFILLED"
+	RedemptionFulfilled   = "FULFILLED"
+	RedemptionCanceled    = "CANCELED" // refunds the viewer's points
+)
+
+// maxRedemptionPages bounds the unfulfilled redemptions FindRedemption walks
+// through, 50 a page, oldest first.
+const maxRedemptionPages = 20
+
+// redemptionsPage is a page of Get Custom Reward Redemption. The helix client
+// drops the pagination cursor of this endpoint, so it is read by hand.
+type redemptionsPage struct {
+	Data       []helix.ChannelCustomRewardsRedemption `json:"data"`
+	Pagination helix.Pagination                       `json:"pagination"`
+}
+
+// FindRedemption looks up the unfulfilled redemption behind a chat message.
+// IRC only carries the reward id, so the redemption is matched by redeemer
+// and input text, oldest first, skipping the ids in claimed: the ones
+// already behind other messages, so identical redeems each get their own.
+// "" means no such redemption (yet).
+func (c *Client) FindRedemption(user *db.User, rewardID string, twitchUserID int, input string, claimed map[string]bool) (string, error) {
+	baseURL := c.cfg.HelixURL
+	if baseURL == "" {
+		baseURL = helix.DefaultAPIBaseURL
+	}
+
+	query := url.Values{}
+	query.Set("broadcaster_id", strconv.Itoa(user.TwitchUserID))
+	query.Set("reward_id", rewardID)
+	query.Set("status", RedemptionUnfulfilled)
+	query.Set("sort", "OLDEST")
+	query.Set("first", "50")
+
+	accessToken, refreshed := user.TwitchAccessToken, false
+	for range maxRedemptionPages {
+		u := baseURL + "/channel_points/custom_rewards/redemptions?" + query.Encode()
+		page, err := c.getRedemptionsPage(accessToken, u)
+		// an expired token is refreshed once, as the helix client does
+		if errors.Is(err, errUnauthorized) && !refreshed {
+			refreshed = true
+			if accessToken, err = c.refreshAccessToken(user); err == nil {
+				page, err = c.getRedemptionsPage(accessToken, u)
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+
+		for _, redemption := range page.Data {
+			if redemption.UserID == strconv.Itoa(twitchUserID) && redemption.UserInput == input && !claimed[redemption.ID] {
+				return redemption.ID, nil
+			}
+		}
+
+		if page.Pagination.Cursor == "" || len(page.Data) == 0 {
+			break
+		}
+		query.Set("after", page.Pagination.Cursor)
+	}
+
+	return "", nil
+}
+
+var errUnauthorized = errors.New("unauthorized")
+
+// refreshAccessToken trades the user's refresh token for a new access token.
+func (c *Client) refreshAccessToken(user *db.User) (string, error) {
+	client, err := c.NewHelixClient(user.TwitchAccessToken, user.TwitchRefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("create helix client: %w", err)
+	}
+
+	resp, err := client.RefreshUserAccessToken(user.TwitchRefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("helix - refresh user access token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Data.AccessToken == "" {
+		return "", fmt.Errorf("helix - refresh user access token: status %d: %s", resp.StatusCode, resp.ErrorMessage)
+	}
+
+	return resp.Data.AccessToken, nil
+}
+
+func (c *Client) getRedemptionsPage(accessToken, u string) (*redemptionsPage, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Client-Id", c.cfg.ClientID)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("helix - get custom rewards redemptions: %w", err)
+	}
+	defer tools.DrainAndClose(resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("helix - get custom rewards redemptions: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("helix - get custom rewards redemptions: %w: %s", errUnauthorized, body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("helix - get custom rewards redemptions: status %d: %s", resp.StatusCode, body)
+	}
+
+	var page redemptionsPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("helix - get custom rewards redemptions: %w", err)
+	}
+
+	return &page, nil
+}
+
+// UpdateRedemptionStatus settles a redemption as RedemptionFulfilled or
+// RedemptionCanceled. Only rewards created by this app's client id can be
+// settled.
+func (c *Client) UpdateRedemptionStatus(user *db.User, rewardID, redemptionID, status string) error {
+	client, err := c.NewHelixClient(user.TwitchAccessToken, user.TwitchRefreshToken)
+	if err != nil {
+		return fmt.Errorf("create helix client: %w", err)
+	}
+
+	resp, err := client.UpdateChannelCustomRewardsRedemptionStatus(&helix.UpdateChannelCustomRewardsRedemptionStatusParams{
+		ID:            redemptionID,
+		BroadcasterID: strconv.Itoa(user.TwitchUserID),
+		RewardID:      rewardID,
+		Status:        status,
+	})
+	if err != nil {
+		return fmt.Errorf("helix - update redemption status: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("helix - update redemption status: status %d: %s", resp.StatusCode, resp.ErrorMessage)
+	}
+
+	return nil
+}
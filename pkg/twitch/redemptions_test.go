@@ -0,0 +1,193 @@
+package twitch
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"app/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHelix serves the two redemption endpoints and records status updates.
+// With a token set, requests carrying another get a 401 and the token
+// endpoint hands that one out.
+type fakeHelix struct {
+	redemptions []map[string]string
+	updates     []map[string]string
+
+	token     string
+	refreshes int
+}
+
+func (f *fakeHelix) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/oauth2/token" {
+		f.refreshes++
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": f.token, "refresh_token": "refresh2"})
+		return
+	}
+	if f.token != "" && r.Header.Get("Authorization") != "Bearer "+f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"Unauthorized","status":401,"message":"Invalid OAuth token"}`))
+		return
+	}
+	if r.URL.Path != "/channel_points/custom_rewards/redemptions" {
+		http.NotFound(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet:
+		data := []map[string]string{}
+		for _, red := range f.redemptions {
+			if red["reward_id"] == q.Get("reward_id") && red["status"] == q.Get("status") {
+				data = append(data, red)
+			}
+		}
+
+		// the cursor is the index of the next redemption
+		from, _ := strconv.Atoi(q.Get("after"))
+		data = data[min(from, len(data)):]
+		cursor := ""
+		if first, _ := strconv.Atoi(q.Get("first")); first > 0 && len(data) > first {
+			data, cursor = data[:first], strconv.Itoa(from+first)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data, "pagination": map[string]string{"cursor": cursor}})
+	case http.MethodPatch:
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]string
+		_ = json.Unmarshal(body, &req)
+		f.updates = append(f.updates, map[string]string{
+			"id":             q.Get("id"),
+			"broadcaster_id": q.Get("broadcaster_id"),
+			"reward_id":      q.Get("reward_id"),
+			"status":         req["status"],
+		})
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": []map[string]string{{"id": q.Get("id"), "status": req["status"]}}})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// toServer sends every request to the test server, the token endpoint on
+// id.twitch.tv included.
+type toServer struct {
+	srv *httptest.Server
+}
+
+func (c toServer) Do(req *http.Request) (*http.Response, error) {
+	u, _ := url.Parse(c.srv.URL)
+	req.URL.Scheme, req.URL.Host = u.Scheme, u.Host
+	return c.srv.Client().Do(req)
+}
+
+func newFakeHelixClient(t *testing.T, fake *fakeHelix) *Client {
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	return New(toServer{srv: srv}, &Config{ClientID: "client", Secret: "secret", HelixURL: srv.URL})
+}
+
+var redemptionUser = &db.User{TwitchUserID: 42, TwitchAccessToken: "access", TwitchRefreshToken: "refresh"}
+
+func TestFindRedemption(t *testing.T) {
+	fake := &fakeHelix{redemptions: []map[string]string{
+		{"id": "r1", "reward_id": "reward", "status": RedemptionUnfulfilled, "user_id": "7", "user_input": "other text"},
+		{"id": "r2", "reward_id": "reward", "status": RedemptionUnfulfilled, "user_id": "8", "user_input": "hello"},
+		{"id": "r3", "reward_id": "reward", "status": RedemptionUnfulfilled, "user_id": "7", "user_input": "hello"},
+	}}
+	client := newFakeHelixClient(t, fake)
+
+	id, err := client.FindRedemption(redemptionUser, "reward", 7, "hello", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "r3", id)
+
+	id, err = client.FindRedemption(redemptionUser, "reward", 9, "hello", nil)
+	require.NoError(t, err)
+	assert.Empty(t, id)
+
+	id, err = client.FindRedemption(redemptionUser, "other_reward", 7, "hello", nil)
+	require.NoError(t, err)
+	assert.Empty(t, id)
+}
+
+func TestFindRedemptionClaimedAndPaged(t *testing.T) {
+	fake := &fakeHelix{}
+	for i := range 60 {
+		fake.redemptions = append(fake.redemptions, map[string]string{
+			"id": "filler" + strconv.Itoa(i), "reward_id": "reward", "status": RedemptionUnfulfilled, "user_id": "8", "user_input": "hello",
+		})
+	}
+	// two identical redeems, past the first page
+	fake.redemptions = append(fake.redemptions,
+		map[string]string{"id": "r1", "reward_id": "reward", "status": RedemptionUnfulfilled, "user_id": "7", "user_input": "hello"},
+		map[string]string{"id": "r2", "reward_id": "reward", "status": RedemptionUnfulfilled, "user_id": "7", "user_input": "hello"},
+	)
+	client := newFakeHelixClient(t, fake)
+
+	id, err := client.FindRedemption(redemptionUser, "reward", 7, "hello", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", id)
+
+	id, err = client.FindRedemption(redemptionUser, "reward", 7, "hello", map[string]bool{"r1": true})
+	require.NoError(t, err)
+	assert.Equal(t, "r2", id, "the second redeem gets its own redemption")
+
+	id, err = client.FindRedemption(redemptionUser, "reward", 7, "hello", map[string]bool{"r1": true, "r2": true})
+	require.NoError(t, err)
+	assert.Empty(t, id)
+}
+
+func TestFindRedemptionRefreshesToken(t *testing.T) {
+	fake := &fakeHelix{token: "fresh", redemptions: []map[string]string{
+		{"id": "r1", "reward_id": "reward", "status": RedemptionUnfulfilled, "user_id": "7", "user_input": "hello"},
+	}}
+	client := newFakeHelixClient(t, fake)
+
+	id, err := client.FindRedemption(redemptionUser, "reward", 7, "hello", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "r1", id)
+	assert.Equal(t, 1, fake.refreshes)
+
+	// a token the refresh does not fix fails instead of looping
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(srv.Close)
+	_, err = New(toServer{srv: srv}, &Config{ClientID: "client", Secret: "secret", HelixURL: srv.URL}).FindRedemption(redemptionUser, "reward", 7, "hello", nil)
+	assert.Error(t, err)
+}
+
+func TestUpdateRedemptionStatus(t *testing.T) {
+	fake := &fakeHelix{}
+	client := newFakeHelixClient(t, fake)
+
+	require.NoError(t, client.UpdateRedemptionStatus(redemptionUser, "reward", "r1", RedemptionCanceled))
+
+	require.Len(t, fake.updates, 1)
+	assert.Equal(t, map[string]string{
+		"id":             "r1",
+		"broadcaster_id": "42",
+		"reward_id":      "reward",
+		"status":         RedemptionCanceled,
+	}, fake.updates[0])
+}
+
+func TestUpdateRedemptionStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":"Forbidden","status":403,"message":"reward not created by this client"}`))
+	}))
+	t.Cleanup(srv.Close)
+	client := New(srv.Client(), &Config{ClientID: "client", Secret: "secret", HelixURL: srv.URL})
+
+	err := client.UpdateRedemptionStatus(redemptionUser, "reward", "r1", RedemptionFulfilled)
+	assert.ErrorContains(t, err, "403")
+}
@@ -0,0 +1,79 @@
+package twitch
+
+import (
+	"fmt"
+	"strconv"
+
+	"app/db"
+
+	"github.com/nicklaw5/helix/v2"
+)
+
+// ApplyRewardSettings copies cost and limits onto reward create params; a
+// zero limit stays disabled.
+func ApplyRewardSettings(params *helix.ChannelCustomRewardsParams, data *db.TwitchRewardData) {
+	data = data.WithDefaults()
+
+	params.Cost = data.Cost
+	params.IsGlobalCooldownEnabled = data.GlobalCooldownSeconds > 0
+	params.GlobalCooldownSeconds = data.GlobalCooldownSeconds
+	params.IsMaxPerStreamEnabled = data.MaxPerStream > 0
+	params.MaxPerStream = data.MaxPerStream
+	params.IsMaxPerUserPerStreamEnabled = data.MaxPerUserPerStream > 0
+	params.MaxPerUserPerStream = data.MaxPerUserPerStream
+}
+
+// UpdateRewardSettings pushes cost and limits onto an existing reward. The
+// PATCH sends every field, so the rest of the reward is read back first to
+// leave title, prompt and state as they are.
+func (c *Client) UpdateRewardSettings(user *db.User, rewardID string, data *db.TwitchRewardData) error {
+	client, err := c.NewHelixClient(user.TwitchAccessToken, user.TwitchRefreshToken)
+	if err != nil {
+		return fmt.Errorf("create helix client: %w", err)
+	}
+
+	broadcasterID := strconv.Itoa(user.TwitchUserID)
+
+	getResp, err := client.GetCustomRewards(&helix.GetCustomRewardsParams{
+		BroadcasterID:         broadcasterID,
+		ID:                    rewardID,
+		OnlyManageableRewards: true,
+	})
+	if err != nil {
+		return fmt.Errorf("helix - get custom reward: %w", err)
+	}
+	if len(getResp.Data.ChannelCustomRewards) == 0 {
+		return fmt.Errorf("helix - get custom reward: reward not found or not created by this app (%s, %s)", getResp.Error, getResp.ErrorMessage)
+	}
+	current := getResp.Data.ChannelCustomRewards[0]
+
+	params := helix.ChannelCustomRewardsParams{}
+	ApplyRewardSettings(&params, data)
+
+	resp, err := client.UpdateCustomReward(&helix.UpdateChannelCustomRewardsParams{
+		ID:                                rewardID,
+		BroadcasterID:                     broadcasterID,
+		Title:                             current.Title,
+		Prompt:                            current.Prompt,
+		IsEnabled:                         current.IsEnabled,
+		BackgroundColor:                   current.BackgroundColor,
+		IsUserInputRequired:               current.IsUserInputRequired,
+		ShouldRedemptionsSkipRequestQueue: current.ShouldRedemptionsSkipRequestQueue,
+
+		Cost:                         params.Cost,
+		IsGlobalCooldownEnabled:      params.IsGlobalCooldownEnabled,
+		GlobalCooldownSeconds:        params.GlobalCooldownSeconds,
+		IsMaxPerStreamEnabled:        params.IsMaxPerStreamEnabled,
+		MaxPerStream:                 params.MaxPerStream,
+		IsMaxPerUserPerStreamEnabled: params.IsMaxPerUserPerStreamEnabled,
+		MaxPerUserPerStream:          params.MaxPerUserPerStream,
+	})
+	if err != nil {
+		return fmt.Errorf("helix - update custom reward: %w", err)
+	}
+	if len(resp.Data.ChannelCustomRewards) == 0 {
+		return fmt.Errorf("helix - update custom reward: no custom reward updated (%s, %s)", resp.Error, resp.ErrorMessage)
+	}
+
+	return nil
+}
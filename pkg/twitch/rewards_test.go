@@ -0,0 +1,63 @@
+package twitch
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"app/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateRewardSettings(t *testing.T) {
+	reward := map[string]any{
+		"id":                     "reward",
+		"title":                  "Forsen TTS",
+		"prompt":                 "Voices: example.com/voices",
+		"is_enabled":             true,
+		"background_color":       "#A970FF",
+		"is_user_input_required": true,
+		"cost":                   10,
+	}
+
+	var patched map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/channel_points/custom_rewards" || r.URL.Query().Get("id") != "reward" {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, "true", r.URL.Query().Get("only_manageable_rewards"))
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": []any{reward}})
+		case http.MethodPatch:
+			body, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(body, &patched))
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": []any{reward}})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client := New(srv.Client(), &Config{ClientID: "client", Secret: "secret", HelixURL: srv.URL})
+
+	err := client.UpdateRewardSettings(redemptionUser, "reward", &db.TwitchRewardData{
+		Cost:         500,
+		MaxPerStream: 3,
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, patched)
+	assert.Equal(t, "Forsen TTS", patched["title"])
+	assert.Equal(t, "Voices: example.com/voices", patched["prompt"])
+	assert.Equal(t, true, patched["is_user_input_required"])
+	assert.EqualValues(t, 500, patched["cost"])
+	assert.Equal(t, true, patched["is_max_per_stream_enabled"])
+	assert.EqualValues(t, 3, patched["max_per_stream"])
+	assert.Equal(t, false, patched["is_global_cooldown_enabled"])
+}